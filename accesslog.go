@@ -0,0 +1,274 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Formats of the access log
+const (
+	AccessLogJSON     = "json"
+	AccessLogCombined = "combined"
+)
+
+// accessLog writes one line per HTTP request of the client listeners, SSE
+// streams are logged when they end
+type accessLog struct {
+	format     string
+	sampleRate float64
+
+	mu  sync.Mutex
+	out io.Writer
+	buf bytes.Buffer
+
+	// Plugin sessions by the SDK session ID of their message endpoint, so
+	// message POSTs are logged with the session they belong to
+	endpoints sync.Map
+}
+
+// openAccessLog opens the output of the access log, nil when it is disabled
+func openAccessLog(cfg *Config) (*accessLog, error) {
+	if !cfg.AccessLog.Enabled {
+		return nil, nil
+	}
+
+	var out io.Writer
+	switch cfg.AccessLog.Output {
+	case "stdout":
+		out = os.Stdout
+	case "stderr":
+		out = os.Stderr
+	default:
+		f, err := os.OpenFile(cfg.AccessLog.Output, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+		if err != nil {
+			return nil, err
+		}
+		out = f
+	}
+
+	return &accessLog{format: cfg.AccessLog.Format, sampleRate: cfg.AccessLog.SampleRate, out: out}, nil
+}
+
+// close closes the output file
+func (l *accessLog) close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if f, ok := l.out.(*os.File); ok && f != os.Stdout && f != os.Stderr {
+		return f.Close()
+	}
+	return nil
+}
+
+// accessEntry is the record of one request, the session handler fills in
+// the session
+type accessEntry struct {
+	time      time.Time
+	remoteIP  string
+	method    string
+	path      string
+	proto     string
+	referer   string
+	userAgent string
+	status    int
+	bytes     int64
+	duration  time.Duration
+	sessionID string
+}
+
+// accessCtxKey carries the access entry of a request to the handlers
+type accessCtxKey struct{}
+
+// setAccessSession records the session of the request being logged
+func setAccessSession(ctx context.Context, sessionID string) {
+	if entry, ok := ctx.Value(accessCtxKey{}).(*accessEntry); ok {
+		entry.sessionID = sessionID
+	}
+}
+
+// logAccess writes an access log line for every request, sampled by
+// access_log.sample_rate; failed requests are always logged
+func (p *Plugin) logAccess(next http.Handler) http.Handler {
+	if p.accessLog == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry := &accessEntry{
+			time:      time.Now(),
+			remoteIP:  remoteIP(r.RemoteAddr),
+			method:    r.Method,
+			path:      r.URL.RequestURI(),
+			proto:     r.Proto,
+			referer:   r.Referer(),
+			userAgent: r.UserAgent(),
+		}
+		if endpoint := r.URL.Query().Get("sessionid"); endpoint != "" {
+			if sessionID, ok := p.accessLog.endpoints.Load(endpoint); ok {
+				entry.sessionID = sessionID.(string)
+			}
+		}
+
+		aw := &accessWriter{ResponseWriter: w}
+		defer func() {
+			entry.status = aw.status
+			if entry.status == 0 {
+				entry.status = http.StatusOK
+			}
+			entry.bytes = aw.bytes
+			entry.duration = time.Since(entry.time)
+			p.accessLog.write(entry)
+		}()
+
+		next.ServeHTTP(aw, r.WithContext(context.WithValue(r.Context(), accessCtxKey{}, entry)))
+	})
+}
+
+// write formats and writes an entry unless it is sampled out
+func (l *accessLog) write(entry *accessEntry) {
+	if entry.status < http.StatusBadRequest && l.sampleRate < 1 && rand.Float64() >= l.sampleRate {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.buf.Reset()
+	if l.format == AccessLogCombined {
+		l.formatCombined(entry)
+	} else {
+		l.formatJSON(entry)
+	}
+	_, _ = l.out.Write(l.buf.Bytes())
+}
+
+// formatJSON writes an entry as a JSON object, must be called under lock
+func (l *accessLog) formatJSON(entry *accessEntry) {
+	_ = json.NewEncoder(&l.buf).Encode(struct {
+		Time      string  `json:"time"`
+		RemoteIP  string  `json:"remote_ip"`
+		Method    string  `json:"method"`
+		Path      string  `json:"path"`
+		Proto     string  `json:"proto"`
+		Status    int     `json:"status"`
+		Bytes     int64   `json:"bytes"`
+		Duration  float64 `json:"duration"`
+		SessionID string  `json:"session_id,omitempty"`
+		Referer   string  `json:"referer,omitempty"`
+		UserAgent string  `json:"user_agent,omitempty"`
+	}{
+		Time:      entry.time.UTC().Format(time.RFC3339Nano),
+		RemoteIP:  entry.remoteIP,
+		Method:    entry.method,
+		Path:      entry.path,
+		Proto:     entry.proto,
+		Status:    entry.status,
+		Bytes:     entry.bytes,
+		Duration:  entry.duration.Seconds(),
+		SessionID: entry.sessionID,
+		Referer:   entry.referer,
+		UserAgent: entry.userAgent,
+	})
+}
+
+// formatCombined writes an entry in the Apache combined format followed by
+// the duration in microseconds and the session ID, must be called under lock
+func (l *accessLog) formatCombined(entry *accessEntry) {
+	fmt.Fprintf(&l.buf, "%s - - [%s] \"%s %s %s\" %d %s \"%s\" \"%s\" %d %s\n",
+		entry.remoteIP,
+		entry.time.Format("02/Jan/2006:15:04:05 -0700"),
+		entry.method,
+		entry.path,
+		entry.proto,
+		entry.status,
+		combinedField(entry.bytes),
+		escapeCombined(entry.referer),
+		escapeCombined(entry.userAgent),
+		entry.duration.Microseconds(),
+		combinedValue(entry.sessionID),
+	)
+}
+
+// combinedField formats a size, "-" for none
+func combinedField(n int64) string {
+	if n == 0 {
+		return "-"
+	}
+	return fmt.Sprint(n)
+}
+
+// combinedValue formats a value, "-" for none
+func combinedValue(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// combinedEscaper escapes quoted fields of the combined format
+var combinedEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// escapeCombined escapes a quoted field of the combined format
+func escapeCombined(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return combinedEscaper.Replace(s)
+}
+
+// remoteIP strips the port of a remote address
+func remoteIP(addr string) string {
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		return host
+	}
+	return addr
+}
+
+// accessWriter records the status and size of a response
+type accessWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader records the status of the response
+func (w *accessWriter) WriteHeader(status int) {
+	if w.status == 0 && status >= http.StatusOK {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write counts the bytes of the response body
+func (w *accessWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush sends buffered data to the client
+func (w *accessWriter) Flush() {
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *accessWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+	"golang.org/x/crypto/acme"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// ACMEConfig obtains the certificates of a listener from an ACME CA such as
+// Let's Encrypt and renews them before they expire
+type ACMEConfig struct {
+	// Host names certificates are requested for, other names are refused
+	Domains []string `json:"domains" mapstructure:"domains"`
+
+	// Directory keeping account keys and certificates across restarts
+	CacheDir string `json:"cacheDir" mapstructure:"cache_dir"`
+
+	// Contact for expiry and problem notices from the CA
+	Email string `json:"email,omitempty" mapstructure:"email"`
+
+	// Directory URL of the CA, Let's Encrypt when empty
+	Directory string `json:"directory,omitempty" mapstructure:"directory"`
+
+	// Address answering HTTP-01 challenges, e.g. ":80". Without it the CA
+	// validates with TLS-ALPN-01 on the listener, which must then be
+	// reachable on port 443.
+	HTTPAddress string `json:"httpAddress,omitempty" mapstructure:"http_address"`
+}
+
+// Validate checks the ACME settings
+func (a *ACMEConfig) Validate() error {
+	if len(a.Domains) == 0 {
+		return errors.Str("domains are required")
+	}
+	for _, domain := range a.Domains {
+		if domain == "" || strings.ContainsAny(domain, "*/: ") {
+			return errors.Errorf("invalid domain %q, wildcards are not supported", domain)
+		}
+	}
+	if a.CacheDir == "" {
+		return errors.Str("cache_dir is required, certificates would be requested again on every start")
+	}
+	return nil
+}
+
+// manager creates the certificate manager, managers with the same cache
+// share certificates and pending challenges
+func (a *ACMEConfig) manager() *autocert.Manager {
+	m := &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		Cache:      autocert.DirCache(a.CacheDir),
+		HostPolicy: autocert.HostWhitelist(a.Domains...),
+		Email:      a.Email,
+	}
+	if a.Directory != "" {
+		m.Client = &acme.Client{DirectoryURL: a.Directory}
+	}
+	return m
+}
+
+// acmeProtocols adds the TLS-ALPN-01 challenge protocol to those of a listener
+func acmeProtocols(protocols []string) []string {
+	return append(slices.Clone(protocols), acme.ALPNProto)
+}
+
+// acmeConfigs returns the ACME settings of all listeners answering HTTP-01
+// challenges
+func (p *Plugin) acmeConfigs() []*ACMEConfig {
+	var configs []*ACMEConfig
+	if p.cfg.Transport != "stdio" && p.cfg.TLS != nil && p.cfg.TLS.ACME != nil && p.cfg.TLS.ACME.HTTPAddress != "" {
+		configs = append(configs, p.cfg.TLS.ACME)
+	}
+	for _, server := range p.cfg.Servers {
+		if server.Address != "" && server.TLS != nil && server.TLS.ACME != nil && server.TLS.ACME.HTTPAddress != "" {
+			configs = append(configs, server.TLS.ACME)
+		}
+	}
+	return configs
+}
+
+// serveACMEChallenges answers HTTP-01 challenges on http_address and
+// redirects other requests to HTTPS
+func (p *Plugin) serveACMEChallenges(cfg *ACMEConfig) error {
+	const op = errors.Op("mcp_serve_acme_challenges")
+
+	srv := &http.Server{
+		Addr:              cfg.HTTPAddress,
+		Handler:           cfg.manager().HTTPHandler(nil),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	p.mu.Lock()
+	if p.ctx.Err() != nil {
+		p.mu.Unlock()
+		return nil
+	}
+	p.acmeServers = append(p.acmeServers, srv)
+	p.mu.Unlock()
+
+	p.log.Info("ACME challenges listening",
+		zap.String("address", cfg.HTTPAddress),
+		zap.Strings("domains", cfg.Domains),
+	)
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// stopACMEChallenges shuts the HTTP-01 challenge listeners down
+func (p *Plugin) stopACMEChallenges(ctx context.Context) {
+	p.mu.RLock()
+	servers := p.acmeServers
+	p.mu.RUnlock()
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil {
+			p.log.Error("failed to shutdown ACME challenge listener", zap.Error(err))
+		}
+	}
+}
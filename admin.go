@@ -0,0 +1,196 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// adminSession is the session view exposed by the admin endpoints,
+// tokens and credentials are deliberately left out
+type adminSession struct {
+	ID            string              `json:"id"`
+	Transport     string              `json:"transport"`
+	ConnectedAt   time.Time           `json:"connectedAt"`
+	LastActivity  time.Time           `json:"lastActivity"`
+	ClientInfo    *mcp.Implementation `json:"clientInfo,omitempty"`
+	Initialized   bool                `json:"initialized"`
+	Authenticated bool                `json:"authenticated"`
+	Scopes        []string            `json:"scopes,omitempty"`
+	Tenant        string              `json:"tenant,omitempty"`
+	Server        string              `json:"server,omitempty"`
+}
+
+// serveAdmin starts the admin listener
+func (p *Plugin) serveAdmin() error {
+	const op = errors.Op("mcp_serve_admin")
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /tools", p.adminTools)
+	mux.HandleFunc("GET /sessions", p.adminSessions)
+	mux.HandleFunc("GET /calls", p.adminCalls)
+	mux.HandleFunc("GET /calls/{id}", p.adminCall)
+	mux.HandleFunc("POST /calls/{id}/replay", p.adminReplayCall)
+	mux.HandleFunc("GET /pool", p.adminPool)
+	mux.HandleFunc("GET /status", p.adminStatus)
+	mux.HandleFunc("POST /loadtest", p.adminLoadTest)
+	mux.HandleFunc("GET /definition", p.adminExportDefinition)
+	mux.HandleFunc("POST /definition", p.adminImportDefinition)
+
+	if p.cfg.Admin.Pprof {
+		mux.HandleFunc("/debug/pprof/", pprof.Index)
+		mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+		mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+		mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+		mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	}
+
+	p.mu.Lock()
+	p.adminServer = &http.Server{
+		Addr:              p.cfg.Admin.Address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	srv := p.adminServer
+	p.mu.Unlock()
+
+	p.log.Info("admin endpoints listening",
+		zap.String("address", p.cfg.Admin.Address),
+		zap.Bool("pprof", p.cfg.Admin.Pprof),
+	)
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// adminTools returns the tool registry
+func (p *Plugin) adminTools(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, &GetToolsResponse{Tools: p.toolInfos(r.URL.Query().Get("namespace"), r.URL.Query().Get("tenant"))})
+}
+
+// adminSessions returns the active sessions
+func (p *Plugin) adminSessions(w http.ResponseWriter, _ *http.Request) {
+	p.mu.RLock()
+	active := p.sessions.snapshot()
+	sessions := make([]*adminSession, 0, len(active))
+	for _, id := range sortedKeys(active) {
+		info := active[id]
+		sessions = append(sessions, &adminSession{
+			ID:            info.ID,
+			Transport:     info.Transport,
+			ConnectedAt:   info.ConnectedAt,
+			LastActivity:  info.lastActivity(),
+			ClientInfo:    info.ClientInfo,
+			Initialized:   info.Session != nil,
+			Authenticated: info.Authenticated,
+			Scopes:        info.Scopes,
+			Tenant:        info.Tenant,
+			Server:        info.Server,
+		})
+	}
+	p.mu.RUnlock()
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"sessions": sessions})
+}
+
+// adminCalls returns the recent tool calls, newest first
+func (p *Plugin) adminCalls(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	writeJSON(w, http.StatusOK, map[string]interface{}{"calls": p.recentCalls(r.URL.Query().Get("tool"), limit)})
+}
+
+// adminCall returns a single recorded call
+func (p *Plugin) adminCall(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid call id")
+		return
+	}
+
+	record, ok := p.calls.find(id)
+	if !ok {
+		writeJSONError(w, http.StatusNotFound, "call not found")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, record)
+}
+
+// adminReplayCall re-executes a recorded call and returns its result
+func (p *Plugin) adminReplayCall(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseUint(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid call id")
+		return
+	}
+
+	result, err := p.replayCall(r.Context(), id)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// adminPool returns the worker pool state
+func (p *Plugin) adminPool(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{"workers": p.Workers()})
+}
+
+// adminStatus returns the readiness reported to the status plugin with the
+// session counts, which its status codes cannot carry
+func (p *Plugin) adminStatus(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"ready":    p.ready.Load(),
+		"sessions": p.sessionStats(0),
+	})
+}
+
+// adminLoadTest runs a load test described by the JSON body
+func (p *Plugin) adminLoadTest(w http.ResponseWriter, r *http.Request) {
+	var req LoadTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid load test request: "+err.Error())
+		return
+	}
+
+	resp, err := p.loadTest(r.Context(), &req)
+	if err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// adminExportDefinition returns the server definition declared by PHP
+func (p *Plugin) adminExportDefinition(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, p.exportDefinition())
+}
+
+// adminImportDefinition replaces the server definition declared by PHP
+func (p *Plugin) adminImportDefinition(w http.ResponseWriter, r *http.Request) {
+	var def ServerDefinition
+	if err := json.NewDecoder(r.Body).Decode(&def); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid server definition: "+err.Error())
+		return
+	}
+
+	var resp ImportDefinitionResponse
+	if err := p.importDefinition(&def, &resp); err != nil {
+		writeJSONError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	writeJSON(w, http.StatusOK, &resp)
+}
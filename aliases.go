@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// aliasMiddleware routes calls of a tool's former name to the tool. It runs
+// before the server and tenant middleware, which only know the tool's name.
+func (p *Plugin) aliasMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok {
+			return next(ctx, method, req)
+		}
+
+		target, ok := p.resolveAlias(p.sessionTenant(sessionIDFromContext(ctx)), params.Name)
+		if !ok {
+			return next(ctx, method, req)
+		}
+
+		p.aliasCalls.add(params.Name, target)
+		p.callLogger(ctx).Debug("tool called by alias",
+			zap.String("alias", params.Name),
+			zap.String("tool", target),
+		)
+
+		params.Name = target
+		return next(ctx, method, req)
+	}
+}
+
+// resolveAlias returns the tool an alias called by a session of tenant
+// routes to. Tools shadow aliases of the same name; aliases declared by a
+// tenant shadow shared ones, which shadow tools.aliases.
+func (p *Plugin) resolveAlias(tenant, name string) (string, bool) {
+	tools := p.toolSnapshot()
+	if _, ok := tools[tenantName(tenant, name)]; ok {
+		return "", false
+	}
+	if _, ok := tools[name]; ok {
+		return "", false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if target, ok := p.aliases[tenantName(tenant, name)]; ok {
+		return target, true
+	}
+	if target, ok := p.aliases[name]; ok {
+		return target, true
+	}
+	target, ok := p.cfg.Tools.Aliases[name]
+	return target, ok
+}
+
+// setAliases routes the aliases of a tool declared by PHP to target, must
+// be called under lock
+func (p *Plugin) setAliases(entry *toolEntry, tenant, target string, aliases []string) {
+	for _, alias := range aliases {
+		key := tenantName(tenant, alias)
+		p.aliases[key] = target
+		entry.Aliases = append(entry.Aliases, key)
+	}
+}
+
+// removeAliases removes the aliases of a tool, must be called under lock
+func (p *Plugin) removeAliases(entry *toolEntry) {
+	if entry == nil {
+		return
+	}
+
+	for _, key := range entry.Aliases {
+		delete(p.aliases, key)
+	}
+}
+
+// aliasCallKey identifies a counter of calls by alias
+type aliasCallKey struct {
+	alias string
+	tool  string
+}
+
+// aliasCalls counts tool calls made by an alias
+type aliasCalls struct {
+	mu     sync.Mutex
+	counts map[aliasCallKey]uint64
+}
+
+func newAliasCalls() *aliasCalls {
+	return &aliasCalls{counts: make(map[aliasCallKey]uint64)}
+}
+
+// add counts a call by alias
+func (a *aliasCalls) add(alias, tool string) {
+	a.mu.Lock()
+	a.counts[aliasCallKey{alias: alias, tool: tool}]++
+	a.mu.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (a *aliasCalls) snapshot() map[aliasCallKey]uint64 {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	counts := make(map[aliasCallKey]uint64, len(a.counts))
+	for k, v := range a.counts {
+		counts[k] = v
+	}
+	return counts
+}
@@ -0,0 +1,257 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// argumentsMiddleware completes and converts tool call arguments by the
+// declared input schema before the SDK validates them: defaults of nested
+// properties are filled in with tools.arguments.defaults, values of the
+// wrong type are converted with tools.arguments.coerce.
+func (p *Plugin) argumentsMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		opts := p.cfg.Tools.Arguments
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok || (!opts.Defaults && !opts.Coerce) {
+			return next(ctx, method, req)
+		}
+
+		schema := p.toolSchema(params.Name)
+		if schema == nil {
+			return next(ctx, method, req)
+		}
+
+		var args interface{} = map[string]interface{}{}
+		if len(params.Arguments) > 0 {
+			dec := json.NewDecoder(bytes.NewReader(params.Arguments))
+			dec.UseNumber()
+			if err := dec.Decode(&args); err != nil {
+				// Rejected by the SDK with its usual error
+				return next(ctx, method, req)
+			}
+		}
+
+		n := &argumentNormalizer{defaults: opts.Defaults, coerce: opts.Coerce}
+		args = n.normalize(schema, args, "")
+		if len(n.changes) == 0 {
+			return next(ctx, method, req)
+		}
+
+		data, err := json.Marshal(args)
+		if err != nil {
+			return next(ctx, method, req)
+		}
+		params.Arguments = data
+
+		p.callLogger(ctx).Debug("tool arguments normalized",
+			zap.String("tool", params.Name),
+			zap.Strings("changes", n.changes),
+		)
+
+		return next(ctx, method, req)
+	}
+}
+
+// toolSchema returns the input schema of a registered tool
+func (p *Plugin) toolSchema(name string) map[string]interface{} {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if entry, ok := p.tools[name]; ok {
+		return entry.Schema
+	}
+	return nil
+}
+
+// argumentNormalizer applies a schema to decoded arguments and records
+// what it changed by JSON pointer
+type argumentNormalizer struct {
+	defaults bool
+	coerce   bool
+	changes  []string
+}
+
+// normalize returns v converted to the types of schema, with the defaults
+// of missing optional properties filled in
+func (n *argumentNormalizer) normalize(schema map[string]interface{}, v interface{}, path string) interface{} {
+	if n.coerce {
+		if coerced, ok := coerceValue(schemaTypes(schema), v); ok {
+			n.changes = append(n.changes, "coerced "+pointer(path))
+			v = coerced
+		}
+	}
+
+	switch value := v.(type) {
+	case map[string]interface{}:
+		required := schemaRequired(schema)
+		props := schemaProperties(schema)
+		for _, name := range sortedKeys(props) {
+			prop, ok := props[name].(map[string]interface{})
+			if !ok {
+				continue
+			}
+			propPath := path + "/" + escapePointer(name)
+
+			if current, ok := value[name]; ok {
+				value[name] = n.normalize(prop, current, propPath)
+				continue
+			}
+
+			// Required properties have no defaults, their absence is an error
+			def, ok := prop["default"]
+			if !n.defaults || !ok || required[name] {
+				continue
+			}
+			value[name] = n.normalize(prop, copyJSONValue(def), propPath)
+			n.changes = append(n.changes, "default "+propPath)
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i := range value {
+				value[i] = n.normalize(items, value[i], path+"/"+strconv.Itoa(i))
+			}
+		}
+	}
+
+	return v
+}
+
+// schemaTypes returns the types a schema allows, none when it does not
+// declare a type
+func schemaTypes(schema map[string]interface{}) []string {
+	switch t := schema["type"].(type) {
+	case string:
+		return []string{t}
+	case []interface{}:
+		types := make([]string, 0, len(t))
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				types = append(types, s)
+			}
+		}
+		return types
+	}
+	return nil
+}
+
+// jsonType returns the JSON Schema type of a decoded value, numbers without
+// a fraction are integers
+func jsonType(v interface{}) string {
+	switch value := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case json.Number:
+		if _, err := value.Int64(); err == nil {
+			return "integer"
+		}
+		if f, err := value.Float64(); err == nil && f == float64(int64(f)) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return ""
+}
+
+// coerceValue converts v to the first of types it can be converted to
+// without losing information. Values already of an allowed type stay as is.
+func coerceValue(types []string, v interface{}) (interface{}, bool) {
+	if len(types) == 0 {
+		return nil, false
+	}
+
+	actual := jsonType(v)
+	if slices.Contains(types, actual) || (actual == "integer" && slices.Contains(types, "number")) {
+		return nil, false
+	}
+
+	for _, t := range types {
+		switch t {
+		case "integer":
+			// Whole numbers written with a fraction or exponent are integers too
+			if s, ok := v.(string); ok {
+				s = strings.TrimSpace(s)
+				if f, err := strconv.ParseFloat(s, 64); err == nil && f == math.Trunc(f) && json.Valid([]byte(s)) {
+					return json.Number(s), true
+				}
+			}
+		case "number":
+			if s, ok := v.(string); ok {
+				s = strings.TrimSpace(s)
+				if _, err := strconv.ParseFloat(s, 64); err == nil && json.Valid([]byte(s)) {
+					return json.Number(s), true
+				}
+			}
+		case "boolean":
+			if s, ok := v.(string); ok {
+				switch strings.ToLower(strings.TrimSpace(s)) {
+				case "true":
+					return true, true
+				case "false":
+					return false, true
+				}
+			}
+		case "string":
+			switch value := v.(type) {
+			case json.Number:
+				return value.String(), true
+			case bool:
+				return strconv.FormatBool(value), true
+			}
+		case "array":
+			if actual != "null" {
+				return []interface{}{v}, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// copyJSONValue copies a decoded JSON value, defaults are shared by all calls
+func copyJSONValue(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			copied[k] = copyJSONValue(item)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(value))
+		for i, item := range value {
+			copied[i] = copyJSONValue(item)
+		}
+		return copied
+	}
+	return v
+}
+
+// escapePointer escapes a property name as a JSON pointer token
+func escapePointer(name string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(name)
+}
+
+// pointer returns the JSON pointer of the arguments root as "/"
+func pointer(path string) string {
+	if path == "" {
+		return "/"
+	}
+	return path
+}
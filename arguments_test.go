@@ -0,0 +1,224 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"slices"
+	"testing"
+)
+
+// decodeArguments decodes JSON the way argumentsMiddleware does
+func decodeArguments(t *testing.T, data string) interface{} {
+	t.Helper()
+
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader([]byte(data)))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		t.Fatalf("decode %s: %v", data, err)
+	}
+	return v
+}
+
+func TestArgumentNormalizer(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		args     string
+		defaults bool
+		coerce   bool
+		want     string
+		changes  []string
+	}{
+		{
+			name:     "nested defaults",
+			schema:   `{"type":"object","properties":{"page":{"type":"object","properties":{"size":{"type":"integer","default":20},"sort":{"type":"string","default":"asc"}}}}}`,
+			args:     `{"page":{"sort":"desc"}}`,
+			defaults: true,
+			want:     `{"page":{"size":20,"sort":"desc"}}`,
+			changes:  []string{"default /page/size"},
+		},
+		{
+			name:     "nested defaults of a defaulted object",
+			schema:   `{"type":"object","properties":{"page":{"type":"object","default":{},"properties":{"size":{"type":"integer","default":20}}}}}`,
+			args:     `{}`,
+			defaults: true,
+			want:     `{"page":{"size":20}}`,
+			changes:  []string{"default /page/size", "default /page"},
+		},
+		{
+			name:   "defaults disabled",
+			schema: `{"type":"object","properties":{"size":{"type":"integer","default":20}}}`,
+			args:   `{}`,
+			coerce: true,
+			want:   `{}`,
+		},
+		{
+			name:     "required property without default",
+			schema:   `{"type":"object","required":["query"],"properties":{"query":{"type":"string"},"limit":{"type":"integer","default":10}}}`,
+			args:     `{}`,
+			defaults: true,
+			want:     `{"limit":10}`,
+			changes:  []string{"default /limit"},
+		},
+		{
+			name:     "required property with default",
+			schema:   `{"type":"object","required":["query"],"properties":{"query":{"type":"string","default":"*"}}}`,
+			args:     `{}`,
+			defaults: true,
+			want:     `{}`,
+		},
+		{
+			name:   "integer for number",
+			schema: `{"type":"object","properties":{"price":{"type":"number"}}}`,
+			args:   `{"price":3}`,
+			coerce: true,
+			want:   `{"price":3}`,
+		},
+		{
+			name:    "number string for number",
+			schema:  `{"type":"object","properties":{"price":{"type":"number"}}}`,
+			args:    `{"price":"3.5"}`,
+			coerce:  true,
+			want:    `{"price":3.5}`,
+			changes: []string{"coerced /price"},
+		},
+		{
+			name:   "fraction string for integer",
+			schema: `{"type":"object","properties":{"count":{"type":"integer"}}}`,
+			args:   `{"count":"3.5"}`,
+			coerce: true,
+			want:   `{"count":"3.5"}`,
+		},
+		{
+			name:    "whole number string for integer",
+			schema:  `{"type":"object","properties":{"count":{"type":"integer"}}}`,
+			args:    `{"count":"1.0"}`,
+			coerce:  true,
+			want:    `{"count":1.0}`,
+			changes: []string{"coerced /count"},
+		},
+		{
+			name:   "whole number for integer",
+			schema: `{"type":"object","properties":{"count":{"type":"integer"}}}`,
+			args:   `{"count":1.0}`,
+			coerce: true,
+			want:   `{"count":1.0}`,
+		},
+		{
+			name:    "scalar into array",
+			schema:  `{"type":"object","properties":{"tags":{"type":"array","items":{"type":"string"}}}}`,
+			args:    `{"tags":5}`,
+			coerce:  true,
+			want:    `{"tags":["5"]}`,
+			changes: []string{"coerced /tags", "coerced /tags/0"},
+		},
+		{
+			name:   "null not wrapped into array",
+			schema: `{"type":"object","properties":{"tags":{"type":"array"}}}`,
+			args:   `{"tags":null}`,
+			coerce: true,
+			want:   `{"tags":null}`,
+		},
+		{
+			name:    "boolean string",
+			schema:  `{"type":"object","properties":{"dry":{"type":["boolean","null"]}}}`,
+			args:    `{"dry":" TRUE "}`,
+			coerce:  true,
+			want:    `{"dry":true}`,
+			changes: []string{"coerced /dry"},
+		},
+		{
+			name:     "escaped pointers",
+			schema:   `{"type":"object","properties":{"a/b":{"type":"string"},"c~d":{"type":"integer","default":1}}}`,
+			args:     `{"a/b":7}`,
+			defaults: true,
+			coerce:   true,
+			want:     `{"a/b":"7","c~d":1}`,
+			changes:  []string{"coerced /a~1b", "default /c~0d"},
+		},
+		{
+			name:    "root",
+			schema:  `{"type":"array","items":{"type":"integer"}}`,
+			args:    `"2"`,
+			coerce:  true,
+			want:    `[2]`,
+			changes: []string{"coerced /", "coerced /0"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, _ := decodeArguments(t, tt.schema).(map[string]interface{})
+
+			n := &argumentNormalizer{defaults: tt.defaults, coerce: tt.coerce}
+			got, err := json.Marshal(n.normalize(schema, decodeArguments(t, tt.args), ""))
+			if err != nil {
+				t.Fatalf("marshal: %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Errorf("arguments = %s, want %s", got, tt.want)
+			}
+			if !slices.Equal(n.changes, tt.changes) {
+				t.Errorf("changes = %q, want %q", n.changes, tt.changes)
+			}
+		})
+	}
+}
+
+func TestArgumentNormalizerCopiesDefaults(t *testing.T) {
+	schema, _ := decodeArguments(t, `{"type":"object","properties":{"filter":{"type":"object","default":{"tags":[]}}}}`).(map[string]interface{})
+
+	n := &argumentNormalizer{defaults: true}
+	args := n.normalize(schema, map[string]interface{}{}, "").(map[string]interface{})
+	args["filter"].(map[string]interface{})["tags"] = []interface{}{"changed"}
+
+	again, _ := json.Marshal(n.normalize(schema, map[string]interface{}{}, ""))
+	if string(again) != `{"filter":{"tags":[]}}` {
+		t.Errorf("default changed by an earlier call: %s", again)
+	}
+}
+
+func TestJSONType(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{`null`, "null"},
+		{`true`, "boolean"},
+		{`"1"`, "string"},
+		{`1`, "integer"},
+		{`-7`, "integer"},
+		{`1.0`, "integer"},
+		{`1e3`, "integer"},
+		{`1.5`, "number"},
+		{`1e-3`, "number"},
+		{`[1]`, "array"},
+		{`{}`, "object"},
+	}
+
+	for _, tt := range tests {
+		if got := jsonType(decodeArguments(t, tt.value)); got != tt.want {
+			t.Errorf("jsonType(%s) = %q, want %q", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestEscapePointer(t *testing.T) {
+	tests := map[string]string{
+		"name":  "name",
+		"a/b":   "a~1b",
+		"a~b":   "a~0b",
+		"~1":    "~01",
+		"/~/":   "~1~0~1",
+		"":      "",
+		"a b.c": "a b.c",
+	}
+
+	for name, want := range tests {
+		if got := escapePointer(name); got != want {
+			t.Errorf("escapePointer(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
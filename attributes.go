@@ -0,0 +1,215 @@
+package mcp
+
+import (
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// Limits of the attributes PHP attaches to a session
+const (
+	maxSessionAttributes     = 64
+	maxSessionAttributeValue = 1024
+)
+
+// metricLabelName matches valid Prometheus label names
+var metricLabelName = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// setSessionAttributes merges attributes into a session, empty values remove
+// keys, replace drops the previous attributes first. The session's map is
+// replaced rather than modified, so snapshots stay valid without the lock.
+func (p *Plugin) setSessionAttributes(sessionID string, attributes map[string]string, replace bool) (map[string]string, error) {
+	const op = errors.Op("mcp_set_session_attributes")
+
+	for key, value := range attributes {
+		if key == "" {
+			return nil, errors.E(op, errors.Str("attribute names must not be empty"))
+		}
+		if len(value) > maxSessionAttributeValue {
+			return nil, errors.E(op, errors.Errorf("attribute %q is longer than %d bytes", key, maxSessionAttributeValue))
+		}
+	}
+
+	p.mu.Lock()
+	info, ok := p.sessions.get(sessionID)
+	if !ok {
+		p.mu.Unlock()
+		return nil, errors.E(op, errors.Errorf("unknown session: %s", sessionID))
+	}
+
+	merged := make(map[string]string, len(info.Attributes)+len(attributes))
+	if !replace {
+		maps.Copy(merged, info.Attributes)
+	}
+	for key, value := range attributes {
+		if value == "" {
+			delete(merged, key)
+			continue
+		}
+		merged[key] = value
+	}
+	if len(merged) > maxSessionAttributes {
+		p.mu.Unlock()
+		return nil, errors.E(op, errors.Errorf("sessions carry at most %d attributes", maxSessionAttributes))
+	}
+	info.Attributes = merged
+	p.mu.Unlock()
+
+	p.log.Debug("session attributes set",
+		zap.String("session_id", sessionID),
+		zap.Int("attributes", len(merged)),
+	)
+
+	return maps.Clone(merged), nil
+}
+
+// sessionAttributes returns the attributes of a session, the map must not be
+// modified
+func (p *Plugin) sessionAttributes(sessionID string) map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if info, ok := p.sessions.get(sessionID); ok {
+		return info.Attributes
+	}
+
+	return nil
+}
+
+// otherMetricValue replaces label values that are not allowed or exceed
+// clients.metric_max_values
+const otherMetricValue = "other"
+
+// sessionMetricLabels returns the session labels of mcp_session_calls_total,
+// the tenant first when enabled
+func sessionMetricLabels(cfg *Config) []string {
+	labels := make([]string, 0, len(cfg.Clients.MetricAttributes)+1)
+	if cfg.Clients.MetricTenant {
+		labels = append(labels, "tenant")
+	}
+	return append(labels, cfg.Clients.MetricAttributes...)
+}
+
+// attributedCalls counts tool calls by the session tenant and attributes
+// listed in clients.metric_attributes. Each label keeps at most maxValues
+// distinct values, so sessions with unique attributes cannot create a
+// series each.
+type attributedCalls struct {
+	log       *zap.Logger
+	tenant    bool
+	labels    []string
+	allowed   []map[string]struct{} // nil allows any value
+	maxValues int
+
+	mu     sync.Mutex
+	seen   []map[string]struct{} // values counted per label
+	capped []bool                // whether the cap of a label was logged
+	counts map[string]uint64     // tool, outcome and label values joined by \x00
+}
+
+func newAttributedCalls(cfg *Config, log *zap.Logger) *attributedCalls {
+	c := &attributedCalls{
+		log:       log,
+		tenant:    cfg.Clients.MetricTenant,
+		labels:    sessionMetricLabels(cfg),
+		maxValues: cfg.Clients.MetricMaxValues,
+		counts:    make(map[string]uint64),
+	}
+
+	for _, label := range c.labels {
+		var allowed map[string]struct{}
+		if values, ok := cfg.Clients.MetricValues[label]; ok {
+			allowed = make(map[string]struct{}, len(values))
+			for _, value := range values {
+				allowed[value] = struct{}{}
+			}
+		}
+		c.allowed = append(c.allowed, allowed)
+		c.seen = append(c.seen, make(map[string]struct{}))
+	}
+	c.capped = make([]bool, len(c.labels))
+
+	return c
+}
+
+// enabled reports whether calls are labelled by any session label
+func (c *attributedCalls) enabled() bool {
+	return len(c.labels) > 0
+}
+
+// add counts a finished call, missing attributes count as empty values
+func (c *attributedCalls) add(tool, outcome, tenant string, attributes map[string]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	values := make([]string, 0, len(c.labels)+2)
+	values = append(values, tool, outcome)
+	for i, label := range c.labels {
+		var value string
+		if c.tenant && i == 0 {
+			value = tenant
+		} else {
+			value = attributes[label]
+		}
+		values = append(values, c.value(i, value))
+	}
+
+	c.counts[strings.Join(values, "\x00")]++
+}
+
+// value returns the label value a session value is counted as, must be
+// called under lock
+func (c *attributedCalls) value(i int, value string) string {
+	if value == "" {
+		return value
+	}
+	if c.allowed[i] != nil {
+		if _, ok := c.allowed[i][value]; !ok {
+			return otherMetricValue
+		}
+		return value
+	}
+
+	if _, ok := c.seen[i][value]; ok {
+		return value
+	}
+	if len(c.seen[i]) >= c.maxValues {
+		if !c.capped[i] {
+			c.capped[i] = true
+			c.log.Warn("metric label reached clients.metric_max_values, further values are counted as other",
+				zap.String("label", c.labels[i]),
+				zap.Int("max_values", c.maxValues),
+			)
+		}
+		return otherMetricValue
+	}
+	c.seen[i][value] = struct{}{}
+
+	return value
+}
+
+// snapshot returns the label values and counts of the counters
+func (c *attributedCalls) snapshot() map[string]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return maps.Clone(c.counts)
+}
+
+// validateMetricAttributes checks the attribute names used as metric labels
+func validateMetricAttributes(names []string, tenant bool) error {
+	for i, name := range names {
+		if !metricLabelName.MatchString(name) || strings.HasPrefix(name, "__") {
+			return errors.Errorf("clients.metric_attributes: %q is not a valid label name", name)
+		}
+		if name == "tool" || name == "outcome" || (tenant && name == "tenant") || slices.Contains(names[:i], name) {
+			return errors.Errorf("clients.metric_attributes: duplicate label %q", name)
+		}
+	}
+	return nil
+}
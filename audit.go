@@ -0,0 +1,335 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// Built-in audit sink types, other types name a collected AuditSink
+const (
+	AuditSinkFile   = "file"
+	AuditSinkSyslog = "syslog"
+	AuditSinkHTTP   = "http"
+	AuditSinkJobs   = "jobs"
+)
+
+// auditEventToolCall is the event of audit records of finished tool calls
+const auditEventToolCall = "tool.call"
+
+// AuditRecord is shipped to the audit sinks for every finished tool call
+type AuditRecord struct {
+	Time      time.Time       `json:"time"`
+	Event     string          `json:"event"`
+	RequestID string          `json:"requestId,omitempty"`
+	SessionID string          `json:"sessionId"`
+	Transport string          `json:"transport,omitempty"`
+	Tenant    string          `json:"tenant,omitempty"`
+	Tool      string          `json:"tool"`
+	Arguments json.RawMessage `json:"arguments,omitempty"` // Redacted
+	Duration  time.Duration   `json:"duration"`
+	IsError   bool            `json:"isError"`
+	Summary   string          `json:"summary,omitempty"` // Beginning of the text result
+	Error     string          `json:"error,omitempty"`
+	ErrorInfo *ErrorInfo      `json:"errorInfo,omitempty"`
+
+	// Replace Arguments and Summary with privacy.enabled
+	ArgumentsHash string `json:"argumentsHash,omitempty"`
+	ResultHash    string `json:"resultHash,omitempty"`
+}
+
+// AuditSink is implemented by RoadRunner plugins shipping audit records,
+// e.g. to a message broker. Sinks configured with the type of the plugin's
+// name receive the batches; a failed batch is retried.
+type AuditSink interface {
+	// Name returns the sink type referenced in audit.sinks
+	Name() string
+	// WriteAudit ships a batch of records
+	WriteAudit(ctx context.Context, records []*AuditRecord) error
+}
+
+// auditWriter ships batches to one destination
+type auditWriter interface {
+	write(ctx context.Context, records []*AuditRecord) error
+	close() error
+}
+
+// pluginAuditWriter ships batches to a collected AuditSink
+type pluginAuditWriter struct {
+	sink AuditSink
+}
+
+func (w *pluginAuditWriter) write(ctx context.Context, records []*AuditRecord) error {
+	return w.sink.WriteAudit(ctx, records)
+}
+
+func (w *pluginAuditWriter) close() error { return nil }
+
+// auditLog fans audit records out to the configured sinks
+type auditLog struct {
+	shippers []*auditShipper
+}
+
+// openAuditLog opens the configured sinks and starts shipping to them, nil
+// without sinks
+func (p *Plugin) openAuditLog() (*auditLog, error) {
+	const op = errors.Op("mcp_open_audit_log")
+
+	if len(p.cfg.Audit.Sinks) == 0 {
+		return nil, nil
+	}
+
+	l := &auditLog{}
+	for _, name := range sortedKeys(p.cfg.Audit.Sinks) {
+		cfg := p.cfg.Audit.Sinks[name]
+
+		w, err := p.newAuditWriter(cfg)
+		if err != nil {
+			l.stop(context.Background())
+			return nil, errors.E(op, errors.Errorf("audit.sinks.%s: %v", name, err))
+		}
+
+		s := &auditShipper{
+			name:    name,
+			cfg:     cfg,
+			w:       w,
+			log:     p.log,
+			queue:   make(chan *AuditRecord, cfg.QueueSize),
+			stopped: make(chan struct{}),
+			done:    make(chan struct{}),
+		}
+		go s.run()
+
+		l.shippers = append(l.shippers, s)
+	}
+
+	return l, nil
+}
+
+// newAuditWriter opens the destination of a sink
+func (p *Plugin) newAuditWriter(cfg *AuditSinkConfig) (auditWriter, error) {
+	switch cfg.Type {
+	case AuditSinkFile:
+		return newFileAuditWriter(cfg.Path)
+	case AuditSinkSyslog:
+		return newSyslogAuditWriter(cfg), nil
+	case AuditSinkHTTP:
+		return newHTTPAuditWriter(cfg), nil
+	case AuditSinkJobs:
+		p.mu.RLock()
+		jobs := p.jobs
+		p.mu.RUnlock()
+		if jobs == nil {
+			return nil, errors.Str("jobs plugin is not available")
+		}
+		return &jobsAuditWriter{jobs: jobs, pipeline: cfg.Pipeline}, nil
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	idx := slices.IndexFunc(p.auditSinkPlugins, func(s AuditSink) bool { return s.Name() == cfg.Type })
+	if idx < 0 {
+		return nil, errors.Errorf("unknown sink type %q", cfg.Type)
+	}
+
+	return &pluginAuditWriter{sink: p.auditSinkPlugins[idx]}, nil
+}
+
+// add queues a record for every sink without blocking, records are dropped
+// while a sink's queue is full
+func (l *auditLog) add(record *AuditRecord) {
+	if l == nil {
+		return
+	}
+
+	for _, s := range l.shippers {
+		select {
+		case s.queue <- record:
+		default:
+			s.dropped.Add(1)
+			s.log.Warn("audit queue full, record dropped", zap.String("sink", s.name))
+		}
+	}
+}
+
+// stop ships the queued records and closes the sinks, records left when ctx
+// ends are dropped
+func (l *auditLog) stop(ctx context.Context) {
+	if l == nil {
+		return
+	}
+
+	var wg sync.WaitGroup
+	for _, s := range l.shippers {
+		wg.Go(func() { s.stop(ctx) })
+	}
+	wg.Wait()
+}
+
+// auditShipper batches the records of one sink and ships them with retries
+type auditShipper struct {
+	name string
+	cfg  *AuditSinkConfig
+	w    auditWriter
+	log  *zap.Logger
+
+	queue   chan *AuditRecord
+	stopped chan struct{} // closed by stop
+	done    chan struct{} // closed once the queue is shipped
+
+	// Context the final batches are shipped with, set before stopped is closed
+	stopCtx context.Context
+
+	shipped atomic.Uint64
+	dropped atomic.Uint64
+}
+
+// run ships batches once they are full or audit.sinks.*.flush_interval passed
+func (s *auditShipper) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]*AuditRecord, 0, s.cfg.BatchSize)
+	for {
+		select {
+		case record := <-s.queue:
+			batch = append(batch, record)
+			if len(batch) < s.cfg.BatchSize {
+				continue
+			}
+		case <-ticker.C:
+			if len(batch) == 0 {
+				continue
+			}
+		case <-s.stopped:
+			for {
+				select {
+				case record := <-s.queue:
+					batch = append(batch, record)
+					if len(batch) == s.cfg.BatchSize {
+						s.ship(s.stopCtx, batch)
+						batch = batch[:0]
+					}
+					continue
+				default:
+				}
+				break
+			}
+			if len(batch) > 0 {
+				s.ship(s.stopCtx, batch)
+			}
+			if err := s.w.close(); err != nil {
+				s.log.Warn("failed to close audit sink", zap.String("sink", s.name), zap.Error(err))
+			}
+			return
+		}
+
+		s.ship(context.Background(), batch)
+		batch = batch[:0]
+	}
+}
+
+// ship writes a batch, retrying failures with a doubling backoff up to
+// audit.sinks.*.max_retries times before the batch is dropped
+func (s *auditShipper) ship(ctx context.Context, batch []*AuditRecord) {
+	backoff := s.cfg.RetryBackoff
+	for attempt := 0; ; attempt++ {
+		writeCtx, cancel := context.WithTimeout(ctx, s.cfg.Timeout)
+		err := s.w.write(writeCtx, batch)
+		cancel()
+		if err == nil {
+			s.shipped.Add(uint64(len(batch)))
+			return
+		}
+
+		if attempt == s.cfg.MaxRetries || ctx.Err() != nil {
+			s.dropped.Add(uint64(len(batch)))
+			s.log.Error("failed to ship audit records, batch dropped",
+				zap.String("sink", s.name),
+				zap.Int("records", len(batch)),
+				zap.Error(err),
+			)
+			return
+		}
+
+		s.log.Warn("failed to ship audit records, retrying",
+			zap.String("sink", s.name),
+			zap.Int("attempt", attempt+1),
+			zap.Duration("backoff", backoff),
+			zap.Error(err),
+		)
+
+		select {
+		case <-ctx.Done():
+		case <-s.stopped:
+			// Shutting down, the remaining attempts are made without waiting
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// stop ships the queued records within ctx and waits for the shipper to end
+func (s *auditShipper) stop(ctx context.Context) {
+	s.stopCtx = ctx
+	close(s.stopped)
+
+	select {
+	case <-s.done:
+	case <-ctx.Done():
+	}
+}
+
+// auditStats are the shipped and dropped records of a sink
+type auditStats struct {
+	shipped uint64
+	dropped uint64
+}
+
+// snapshot returns the record counts by sink
+func (l *auditLog) snapshot() map[string]auditStats {
+	stats := make(map[string]auditStats)
+	if l == nil {
+		return stats
+	}
+
+	for _, s := range l.shippers {
+		stats[s.name] = auditStats{shipped: s.shipped.Load(), dropped: s.dropped.Load()}
+	}
+	return stats
+}
+
+// auditCall ships the record of a finished tool call
+func (p *Plugin) auditCall(record *CallRecord) {
+	if p.audit == nil {
+		return
+	}
+
+	p.audit.add(&AuditRecord{
+		Time:      record.StartedAt,
+		Event:     auditEventToolCall,
+		RequestID: record.RequestID,
+		SessionID: record.SessionID,
+		Transport: p.sessionTransport(record.SessionID),
+		Tenant:    p.sessionTenant(record.SessionID),
+		Tool:      record.Tool,
+		Arguments: record.Arguments,
+		Duration:  record.Duration,
+		IsError:   record.IsError,
+		Summary:   record.Summary,
+		Error:     record.Error,
+		ErrorInfo: record.ErrorInfo,
+
+		ArgumentsHash: record.ArgumentsHash,
+		ResultHash:    record.ResultHash,
+	})
+}
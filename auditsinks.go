@@ -0,0 +1,227 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/roadrunner-server/errors"
+)
+
+// fileAuditWriter appends records to a file as JSON lines
+type fileAuditWriter struct {
+	f *os.File
+}
+
+func newFileAuditWriter(path string) (*fileAuditWriter, error) {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o640)
+	if err != nil {
+		return nil, err
+	}
+	return &fileAuditWriter{f: f}, nil
+}
+
+func (w *fileAuditWriter) write(_ context.Context, records []*AuditRecord) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return err
+		}
+	}
+	_, err := w.f.Write(buf.Bytes())
+	return err
+}
+
+func (w *fileAuditWriter) close() error {
+	return w.f.Close()
+}
+
+// Syslog priority of audit records, facility local0
+const (
+	syslogFacilityLocal0 = 16
+	syslogSeverityInfo   = 6
+	syslogSeverityNotice = 5
+)
+
+// syslogAuditWriter sends records as RFC 5424 messages with the JSON record
+// as the message. Stream connections frame messages by octet counting
+// (RFC 6587) and are dialed again after a failure.
+type syslogAuditWriter struct {
+	network  string
+	address  string
+	appName  string
+	hostname string
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+func newSyslogAuditWriter(cfg *AuditSinkConfig) *syslogAuditWriter {
+	hostname, _ := os.Hostname()
+	if hostname == "" {
+		hostname = "-"
+	}
+
+	return &syslogAuditWriter{
+		network:  cfg.Network,
+		address:  cfg.Address,
+		appName:  cfg.AppName,
+		hostname: hostname,
+	}
+}
+
+func (w *syslogAuditWriter) write(ctx context.Context, records []*AuditRecord) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, w.network, w.address)
+		if err != nil {
+			return err
+		}
+		w.conn = conn
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = w.conn.SetWriteDeadline(deadline)
+	}
+
+	datagrams := w.network == "udp" || w.network == "udp4" || w.network == "udp6" || w.network == "unixgram"
+	for _, record := range records {
+		msg, err := w.message(record)
+		if err != nil {
+			return err
+		}
+		if !datagrams {
+			msg = append([]byte(strconv.Itoa(len(msg))+" "), msg...)
+		}
+		if _, err := w.conn.Write(msg); err != nil {
+			_ = w.conn.Close()
+			w.conn = nil
+			return err
+		}
+	}
+
+	return nil
+}
+
+// message formats a record as an RFC 5424 message, failed calls are sent
+// with notice severity
+func (w *syslogAuditWriter) message(record *AuditRecord) ([]byte, error) {
+	data, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+
+	severity := syslogSeverityInfo
+	if record.IsError || record.Error != "" {
+		severity = syslogSeverityNotice
+	}
+
+	header := fmt.Sprintf("<%d>1 %s %s %s %d %s - ",
+		syslogFacilityLocal0*8+severity,
+		record.Time.UTC().Format(time.RFC3339Nano),
+		w.hostname,
+		w.appName,
+		os.Getpid(),
+		record.Event,
+	)
+
+	return append([]byte(header), data...), nil
+}
+
+func (w *syslogAuditWriter) close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.conn == nil {
+		return nil
+	}
+	return w.conn.Close()
+}
+
+// httpAuditWriter posts batches to an endpoint as a JSON array
+type httpAuditWriter struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func newHTTPAuditWriter(cfg *AuditSinkConfig) *httpAuditWriter {
+	return &httpAuditWriter{url: cfg.URL, headers: cfg.Headers, client: &http.Client{}}
+}
+
+func (w *httpAuditWriter) write(ctx context.Context, records []*AuditRecord) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range w.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return errors.Errorf("audit endpoint answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+func (w *httpAuditWriter) close() error {
+	w.client.CloseIdleConnections()
+	return nil
+}
+
+// jobsAuditWriter pushes every record as a job to a pipeline of the jobs
+// plugin, e.g. one backed by the Kafka driver
+type jobsAuditWriter struct {
+	jobs     Jobs
+	pipeline string
+}
+
+func (w *jobsAuditWriter) write(ctx context.Context, records []*AuditRecord) error {
+	for _, record := range records {
+		payload, err := json.Marshal(record)
+		if err != nil {
+			return err
+		}
+
+		msg := &jobMessage{
+			id:       uuid.New().String(),
+			name:     "mcp.audit",
+			pipeline: w.pipeline,
+			payload:  payload,
+			headers:  map[string][]string{"event": {record.Event}},
+		}
+		if err := w.jobs.Push(ctx, msg); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (w *jobsAuditWriter) close() error { return nil }
@@ -0,0 +1,198 @@
+package mcp
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// Authentication modes of transports and listeners
+const (
+	// AuthModeNone trusts all sessions
+	AuthModeNone = "none"
+	// AuthModePHP authenticates sessions with the ClientConnected event
+	AuthModePHP = "php"
+	// AuthModeMTLS requires a client certificate signed by the listener's client CA
+	AuthModeMTLS = "mtls"
+)
+
+// ListenerAuthConfig selects the authentication of a transport or logical server
+type ListenerAuthConfig struct {
+	// "none", "php" or "mtls"
+	Mode string `mapstructure:"mode"`
+}
+
+// Validate checks the authentication mode
+func (a *ListenerAuthConfig) Validate() error {
+	switch a.Mode {
+	case AuthModeNone, AuthModePHP, AuthModeMTLS:
+		return nil
+	}
+	return errors.Errorf("auth mode must be %q, %q or %q", AuthModeNone, AuthModePHP, AuthModeMTLS)
+}
+
+// Places credentials are read from
+const (
+	CredentialFromHeader = "header"
+	CredentialFromQuery  = "query"
+	CredentialFromCookie = "cookie"
+)
+
+// CredentialSource maps a request header, query parameter or cookie to a
+// credential passed to PHP
+type CredentialSource struct {
+	// "header", "query" or "cookie"
+	From string `mapstructure:"from"`
+
+	// Header, parameter or cookie name
+	Name string `mapstructure:"name"`
+
+	// Credential key, defaults to the name
+	Key string `mapstructure:"key"`
+}
+
+// Validate checks a credential source
+func (c *CredentialSource) Validate() error {
+	if c.From != CredentialFromHeader && c.From != CredentialFromQuery && c.From != CredentialFromCookie {
+		return errors.Errorf("from must be %q, %q or %q", CredentialFromHeader, CredentialFromQuery, CredentialFromCookie)
+	}
+	if c.Name == "" {
+		return errors.Str("name is required")
+	}
+	return nil
+}
+
+// extract returns the value of the source in a request, empty when missing
+func (c *CredentialSource) extract(r *http.Request) string {
+	switch c.From {
+	case CredentialFromHeader:
+		return r.Header.Get(c.Name)
+	case CredentialFromQuery:
+		return r.URL.Query().Get(c.Name)
+	case CredentialFromCookie:
+		if cookie, err := r.Cookie(c.Name); err == nil {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// TLSConfig enables HTTPS on a listener
+type TLSConfig struct {
+	Cert string `json:"cert" mapstructure:"cert"`
+	Key  string `json:"key" mapstructure:"key"`
+
+	// PEM bundle of CAs verifying client certificates, required for mtls
+	ClientCA string `json:"clientCa,omitempty" mapstructure:"client_ca"`
+
+	// Certificates from an ACME CA instead of cert and key
+	ACME *ACMEConfig `json:"acme,omitempty" mapstructure:"acme"`
+
+	// How often the files are checked for changes, 10s when zero,
+	// negative disables reloading
+	ReloadInterval time.Duration `json:"reloadInterval,omitempty" mapstructure:"reload_interval"`
+}
+
+// Validate checks the certificate settings
+func (t *TLSConfig) Validate() error {
+	if t.ACME != nil {
+		if t.Cert != "" || t.Key != "" {
+			return errors.Str("cert and key cannot be combined with acme")
+		}
+		if err := t.ACME.Validate(); err != nil {
+			return errors.Errorf("acme: %v", err)
+		}
+		return nil
+	}
+	if t.Cert == "" || t.Key == "" {
+		return errors.Str("cert and key or acme are required")
+	}
+	return nil
+}
+
+// newTLSConfig builds the server TLS configuration of a listener. Client
+// certificates are verified when sent so listeners can mix auth modes,
+// mtls sessions are rejected without one.
+func newTLSConfig(cfg *TLSConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if cfg.ClientCA != "" {
+		pem, err := os.ReadFile(cfg.ClientCA)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in client CA %s", cfg.ClientCA)
+		}
+
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, nil
+}
+
+// listenAndServe serves srv over HTTPS when tlsCfg is set, reloading
+// changed certificates while it runs
+func (p *Plugin) listenAndServe(srv *http.Server, tlsCfg *TLSConfig) error {
+	ln, certs, err := listenTLS(srv.Addr, tlsCfg, p.cfg.Clients.KeepAlive, httpProtocols)
+	if err != nil {
+		return err
+	}
+	defer p.watchCertificates(certs)()
+
+	return srv.Serve(ln)
+}
+
+// authMode resolves the authentication of sessions of a transport, opened
+// through a logical server unless server is empty
+func (p *Plugin) authMode(server, transport string) string {
+	// Sessions the plugin runs itself are always trusted
+	if isTrustedTransport(transport) {
+		return AuthModeNone
+	}
+
+	if s, ok := p.cfg.Servers[server]; ok && server != "" && s.Auth != nil {
+		return s.Auth.Mode
+	}
+
+	if t, ok := p.cfg.Auth.Transports[transport]; ok && t != nil {
+		return t.Mode
+	}
+
+	if !p.cfg.Auth.Enabled || (transport == "stdio" && p.cfg.Auth.SkipForStdio) {
+		return AuthModeNone
+	}
+
+	return AuthModePHP
+}
+
+// sessionRequiresAuth reports whether PHP authenticates a session
+func (p *Plugin) sessionRequiresAuth(info *SessionInfo) bool {
+	return p.authMode(info.Server, info.Transport) == AuthModePHP
+}
+
+// authorizeRequest rejects HTTP requests lacking the client certificate
+// required by mtls, it reports whether the request may proceed
+func (p *Plugin) authorizeRequest(w http.ResponseWriter, r *http.Request, server, transport string) bool {
+	if p.authMode(server, transport) != AuthModeMTLS || hasVerifiedClientCert(r) {
+		return true
+	}
+
+	p.errorCounts.add(errorClassAuth)
+	writeJSONError(w, http.StatusUnauthorized, "client certificate required")
+	return false
+}
+
+// hasVerifiedClientCert reports whether the client presented a certificate
+// signed by the listener's client CA
+func hasVerifiedClientCert(r *http.Request) bool {
+	return r.TLS != nil && len(r.TLS.VerifiedChains) > 0
+}
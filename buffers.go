@@ -0,0 +1,56 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+// maxPooledBuffer keeps buffers grown by large payloads out of the pool
+const maxPooledBuffer = 1 << 20
+
+// bufferPool reuses the buffers payloads are encoded into on every call
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer returns an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool, its bytes must no longer be referenced
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBuffer {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// marshalTo encodes v as JSON into buf like json.Marshal, the result aliases buf
+func marshalTo(buf *bytes.Buffer, v interface{}) ([]byte, error) {
+	buf.Reset()
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return nil, err
+	}
+	// Encode terminates the value with a newline
+	return bytes.TrimSuffix(buf.Bytes(), []byte("\n")), nil
+}
+
+// headerPool reuses the header maps of worker events
+var headerPool = sync.Pool{
+	New: func() interface{} { return make(map[string][]string, 8) },
+}
+
+// getHeaders returns an empty header map from the pool
+func getHeaders() map[string][]string {
+	return headerPool.Get().(map[string][]string)
+}
+
+// putHeaders clears a header map and returns it to the pool
+func putHeaders(headers map[string][]string) {
+	clear(headers)
+	headerPool.Put(headers)
+}
@@ -0,0 +1,212 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/api/v4/plugins/v4/jobs"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// jobsNamespace is the namespace of the built-in jobs tools
+const jobsNamespace = "jobs"
+
+// Jobs is implemented by the RoadRunner jobs plugin
+type Jobs interface {
+	Push(ctx context.Context, msg jobs.Message) error
+	List() []string
+	JobsState(ctx context.Context) ([]*jobs.State, error)
+}
+
+// jobsTools exposes the jobs plugin as MCP tools
+type jobsTools struct {
+	plugin *Plugin
+	jobs   Jobs
+	cfg    *JobsToolsConfig
+}
+
+// jobMessage implements jobs.Message for jobs pushed by clients
+type jobMessage struct {
+	id       string
+	name     string
+	pipeline string
+	payload  []byte
+	headers  map[string][]string
+	delay    int64
+	priority int64
+}
+
+func (m *jobMessage) ID() string                   { return m.id }
+func (m *jobMessage) GroupID() string              { return m.pipeline }
+func (m *jobMessage) Priority() int64              { return m.priority }
+func (m *jobMessage) Name() string                 { return m.name }
+func (m *jobMessage) Payload() []byte              { return m.payload }
+func (m *jobMessage) Headers() map[string][]string { return m.headers }
+func (m *jobMessage) Delay() int64                 { return m.delay }
+func (m *jobMessage) AutoAck() bool                { return false }
+func (m *jobMessage) UpdatePriority(p int64)       { m.priority = p }
+func (m *jobMessage) Offset() int64                { return 0 }
+func (m *jobMessage) Partition() int32             { return 0 }
+func (m *jobMessage) Topic() string                { return "" }
+func (m *jobMessage) Metadata() string             { return "" }
+
+// newJobsTools creates the jobs tools, the jobs plugin must be collected
+func (p *Plugin) newJobsTools() (*jobsTools, error) {
+	const op = errors.Op("mcp_new_jobs_tools")
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.jobs == nil {
+		return nil, errors.E(op, errors.Str("jobs plugin is not available"))
+	}
+
+	return &jobsTools{
+		plugin: p,
+		jobs:   p.jobs,
+		cfg:    &p.cfg.Builtin.Jobs,
+	}, nil
+}
+
+// Name returns the tools namespace
+func (t *jobsTools) Name() string {
+	return jobsNamespace
+}
+
+// MCPTools returns the jobs tools
+func (t *jobsTools) MCPTools() []*ProviderTool {
+	pipeline := map[string]interface{}{"type": "string"}
+	if len(t.cfg.Pipelines) > 0 {
+		pipeline["enum"] = t.cfg.Pipelines
+	}
+
+	return []*ProviderTool{
+		{
+			Tool: &mcp.Tool{
+				Name:        "push",
+				Description: "Push a job to a RoadRunner jobs pipeline",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"pipeline": pipeline,
+						"name":     map[string]interface{}{"type": "string", "description": "Job name, usually the handler class"},
+						"payload":  map[string]interface{}{"type": "string"},
+						"headers": map[string]interface{}{
+							"type":                 "object",
+							"additionalProperties": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+						},
+						"delay":    map[string]interface{}{"type": "integer", "minimum": 0, "description": "Delay in seconds"},
+						"priority": map[string]interface{}{"type": "integer"},
+					},
+					"required": []string{"pipeline", "name"},
+				},
+			},
+			Handler: t.push,
+		},
+		{
+			Tool: &mcp.Tool{
+				Name:        "pipelines",
+				Description: "List jobs pipelines with their queue statistics",
+				InputSchema: map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"pipeline": map[string]interface{}{"type": "string", "description": "Only return this pipeline"},
+					},
+				},
+				Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+			},
+			Handler: t.pipelines,
+		},
+	}
+}
+
+// jobsArguments are the arguments accepted by the jobs tools
+type jobsArguments struct {
+	Pipeline string              `json:"pipeline"`
+	Name     string              `json:"name"`
+	Payload  string              `json:"payload"`
+	Headers  map[string][]string `json:"headers"`
+	Delay    int64               `json:"delay"`
+	Priority int64               `json:"priority"`
+}
+
+// arguments checks the session scope and decodes the tool arguments
+func (t *jobsTools) arguments(ctx context.Context, req *mcp.CallToolRequest) (*jobsArguments, error) {
+	if !t.plugin.sessionHasScope(sessionIDFromContext(ctx), t.cfg.Scope) {
+		return nil, fmt.Errorf("session is missing the %q scope", t.cfg.Scope)
+	}
+
+	args := &jobsArguments{}
+	if len(req.Params.Arguments) > 0 {
+		if err := json.Unmarshal(req.Params.Arguments, args); err != nil {
+			return nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	return args, nil
+}
+
+func (t *jobsTools) push(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := t.arguments(ctx, req)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	if args.Pipeline == "" || args.Name == "" {
+		return toolErrorResult(errors.Str("pipeline and name are required")), nil
+	}
+	if len(t.cfg.Pipelines) > 0 && !slices.Contains(t.cfg.Pipelines, args.Pipeline) {
+		return toolErrorResult(fmt.Errorf("pushing to pipeline %q is not allowed", args.Pipeline)), nil
+	}
+	if !slices.Contains(t.jobs.List(), args.Pipeline) {
+		return toolErrorResult(fmt.Errorf("unknown pipeline %q", args.Pipeline)), nil
+	}
+
+	msg := &jobMessage{
+		id:       uuid.NewString(),
+		name:     args.Name,
+		pipeline: args.Pipeline,
+		payload:  []byte(args.Payload),
+		headers:  args.Headers,
+		delay:    args.Delay,
+		priority: args.Priority,
+	}
+
+	if err := t.jobs.Push(ctx, msg); err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	t.plugin.log.Debug("job pushed via MCP",
+		zap.String("pipeline", msg.pipeline),
+		zap.String("name", msg.name),
+		zap.String("id", msg.id),
+	)
+
+	return jsonResult(map[string]string{"id": msg.id})
+}
+
+func (t *jobsTools) pipelines(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, err := t.arguments(ctx, req)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	states, err := t.jobs.JobsState(ctx)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	result := make([]*jobs.State, 0, len(states))
+	for _, state := range states {
+		if args.Pipeline == "" || state.Pipeline == args.Pipeline {
+			result = append(result, state)
+		}
+	}
+
+	return jsonResult(result)
+}
@@ -0,0 +1,286 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/api/v4/plugins/v1/kv"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// kvNamespace is the namespace of the built-in KV tools
+const kvNamespace = "kv"
+
+// kvTools exposes RoadRunner KV storages as MCP tools
+type kvTools struct {
+	log      *zap.Logger
+	readOnly bool
+	names    []string
+	storages map[string]kv.Storage
+}
+
+// kvItem implements kv.Item
+type kvItem struct {
+	key     string
+	value   []byte
+	timeout string
+}
+
+func (i *kvItem) Key() string     { return i.key }
+func (i *kvItem) Value() []byte   { return i.value }
+func (i *kvItem) Timeout() string { return i.timeout }
+
+// openKVTools opens the configured storages through the collected KV drivers
+func (p *Plugin) openKVTools() (*kvTools, error) {
+	const op = errors.Op("mcp_open_kv_tools")
+
+	t := &kvTools{
+		log:      p.log,
+		readOnly: p.cfg.Builtin.KV.ReadOnly,
+		storages: make(map[string]kv.Storage, len(p.cfg.Builtin.KV.Storages)),
+	}
+
+	for _, name := range p.cfg.Builtin.KV.Storages {
+		key := fmt.Sprintf("%s.%s", kvNamespace, name)
+
+		if !p.cfgPlugin.Has(key) {
+			t.stop()
+			return nil, errors.E(op, errors.Errorf("kv storage %q is not configured", name))
+		}
+
+		var storageCfg struct {
+			Driver string `mapstructure:"driver"`
+		}
+		if err := p.cfgPlugin.UnmarshalKey(key, &storageCfg); err != nil {
+			t.stop()
+			return nil, errors.E(op, err)
+		}
+
+		p.mu.RLock()
+		driver, ok := p.kvDrivers[storageCfg.Driver]
+		p.mu.RUnlock()
+		if !ok {
+			t.stop()
+			return nil, errors.E(op, errors.Errorf("kv driver %q for storage %q is not available", storageCfg.Driver, name))
+		}
+
+		storage, err := driver.KvFromConfig(key)
+		if err != nil {
+			t.stop()
+			return nil, errors.E(op, err)
+		}
+
+		t.storages[name] = storage
+		t.names = append(t.names, name)
+	}
+
+	sort.Strings(t.names)
+
+	return t, nil
+}
+
+// Name returns the tools namespace
+func (t *kvTools) Name() string {
+	return kvNamespace
+}
+
+// MCPTools returns the KV tools
+func (t *kvTools) MCPTools() []*ProviderTool {
+	tools := []*ProviderTool{
+		{
+			Tool: &mcp.Tool{
+				Name:        "get",
+				Description: "Get a value from a key-value storage",
+				InputSchema: t.schema(map[string]interface{}{
+					"key": map[string]interface{}{"type": "string"},
+				}, "key"),
+				Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+			},
+			Handler: t.get,
+		},
+		{
+			Tool: &mcp.Tool{
+				Name:        "has",
+				Description: "Check which keys exist in a key-value storage",
+				InputSchema: t.schema(map[string]interface{}{
+					"keys": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				}, "keys"),
+				Annotations: &mcp.ToolAnnotations{ReadOnlyHint: true},
+			},
+			Handler: t.has,
+		},
+	}
+
+	if t.readOnly {
+		return tools
+	}
+
+	return append(tools,
+		&ProviderTool{
+			Tool: &mcp.Tool{
+				Name:        "set",
+				Description: "Set a value in a key-value storage",
+				InputSchema: t.schema(map[string]interface{}{
+					"key":   map[string]interface{}{"type": "string"},
+					"value": map[string]interface{}{"type": "string"},
+					"ttl":   map[string]interface{}{"type": "integer", "minimum": 0, "description": "Expiration in seconds, 0 keeps the value forever"},
+				}, "key", "value"),
+			},
+			Handler: t.set,
+		},
+		&ProviderTool{
+			Tool: &mcp.Tool{
+				Name:        "delete",
+				Description: "Delete keys from a key-value storage",
+				InputSchema: t.schema(map[string]interface{}{
+					"keys": map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}},
+				}, "keys"),
+				Annotations: &mcp.ToolAnnotations{DestructiveHint: boolPtr(true)},
+			},
+			Handler: t.delete,
+		},
+	)
+}
+
+// schema builds an input schema with the storage selector
+func (t *kvTools) schema(properties map[string]interface{}, required ...string) map[string]interface{} {
+	properties["storage"] = map[string]interface{}{
+		"type": "string",
+		"enum": t.names,
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+		"required":   append([]string{"storage"}, required...),
+	}
+}
+
+// kvArguments are the arguments accepted by the KV tools
+type kvArguments struct {
+	Storage string   `json:"storage"`
+	Key     string   `json:"key"`
+	Keys    []string `json:"keys"`
+	Value   string   `json:"value"`
+	TTL     int64    `json:"ttl"`
+}
+
+// arguments decodes the tool arguments and resolves the storage
+func (t *kvTools) arguments(req *mcp.CallToolRequest) (*kvArguments, kv.Storage, error) {
+	args := &kvArguments{}
+	if len(req.Params.Arguments) > 0 {
+		if err := json.Unmarshal(req.Params.Arguments, args); err != nil {
+			return nil, nil, fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+
+	storage, ok := t.storages[args.Storage]
+	if !ok {
+		return nil, nil, fmt.Errorf("unknown storage %q", args.Storage)
+	}
+
+	return args, storage, nil
+}
+
+func (t *kvTools) get(_ context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, storage, err := t.arguments(req)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	value, err := storage.Get(args.Key)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+	if value == nil {
+		return toolErrorResult(fmt.Errorf("key %q not found", args.Key)), nil
+	}
+
+	if !utf8.Valid(value) {
+		return toolErrorResult(fmt.Errorf("value of key %q is not valid UTF-8", args.Key)), nil
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(value)}},
+	}, nil
+}
+
+func (t *kvTools) has(_ context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, storage, err := t.arguments(req)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	found, err := storage.Has(args.Keys...)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	exists := make(map[string]bool, len(args.Keys))
+	for _, key := range args.Keys {
+		exists[key] = found[key]
+	}
+
+	return jsonResult(exists)
+}
+
+func (t *kvTools) set(_ context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, storage, err := t.arguments(req)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+	if args.Key == "" {
+		return toolErrorResult(errors.Str("key is required")), nil
+	}
+
+	item := &kvItem{key: args.Key, value: []byte(args.Value)}
+	if args.TTL > 0 {
+		item.timeout = time.Now().Add(time.Duration(args.TTL) * time.Second).Format(time.RFC3339)
+	}
+
+	if err := storage.Set(item); err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	t.log.Debug("kv value set via MCP",
+		zap.String("storage", args.Storage),
+		zap.String("key", args.Key),
+	)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: "OK"}},
+	}, nil
+}
+
+func (t *kvTools) delete(_ context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+	args, storage, err := t.arguments(req)
+	if err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	if err := storage.Delete(args.Keys...); err != nil {
+		return toolErrorResult(err), nil
+	}
+
+	t.log.Debug("kv keys deleted via MCP",
+		zap.String("storage", args.Storage),
+		zap.Strings("keys", args.Keys),
+	)
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: "OK"}},
+	}, nil
+}
+
+// stop closes all opened storages
+func (t *kvTools) stop() {
+	for _, storage := range t.storages {
+		storage.Stop()
+	}
+}
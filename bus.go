@@ -0,0 +1,115 @@
+package mcp
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// busQueueSize bounds the events waiting to be published
+const busQueueSize = 1024
+
+// Events published to the broadcast plugin, the topic is prefixed with events.topic_prefix
+const (
+	BusSessionConnected    = "session.connected"
+	BusSessionDisconnected = "session.disconnected"
+	BusToolRegistered      = "tool.registered"
+	BusToolCallFinished    = "tool.call.finished"
+)
+
+// BroadcastMessage is a message published to the broadcast plugin
+type BroadcastMessage interface {
+	Topic() string
+	Payload() []byte
+}
+
+// Broadcaster is implemented by the RoadRunner broadcast plugin
+type Broadcaster interface {
+	Publish(m BroadcastMessage) error
+}
+
+// BusEvent is the JSON payload of a published event
+type BusEvent struct {
+	Event     string    `json:"event"`
+	Timestamp time.Time `json:"timestamp"`
+
+	// Session events
+	SessionID string `json:"sessionId,omitempty"`
+	Transport string `json:"transport,omitempty"`
+	Server    string `json:"server,omitempty"`
+	Tenant    string `json:"tenant,omitempty"`
+
+	// Tool events
+	Tool      string `json:"tool,omitempty"`
+	Source    string `json:"source,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+	Updated   bool   `json:"updated,omitempty"`
+
+	// Finished tool calls
+	DurationMs int64      `json:"durationMs,omitempty"`
+	IsError    bool       `json:"isError,omitempty"`
+	Error      string     `json:"error,omitempty"`
+	ErrorInfo  *ErrorInfo `json:"errorInfo,omitempty"`
+}
+
+// busMessage implements BroadcastMessage
+type busMessage struct {
+	topic   string
+	payload []byte
+}
+
+func (m *busMessage) Topic() string   { return m.topic }
+func (m *busMessage) Payload() []byte { return m.payload }
+
+// startBus starts publishing events, the broadcast plugin must be collected
+func (p *Plugin) startBus() error {
+	const op = errors.Op("mcp_start_bus")
+
+	p.mu.RLock()
+	broadcaster := p.broadcaster
+	p.mu.RUnlock()
+
+	if broadcaster == nil {
+		return errors.E(op, errors.Str("broadcast plugin is not available"))
+	}
+
+	go func() {
+		for {
+			select {
+			case <-p.ctx.Done():
+				return
+			case msg := <-p.bus:
+				if err := broadcaster.Publish(msg); err != nil {
+					p.log.Warn("failed to publish event",
+						zap.String("topic", msg.topic),
+						zap.Error(err),
+					)
+				}
+			}
+		}
+	}()
+
+	return nil
+}
+
+// publish queues an event for the broadcast plugin without blocking, events
+// are dropped while the queue is full. Safe to call under lock.
+func (p *Plugin) publish(event *BusEvent) {
+	if !p.cfg.Events.Enabled {
+		return
+	}
+
+	event.Timestamp = time.Now()
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	select {
+	case p.bus <- &busMessage{topic: p.cfg.Events.TopicPrefix + "." + event.Event, payload: data}:
+	default:
+		p.log.Warn("event queue full, event dropped", zap.String("event", event.Event))
+	}
+}
@@ -0,0 +1,226 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// transportReplay is the transport of sessions replaying recorded calls
+const transportReplay = "replay"
+
+// callSummaryLength limits the result text kept per recorded call
+const callSummaryLength = 256
+
+// CallRecord describes a completed tool call
+type CallRecord struct {
+	ID        uint64          `json:"id"`
+	RequestID string          `json:"requestId,omitempty"`
+	Tool      string          `json:"tool"`
+	SessionID string          `json:"sessionId"`
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+	StartedAt time.Time       `json:"startedAt"`
+	Duration  time.Duration   `json:"duration"`
+	IsError   bool            `json:"isError"`
+	Summary   string          `json:"summary,omitempty"` // Beginning of the text result
+	Error     string          `json:"error,omitempty"`   // Protocol error returned to the client
+	ErrorInfo *ErrorInfo      `json:"errorInfo,omitempty"`
+
+	// Replace Arguments and Summary with privacy.enabled
+	ArgumentsHash string `json:"argumentsHash,omitempty"`
+	ResultHash    string `json:"resultHash,omitempty"`
+
+	// Original arguments for replays when Arguments are redacted
+	rawArguments json.RawMessage
+}
+
+// callLog is a fixed size ring buffer of recent tool calls
+type callLog struct {
+	mu      sync.Mutex
+	records []*CallRecord
+	next    int
+	full    bool
+	lastID  uint64
+}
+
+// newCallLog creates a call log keeping the last size calls
+func newCallLog(size int) *callLog {
+	return &callLog{records: make([]*CallRecord, size)}
+}
+
+// add records a call, overwriting the oldest one when full
+func (l *callLog) add(record *CallRecord) {
+	if len(l.records) == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.lastID++
+	record.ID = l.lastID
+
+	l.records[l.next] = record
+	l.next = (l.next + 1) % len(l.records)
+	if l.next == 0 {
+		l.full = true
+	}
+}
+
+// recent returns the recorded calls, newest first
+func (l *callLog) recent() []*CallRecord {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	count := l.next
+	if l.full {
+		count = len(l.records)
+	}
+
+	result := make([]*CallRecord, 0, count)
+	for i := 1; i <= count; i++ {
+		idx := (l.next - i + len(l.records)) % len(l.records)
+		result = append(result, l.records[idx])
+	}
+
+	return result
+}
+
+// find returns a recorded call by ID
+func (l *callLog) find(id uint64) (*CallRecord, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, record := range l.records {
+		if record != nil && record.ID == id {
+			return record, true
+		}
+	}
+
+	return nil, false
+}
+
+// callMiddleware records every tool call in the call log
+func (p *Plugin) callMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok {
+			return next(ctx, method, req)
+		}
+
+		sessionID := sessionIDFromContext(ctx)
+		if p.sessionTransport(sessionID) == transportLoadTest {
+			return next(ctx, method, req)
+		}
+
+		record := &CallRecord{
+			RequestID: requestIDFromContext(ctx),
+			Tool:      params.Name,
+			SessionID: sessionID,
+			StartedAt: time.Now(),
+		}
+		record.Arguments, record.ArgumentsHash = p.recordArguments(params.Arguments)
+
+		// Arguments are not kept at all with privacy.enabled, such calls
+		// cannot be replayed
+		if !p.cfg.Privacy.Enabled {
+			record.rawArguments = params.Arguments
+		}
+
+		result, err := next(ctx, method, req)
+
+		record.Duration = time.Since(record.StartedAt)
+		if err != nil {
+			if isSchemaValidationError(err) {
+				err = jsonRPCError(p.countError(errorClassSchema, err))
+			}
+			record.Error = err.Error()
+			record.ErrorInfo = errorInfo(err)
+		} else if res, ok := result.(*mcp.CallToolResult); ok {
+			record.IsError = res.IsError
+			record.Summary, record.ResultHash = p.recordResult(res)
+		}
+
+		p.calls.add(record)
+		p.auditCall(record)
+
+		p.publish(&BusEvent{
+			Event:      BusToolCallFinished,
+			SessionID:  sessionID,
+			Tool:       params.Name,
+			DurationMs: record.Duration.Milliseconds(),
+			IsError:    record.IsError,
+			Error:      record.Error,
+			ErrorInfo:  record.ErrorInfo,
+		})
+
+		return result, err
+	}
+}
+
+// recentCalls returns recorded calls, optionally filtered by tool and limited
+func (p *Plugin) recentCalls(tool string, limit int) []*CallRecord {
+	calls := make([]*CallRecord, 0)
+	for _, record := range p.calls.recent() {
+		if tool != "" && record.Tool != tool {
+			continue
+		}
+		calls = append(calls, record)
+		if limit > 0 && len(calls) == limit {
+			break
+		}
+	}
+
+	return calls
+}
+
+// resultSummary returns the beginning of the text content of a result
+func resultSummary(result *mcp.CallToolResult) string {
+	for _, content := range result.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			if len(text.Text) > callSummaryLength {
+				return strings.ToValidUTF8(text.Text[:callSummaryLength], "") + "..."
+			}
+			return text.Text
+		}
+	}
+
+	return ""
+}
+
+// replayCall re-executes a recorded call with its original arguments. It runs
+// in a local session, so it is not authenticated as the original client.
+func (p *Plugin) replayCall(ctx context.Context, id uint64) (*mcp.CallToolResult, error) {
+	record, ok := p.calls.find(id)
+	if !ok {
+		return nil, fmt.Errorf("call %d is not in the history", id)
+	}
+
+	cs, closeSession, err := p.connectLocal(ctx, transportReplay, map[string]string{})
+	if err != nil {
+		return nil, err
+	}
+	defer closeSession()
+
+	if record.ArgumentsHash != "" && record.rawArguments == nil {
+		return nil, fmt.Errorf("call %d was recorded with privacy.enabled, its arguments are not kept", id)
+	}
+
+	params := &mcp.CallToolParams{Name: record.Tool}
+	if len(record.rawArguments) > 0 {
+		params.Arguments = record.rawArguments
+	}
+
+	p.log.Info("replaying tool call",
+		zap.Uint64("call_id", id),
+		zap.String("tool", record.Tool),
+	)
+
+	return cs.CallTool(ctx, params)
+}
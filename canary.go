@@ -0,0 +1,123 @@
+package mcp
+
+import (
+	"context"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"sync"
+)
+
+// Pools of canary routed tool calls, values of the pool label
+const (
+	canaryPoolPrimary = "primary"
+	canaryPoolCanary  = "canary"
+)
+
+// Outcomes of canary routed tool calls
+const (
+	canaryOutcomeSuccess = "success"
+	canaryOutcomeError   = "error"
+)
+
+// canaryCtxKey marks a tool call routed to the canary pool
+type canaryCtxKey struct{}
+
+// withCanary routes the worker execution of a tool call to the canary pool
+func withCanary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, canaryCtxKey{}, true)
+}
+
+// isCanary reports whether a tool call is routed to the canary pool
+func isCanary(ctx context.Context) bool {
+	canary, _ := ctx.Value(canaryCtxKey{}).(bool)
+	return canary
+}
+
+// canaryKey partitions canary routed tool calls
+type canaryKey struct {
+	tool    string
+	pool    string
+	outcome string
+}
+
+// canaryCalls counts the outcomes of calls eligible for the canary on both
+// pools, so error rates can be compared
+type canaryCalls struct {
+	mu     sync.Mutex
+	counts map[canaryKey]uint64
+}
+
+func newCanaryCalls() *canaryCalls {
+	return &canaryCalls{counts: make(map[canaryKey]uint64)}
+}
+
+// add counts a finished call
+func (c *canaryCalls) add(tool, pool string, failed bool) {
+	outcome := canaryOutcomeSuccess
+	if failed {
+		outcome = canaryOutcomeError
+	}
+
+	c.mu.Lock()
+	c.counts[canaryKey{tool: tool, pool: pool, outcome: outcome}]++
+	c.mu.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (c *canaryCalls) snapshot() map[canaryKey]uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(map[canaryKey]uint64, len(c.counts))
+	for k, v := range c.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// canaryEligible reports whether calls of a tool may be routed to the canary,
+// tenants with a dedicated pool keep it
+func (p *Plugin) canaryEligible(tool, tenant string) bool {
+	if p.canaryPool == nil {
+		return false
+	}
+	if _, dedicated := p.tenantPools[tenant]; dedicated && tenant != "" {
+		return false
+	}
+
+	return slices.ContainsFunc(p.cfg.Canary.Tools, func(pattern string) bool {
+		matched, _ := path.Match(pattern, tool)
+		return matched
+	})
+}
+
+// routeCanary picks the pool of an eligible call, canary.percentage of the
+// calls go to the canary
+func (p *Plugin) routeCanary() string {
+	if rand.Float64()*100 < p.cfg.Canary.Percentage {
+		return canaryPoolCanary
+	}
+	return canaryPoolPrimary
+}
+
+// createCanaryPool starts the canary pool, must be called under lock
+func (p *Plugin) createCanaryPool() error {
+	if p.cfg.Canary.Pool == nil {
+		return nil
+	}
+
+	pool, err := p.server.NewPool(
+		p.ctx,
+		p.cfg.Canary.Pool,
+		p.workerEnv(map[string]string{"RR_MCP_CANARY": "true"}),
+		p.log.Named("canary"),
+	)
+	if err != nil {
+		return err
+	}
+
+	p.canaryPool = pool
+
+	return nil
+}
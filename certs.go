@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// httpProtocols are offered by TLS handshakes of HTTP listeners
+var httpProtocols = []string{"h2", "http/1.1"}
+
+// defaultCertificateReload is how often certificate files are checked
+const defaultCertificateReload = 10 * time.Second
+
+// tlsCertificates holds the TLS configuration of a listener. Handshakes
+// read it on every connection, so certificates are replaced without
+// restarting the listener and established connections keep theirs.
+type tlsCertificates struct {
+	protocols []string
+	current   atomic.Pointer[tls.Config]
+
+	// Settings and file versions the current configuration was loaded from
+	mu     sync.Mutex
+	source *TLSConfig
+	stamp  string
+}
+
+// newTLSCertificates loads the certificates of a listener offering protocols
+// in ALPN, none for raw connections
+func newTLSCertificates(cfg *TLSConfig, protocols []string) (*tlsCertificates, error) {
+	c := &tlsCertificates{protocols: protocols}
+	if err := c.load(cfg); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// load replaces the certificates, the previous ones stay on failure
+func (c *tlsCertificates) load(cfg *TLSConfig) error {
+	// Taken first, a file changing while it is read is loaded again
+	stamp := certificateStamp(cfg)
+
+	tlsConfig, err := newTLSConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	// Certificates are obtained and renewed on handshakes
+	if cfg.ACME != nil {
+		tlsConfig.GetCertificate = cfg.ACME.manager().GetCertificate
+		tlsConfig.NextProtos = acmeProtocols(c.protocols)
+	} else {
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+		tlsConfig.NextProtos = c.protocols
+	}
+
+	c.mu.Lock()
+	c.current.Store(tlsConfig)
+	c.source = cfg
+	c.stamp = stamp
+	c.mu.Unlock()
+
+	return nil
+}
+
+// changed returns the settings to load again when their files changed
+func (c *tlsCertificates) changed() (*TLSConfig, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.source, certificateStamp(c.source) != c.stamp
+}
+
+// serverConfig returns the configuration of the listener, resolving the
+// current certificates per handshake
+func (c *tlsCertificates) serverConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		NextProtos: c.protocols,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return c.current.Load(), nil
+		},
+	}
+}
+
+// certificateStamp identifies the versions of the files of a listener by
+// their size and modification time. Stat follows symlinks, so certificates
+// swapped in by replacing a link are noticed as well.
+func certificateStamp(cfg *TLSConfig) string {
+	var stamp strings.Builder
+	for _, path := range []string{cfg.Cert, cfg.Key, cfg.ClientCA} {
+		if path == "" {
+			continue
+		}
+		if stat, err := os.Stat(path); err == nil {
+			fmt.Fprintf(&stamp, "%d:%d;", stat.Size(), stat.ModTime().UnixNano())
+		} else {
+			stamp.WriteString("missing;")
+		}
+	}
+	return stamp.String()
+}
+
+// watchCertificates reloads the certificates of a listener when their files
+// change, every tls.reload_interval, and returns a function stopping it
+func (p *Plugin) watchCertificates(certs *tlsCertificates) func() {
+	if certs == nil {
+		return func() {}
+	}
+
+	source, _ := certs.changed()
+	interval := source.ReloadInterval
+	if interval == 0 {
+		interval = defaultCertificateReload
+	}
+	if interval < 0 {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(p.ctx)
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			source, changed := certs.changed()
+			if !changed {
+				continue
+			}
+
+			// A failed load is retried on the next tick, e.g. when the key
+			// was not written yet
+			if err := certs.load(source); err != nil {
+				p.log.Warn("failed to reload TLS certificates",
+					zap.String("cert", source.Cert),
+					zap.Error(err),
+				)
+				continue
+			}
+
+			p.log.Info("TLS certificates reloaded", zap.String("cert", source.Cert))
+		}
+	}()
+
+	return cancel
+}
+
+// listenTLS opens a listener on address, serving TLS with the certificates
+// of tlsCfg unless it is nil
+func listenTLS(address string, tlsCfg *TLSConfig, keepAlive time.Duration, protocols []string) (net.Listener, *tlsCertificates, error) {
+	var certs *tlsCertificates
+	if tlsCfg != nil {
+		var err error
+		if certs, err = newTLSCertificates(tlsCfg, protocols); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	ln, err := listen(address, keepAlive)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if certs != nil {
+		ln = tls.NewListener(ln, certs.serverConfig())
+	}
+
+	return ln, certs, nil
+}
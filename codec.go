@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Wire formats of worker payloads
+const (
+	CodecJSON     = "json"
+	CodecMsgpack  = "msgpack"
+	CodecProtobuf = "protobuf"
+)
+
+// headerCodec names the wire format of an event body
+const headerCodec = "X-MCP-Codec"
+
+// codecContentTypes are sent as Content-Type of event bodies
+var codecContentTypes = map[string]string{
+	CodecJSON:     "application/json",
+	CodecMsgpack:  "application/msgpack",
+	CodecProtobuf: "application/x-protobuf",
+}
+
+// codecHeaders are the Content-Type header values of the codecs
+var codecHeaders = map[string][]string{
+	CodecJSON:     {codecContentTypes[CodecJSON]},
+	CodecMsgpack:  {codecContentTypes[CodecMsgpack]},
+	CodecProtobuf: {codecContentTypes[CodecProtobuf]},
+}
+
+// codec returns the wire format negotiated with the workers, JSON until a
+// readiness ping agreed on another
+func (p *Plugin) codec() string {
+	if codec, ok := p.workerCodec.Load().(string); ok {
+		return codec
+	}
+	return CodecJSON
+}
+
+// offeredCodecs lists the configured codec first, JSON is always accepted
+func (p *Plugin) offeredCodecs() []string {
+	if p.cfg.Codec == CodecJSON {
+		return []string{CodecJSON}
+	}
+	return []string{p.cfg.Codec, CodecJSON}
+}
+
+// negotiateCodec returns the codec chosen in a worker's ping response
+func (p *Plugin) negotiateCodec(resp *PingResponse) (string, error) {
+	if resp.Codec == "" {
+		return CodecJSON, nil
+	}
+	for _, codec := range p.offeredCodecs() {
+		if resp.Codec == codec {
+			return codec, nil
+		}
+	}
+	return "", errors.Errorf("worker chose codec %q, offered were %v", resp.Codec, p.offeredCodecs())
+}
+
+// encodeBody converts a JSON event body to a codec's wire format
+func encodeBody(codec string, body []byte) ([]byte, error) {
+	switch codec {
+	case CodecMsgpack:
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+
+		var value interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		return msgpack.Marshal(msgpackNumbers(value))
+
+	case CodecProtobuf:
+		dec := json.NewDecoder(bytes.NewReader(body))
+		dec.UseNumber()
+
+		var value map[string]interface{}
+		if err := dec.Decode(&value); err != nil {
+			return nil, err
+		}
+		if _, err := protobufNumbers(value); err != nil {
+			return nil, err
+		}
+		message, err := structpb.NewStruct(value)
+		if err != nil {
+			return nil, err
+		}
+		return proto.Marshal(message)
+	}
+
+	return body, nil
+}
+
+// decodeBody converts a worker response from a codec's wire format to JSON
+func decodeBody(codec string, body []byte) ([]byte, error) {
+	if len(body) == 0 {
+		return body, nil
+	}
+
+	switch codec {
+	case CodecMsgpack:
+		var value interface{}
+		if err := msgpack.Unmarshal(body, &value); err != nil {
+			return nil, err
+		}
+		return json.Marshal(value)
+
+	case CodecProtobuf:
+		message := &structpb.Struct{}
+		if err := proto.Unmarshal(body, message); err != nil {
+			return nil, err
+		}
+		return protojson.Marshal(message)
+	}
+
+	return body, nil
+}
+
+// msgpackNumbers replaces JSON numbers, integers stay integers on the wire
+func msgpackNumbers(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]interface{}:
+		for key, item := range v {
+			v[key] = msgpackNumbers(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = msgpackNumbers(item)
+		}
+	}
+	return value
+}
+
+// maxExactInteger is the largest integer a float64 holds exactly
+const maxExactInteger = 1 << 53
+
+// protobufNumbers replaces JSON numbers by the float64 of a protobuf number
+// value, integers a float64 cannot hold exactly are rejected instead of
+// silently rounded
+func protobufNumbers(value interface{}) (interface{}, error) {
+	var err error
+	switch v := value.(type) {
+	case json.Number:
+		return protobufNumber(v)
+	case map[string]interface{}:
+		for key, item := range v {
+			if v[key], err = protobufNumbers(item); err != nil {
+				return nil, err
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			if v[i], err = protobufNumbers(item); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return value, nil
+}
+
+// protobufNumber converts a JSON number to a float64, failing for integers
+// beyond 2^53
+func protobufNumber(number json.Number) (float64, error) {
+	if !strings.ContainsAny(number.String(), ".eE") {
+		i, err := number.Int64()
+		if err != nil || i > maxExactInteger || i < -maxExactInteger {
+			return 0, errors.Errorf("integer %s does not fit a protobuf number value exactly, send it as a string or use another codec", number)
+		}
+		return float64(i), nil
+	}
+	return number.Float64()
+}
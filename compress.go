@@ -0,0 +1,282 @@
+package mcp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Content encodings offered to HTTP clients and accepted from workers
+const (
+	EncodingZstd = "zstd"
+	EncodingGzip = "gzip"
+)
+
+// maxDecodedPayload bounds a decompressed worker response
+const maxDecodedPayload = 64 << 20
+
+// errPayloadTooLarge rejects worker responses decompressing beyond maxDecodedPayload
+var errPayloadTooLarge = fmt.Errorf("decompressed worker payload exceeds %d bytes", maxDecodedPayload)
+
+// Magic bytes of compressed worker payloads, JSON never starts with them
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// encoder is a streaming compressor
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// newEncoder creates a compressor for a content encoding
+func newEncoder(encoding string, w io.Writer) (encoder, error) {
+	switch encoding {
+	case EncodingZstd:
+		return zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	case EncodingGzip:
+		return gzip.NewWriter(w), nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q", encoding)
+}
+
+// negotiateEncoding picks the first configured encoding the client accepts,
+// empty when the response is sent uncompressed
+func negotiateEncoding(acceptEncoding string, algorithms []string) string {
+	if acceptEncoding == "" {
+		return ""
+	}
+
+	accepted := make(map[string]bool)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		name, params, _ := strings.Cut(part, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+		accepted[name] = q > 0
+	}
+
+	for _, algorithm := range algorithms {
+		if ok, listed := accepted[algorithm]; ok || (!listed && accepted["*"]) {
+			return algorithm
+		}
+	}
+
+	return ""
+}
+
+// compressResponses compresses HTTP responses for clients accepting one of
+// the configured encodings
+func (p *Plugin) compressResponses(next http.Handler) http.Handler {
+	if !p.cfg.Compression.Enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		encoding := negotiateEncoding(r.Header.Get("Accept-Encoding"), p.cfg.Compression.Algorithms)
+		if encoding == "" || r.Method == http.MethodHead {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		cw := &compressWriter{
+			ResponseWriter: w,
+			encoding:       encoding,
+			minSize:        p.cfg.Compression.MinSize,
+		}
+		defer func() {
+			_ = cw.Close()
+		}()
+
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// compressWriter buffers a response until it reaches the minimum size and
+// compresses it from then on. Event streams are compressed from the first
+// byte and every flush sends the events written so far.
+type compressWriter struct {
+	http.ResponseWriter
+	encoding string
+	minSize  int
+
+	status  int
+	buf     []byte
+	enc     encoder
+	decided bool
+}
+
+// WriteHeader defers the final status until the encoding is decided
+func (w *compressWriter) WriteHeader(status int) {
+	if status < http.StatusOK {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+// Write buffers or compresses response bytes
+func (w *compressWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	if !w.decided {
+		w.buf = append(w.buf, b...)
+		if len(w.buf) < w.minSize && !w.streaming() {
+			return len(b), nil
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+		return len(b), nil
+	}
+
+	if w.enc != nil {
+		return w.enc.Write(b)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// FlushError sends compressed bytes to the client, http.ResponseController
+// reports its error
+func (w *compressWriter) FlushError() error {
+	if !w.decided {
+		if err := w.decide(w.streaming()); err != nil {
+			return err
+		}
+	}
+
+	if w.enc != nil {
+		if err := w.enc.Flush(); err != nil {
+			return err
+		}
+	}
+
+	return http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+// Flush implements http.Flusher
+func (w *compressWriter) Flush() {
+	_ = w.FlushError()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Close sends a response that stayed below the minimum size and finishes
+// the compressed stream
+func (w *compressWriter) Close() error {
+	if !w.decided {
+		if w.status == 0 {
+			return nil
+		}
+		if err := w.decide(false); err != nil {
+			return err
+		}
+	}
+
+	if w.enc != nil {
+		return w.enc.Close()
+	}
+	return nil
+}
+
+// streaming reports whether the response is an event stream
+func (w *compressWriter) streaming() bool {
+	return strings.HasPrefix(w.Header().Get("Content-Type"), "text/event-stream")
+}
+
+// decide writes the headers and the buffered bytes, compressed or not
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	header := w.Header()
+	if header.Get("Content-Encoding") != "" || w.status == http.StatusNoContent || w.status == http.StatusNotModified {
+		compress = false
+	}
+
+	if compress {
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", w.encoding)
+
+		enc, err := newEncoder(w.encoding, w.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		w.enc = enc
+	}
+
+	w.ResponseWriter.WriteHeader(w.status)
+
+	buf := w.buf
+	w.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+
+	var err error
+	if w.enc != nil {
+		_, err = w.enc.Write(buf)
+	} else {
+		_, err = w.ResponseWriter.Write(buf)
+	}
+	return err
+}
+
+// decodeWorkerPayload decompresses a worker response sent with zstd or gzip
+func decodeWorkerPayload(body []byte) ([]byte, error) {
+	var r io.Reader
+	switch {
+	case bytes.HasPrefix(body, zstdMagic):
+		dec, err := zstd.NewReader(bytes.NewReader(body), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, err
+		}
+		defer dec.Close()
+		r = dec
+	case bytes.HasPrefix(body, gzipMagic):
+		dec, err := gzip.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = dec.Close()
+		}()
+		r = dec
+	default:
+		return body, nil
+	}
+
+	decoded, err := io.ReadAll(io.LimitReader(r, maxDecodedPayload+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress worker payload: %w", err)
+	}
+	if len(decoded) > maxDecodedPayload {
+		return nil, errPayloadTooLarge
+	}
+
+	return decoded, nil
+}
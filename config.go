@@ -1,6 +1,14 @@
 package mcp
 
 import (
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/roadrunner-server/errors"
@@ -11,12 +19,52 @@ const PluginName = "mcp"
 
 // Config represents the MCP plugin configuration
 type Config struct {
-	// Transport type: "sse", "stdio"
+	// Fail startup on unknown keys and contradicting settings
+	Strict bool `mapstructure:"strict"`
+
+	// Transport type: "sse", "stdio", "tcp"
 	Transport string `mapstructure:"transport"`
 
-	// Address for SSE transports (ignored for stdio)
+	// Mode: "workers" (default) or "mock" to answer tools from fixtures without PHP
+	Mode string `mapstructure:"mode"`
+
+	// Mock mode tools, inline and from a JSON fixture file
+	Mock struct {
+		Fixture string      `mapstructure:"fixture"`
+		Tools   []*MockTool `mapstructure:"tools"`
+	} `mapstructure:"mock"`
+
+	// Stdio transport behaviour
+	Stdio struct {
+		// Wait for the next client on stdin after one disconnects instead of
+		// ending the transport
+		RestartOnDisconnect bool `mapstructure:"restart_on_disconnect"`
+
+		// Pause before serving the next client, stdin must be open by then
+		RestartDelay time.Duration `mapstructure:"restart_delay"`
+	} `mapstructure:"stdio"`
+
+	// TCP transport behaviour
+	TCP struct {
+		// Message framing: "ndjson" (default) or "lsp" for Content-Length headers
+		Framing string `mapstructure:"framing"`
+	} `mapstructure:"tcp"`
+
+	// Address for SSE and TCP transports (ignored for stdio), the TCP transport
+	// also takes "unix://path" and "pipe://name" for local sockets
 	Address string `mapstructure:"address"`
 
+	// TLS for the SSE or TCP listener
+	TLS *TLSConfig `mapstructure:"tls"`
+
+	// Server identity reported to clients on initialize
+	Server struct {
+		Name         string `mapstructure:"name"`
+		Version      string `mapstructure:"version"`
+		Title        string `mapstructure:"title"`
+		Instructions string `mapstructure:"instructions"`
+	} `mapstructure:"server"`
+
 	// Worker pool configuration (uses RoadRunner's standard pool)
 	Pool *pool.Config `mapstructure:"pool"`
 
@@ -26,38 +74,679 @@ type Config struct {
 		ReadTimeout    time.Duration `mapstructure:"read_timeout"`
 		WriteTimeout   time.Duration `mapstructure:"write_timeout"`
 		PingInterval   time.Duration `mapstructure:"ping_interval"`
+
+		// How long an HTTP connection is kept open between requests,
+		// defaults to read_timeout
+		IdleTimeout time.Duration `mapstructure:"idle_timeout"`
+
+		// Period of TCP keepalive probes on client connections, keeps idle
+		// streams alive behind NATs, negative disables probes
+		KeepAlive time.Duration `mapstructure:"keep_alive"`
+
+		// Notifications pending per session before queue_overflow applies
+		NotificationQueue int `mapstructure:"notification_queue"`
+
+		// "drop_oldest" drops the oldest pending notification, "disconnect" closes the session
+		QueueOverflow string `mapstructure:"queue_overflow"`
+
+		// How long notifications are buffered for a disconnected session
+		// that sent a resume key, 0 disables resuming
+		ResumeGrace time.Duration `mapstructure:"resume_grace"`
+
+		// Notifications buffered per disconnected session
+		ResumeBuffer int `mapstructure:"resume_buffer"`
+
+		// Session attributes set by PHP that label mcp_session_calls_total,
+		// keep them to values of low cardinality such as a plan
+		MetricAttributes []string `mapstructure:"metric_attributes"`
+
+		// Labels mcp_session_calls_total with the tenant of the session
+		MetricTenant bool `mapstructure:"metric_tenant"`
+
+		// Allowed values per label of mcp_session_calls_total, by attribute
+		// name or "tenant"; other values are counted as "other"
+		MetricValues map[string][]string `mapstructure:"metric_values"`
+
+		// Distinct values per label of mcp_session_calls_total, values seen
+		// after the cap is reached are counted as "other"
+		MetricMaxValues int `mapstructure:"metric_max_values"`
+
+		// Quotas of the values PHP stores per session over RPC
+		Storage struct {
+			MaxKeys  int `mapstructure:"max_keys"`
+			MaxBytes int `mapstructure:"max_bytes"` // Keys and values together
+		} `mapstructure:"storage"`
 	} `mapstructure:"clients"`
 
+	// Lifecycle and call events published to the broadcast plugin
+	Events struct {
+		Enabled bool `mapstructure:"enabled"`
+
+		// Topics are <topic_prefix>.<event>, e.g. "mcp.session.connected"
+		TopicPrefix string `mapstructure:"topic_prefix"`
+	} `mapstructure:"events"`
+
+	// Compression of HTTP responses and worker payloads
+	Compression struct {
+		Enabled bool `mapstructure:"enabled"`
+
+		// Encodings in order of preference: "zstd", "gzip"
+		Algorithms []string `mapstructure:"algorithms"`
+
+		// Responses smaller than this are sent uncompressed, SSE streams are always compressed
+		MinSize int `mapstructure:"min_size"`
+
+		// Accept worker responses compressed with zstd or gzip
+		WorkerPayloads bool `mapstructure:"worker_payloads"`
+	} `mapstructure:"compression"`
+
+	// Log of the HTTP requests of the client listeners, separate from the
+	// plugin log
+	AccessLog struct {
+		Enabled bool `mapstructure:"enabled"`
+
+		// Line format: "json" or "combined"
+		Format string `mapstructure:"format"`
+
+		// "stdout", "stderr" or a file path
+		Output string `mapstructure:"output"`
+
+		// Share of successful requests logged, failed requests are always logged
+		SampleRate float64 `mapstructure:"sample_rate"`
+	} `mapstructure:"access_log"`
+
+	// Keeps tool arguments and results out of logs, the call history and
+	// audit records, which carry their hashes instead
+	Privacy struct {
+		Enabled bool `mapstructure:"enabled"`
+
+		// Key of HMAC-SHA256 hashes, plain SHA-256 is used when empty
+		HashKey string `mapstructure:"hash_key"`
+	} `mapstructure:"privacy"`
+
+	// Audit records of tool calls shipped to external sinks
+	Audit struct {
+		// Sinks by name, every sink receives every record
+		Sinks map[string]*AuditSinkConfig `mapstructure:"sinks"`
+	} `mapstructure:"audit"`
+
+	// Size limits of incoming requests
+	Limits struct {
+		// Maximum HTTP request body in bytes, a JSON-RPC message or REST arguments,
+		// also bounds stdio and TCP messages
+		MaxRequestSize int64 `mapstructure:"max_request_size"`
+
+		// Maximum size of HTTP request headers in bytes
+		MaxHeaderSize int `mapstructure:"max_header_size"`
+
+		// Maximum size of a single tool argument in bytes
+		MaxArgumentSize int `mapstructure:"max_argument_size"`
+	} `mapstructure:"limits"`
+
 	// Tool management
 	Tools struct {
 		NotifyClientsOnChange bool `mapstructure:"notify_clients_on_change"`
+
+		// Window in which list_changed notifications are coalesced
+		NotifyDebounce time.Duration `mapstructure:"notify_debounce"`
+
+		// Prefix prepended to every tool name (joined with "_")
+		Prefix string `mapstructure:"prefix"`
+
+		// Overrides for tools declared by PHP (tool name -> override)
+		Overrides map[string]*ToolOverride `mapstructure:"overrides"`
+
+		// Former tool names routed to a tool (alias -> tool name)
+		Aliases map[string]string `mapstructure:"aliases"`
+
+		// What happens to declared schemas using keywords clients are known
+		// to reject: reject (default), warn or off
+		SchemaCheck string `mapstructure:"schema_check"`
+
+		// Ask PHP which tools each session may see (FilterTools event)
+		Filter bool `mapstructure:"filter"`
+
+		// How long a session's filter decision is cached, zero keeps it for the session lifetime
+		FilterTTL time.Duration `mapstructure:"filter_ttl"`
+
+		// Re-run tool discovery (ListTools event) at startup and whenever workers are replaced
+		ResyncOnRestart bool `mapstructure:"resync_on_restart"`
+
+		// How often the worker pool is checked for replaced workers
+		ResyncInterval time.Duration `mapstructure:"resync_interval"`
+
+		// Deadline of a tool call's worker execution, none when zero. Clients may
+		// shorten it with _meta.timeoutMs.
+		CallTimeout time.Duration `mapstructure:"call_timeout"`
+
+		// Run a call of a read-only or idempotent tool once more when its
+		// worker died during the call
+		CrashRetry bool `mapstructure:"crash_retry"`
+
+		// Maximum size of a tool result's content in bytes, larger results
+		// are truncated with a marker. Unlimited when zero.
+		MaxResultBytes int `mapstructure:"max_result_bytes"`
+
+		// Results larger than max_result_bytes are stored as resources the
+		// client reads on demand instead of being truncated
+		Spill struct {
+			Enabled bool `mapstructure:"enabled"`
+
+			// Directory of the spill files, emptied on startup
+			Dir string `mapstructure:"dir"`
+
+			// How long a spilled result can be read
+			TTL time.Duration `mapstructure:"ttl"`
+		} `mapstructure:"spill"`
+
+		// Arguments are completed and converted by the input schema before
+		// they are validated and sent to PHP
+		Arguments struct {
+			// Fill in the defaults of nested properties, the SDK only fills
+			// in top level defaults
+			Defaults bool `mapstructure:"defaults"`
+
+			// Convert values of the wrong type, e.g. "42" for an integer
+			Coerce bool `mapstructure:"coerce"`
+		} `mapstructure:"arguments"`
 	} `mapstructure:"tools"`
 
+	// Checks run before every tool call
+	Policy struct {
+		// Send the BeforeToolCall event to PHP
+		Enabled bool `mapstructure:"enabled"`
+
+		// Glob patterns of tool names checked by PHP, all tools when empty
+		Tools []string `mapstructure:"tools"`
+	} `mapstructure:"policy"`
+
+	// Transformations applied to tool results before they reach the client
+	Results struct {
+		// Result filters in order, by name. Built-ins are "redact_secrets", "redact_pii", "truncate" and "sanitize"
+		Filters []string `mapstructure:"filters"`
+
+		// Regular expressions redacted by "redact_secrets" in addition to the built-in ones
+		RedactPatterns []string `mapstructure:"redact_patterns"`
+
+		// Text replacing redacted secrets
+		RedactReplacement string `mapstructure:"redact_replacement"`
+
+		// Maximum length of text content in bytes, enforced by "truncate"
+		MaxLength int `mapstructure:"max_length"`
+
+		// Actions of "sanitize" per class of malformed text: "allow", "strip",
+		// "replace" with U+FFFD or "reject" the result with a tool error
+		Sanitize struct {
+			InvalidUTF8 string `mapstructure:"invalid_utf8"`
+			// Null bytes and other C0 and C1 controls except tabs and line breaks
+			Control string `mapstructure:"control"`
+			ANSI    string `mapstructure:"ansi"`
+		} `mapstructure:"sanitize"`
+	} `mapstructure:"results"`
+
+	// Redaction of personal data and secrets in the call history and logs,
+	// results are redacted with the same rules by the "redact_pii" filter
+	Redaction struct {
+		Enabled bool `mapstructure:"enabled"`
+
+		// Built-in rules: "secrets", "email", "phone", "credit_card", "iban", "ssn", "ipv4"
+		Builtin []string `mapstructure:"builtin"`
+
+		// Custom pattern and entropy rules
+		Rules []*RedactionRule `mapstructure:"rules"`
+
+		// Argument keys whose values are always redacted, case-insensitive
+		Keys []string `mapstructure:"keys"`
+
+		// Text replacing redacted data
+		Replacement string `mapstructure:"replacement"`
+	} `mapstructure:"redaction"`
+
+	// Prompt injection scanning of tool results and resource reads
+	Injection struct {
+		// "flag" annotates results, "strip" also removes suspicious content, scanning is off when empty
+		Mode string `mapstructure:"mode"`
+
+		// Scanners by name, the built-in one is "heuristic"
+		Scanners []string `mapstructure:"scanners"`
+
+		// Regular expressions flagged by the heuristic scanner in addition to the built-in ones
+		Patterns []string `mapstructure:"patterns"`
+	} `mapstructure:"injection"`
+
+	// Secondary pool, e.g. a new PHP entrypoint, running a share of the calls of selected tools
+	Canary struct {
+		// Pool of the canary workers, set command to point at the new entrypoint
+		Pool *pool.Config `mapstructure:"pool"`
+
+		// Share of the calls routed to the canary, 0 to 100
+		Percentage float64 `mapstructure:"percentage"`
+
+		// Glob patterns of the tools routed to the canary
+		Tools []string `mapstructure:"tools"`
+	} `mapstructure:"canary"`
+
+	// Secondary pool running discarded copies of selected tool calls, to load
+	// test new handler code with production inputs
+	Shadow struct {
+		// Pool of the shadow workers, set command to point at the new entrypoint
+		Pool *pool.Config `mapstructure:"pool"`
+
+		// Share of the calls mirrored to the shadow, 0 to 100, defaults to 100
+		Percentage float64 `mapstructure:"percentage"`
+
+		// Glob patterns of the tools mirrored to the shadow
+		Tools []string `mapstructure:"tools"`
+
+		// Mirrored calls running at once, further calls are not mirrored,
+		// defaults to the shadow pool's num_workers
+		MaxInFlight int `mapstructure:"max_in_flight"`
+	} `mapstructure:"shadow"`
+
+	// Feature flags rolling tools out to some sessions
+	Flags struct {
+		// Rules by flag name
+		Rules map[string]*FlagRule `mapstructure:"rules"`
+
+		// Collected FlagProvider consulted for tools the rules allow
+		Provider string `mapstructure:"provider"`
+	} `mapstructure:"flags"`
+
+	// Tool call rate limit per tenant, sessions without a tenant share one limit
+	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
+
+	// Per-tenant overrides (tenant ID -> config)
+	Tenants map[string]*TenantConfig `mapstructure:"tenants"`
+
+	// Logical MCP servers on their own endpoints (name -> config)
+	Servers map[string]*ServerConfig `mapstructure:"servers"`
+
+	// Upstream MCP servers re-exposed through this server (name -> config)
+	Upstreams map[string]*UpstreamConfig `mapstructure:"upstreams"`
+
+	// Prompts re-exposed from upstream servers
+	Prompts struct {
+		// Prompts per prompts/list page
+		PageSize int `mapstructure:"page_size"`
+
+		// How long completions of a prompt argument are reused, negative disables caching
+		CompletionTTL time.Duration `mapstructure:"completion_ttl"`
+	} `mapstructure:"prompts"`
+
+	// Resources declared by PHP
+	Resources struct {
+		// How long cached contents are served before PHP is asked again with
+		// their etag, zero serves them until the resource is updated
+		CacheTTL time.Duration `mapstructure:"cache_ttl"`
+
+		// Total size of cached contents in bytes, negative disables caching
+		CacheSize int64 `mapstructure:"cache_size"`
+
+		// Largest contents of a single read in bytes, decoded blobs included
+		MaxSize int64 `mapstructure:"max_size"`
+
+		// Directory PHP may return files from, their blobs are read from
+		// disk instead of being sent through the worker
+		FileRoot string `mapstructure:"file_root"`
+	} `mapstructure:"resources"`
+
+	// Plain HTTP endpoints invoking tools (SSE transport only)
+	REST struct {
+		Enabled bool   `mapstructure:"enabled"`
+		Path    string `mapstructure:"path"`
+
+		// Path of the generated OpenAPI document
+		OpenAPIPath string `mapstructure:"openapi_path"`
+	} `mapstructure:"rest"`
+
+	// Endpoint turning external events into notifications (SSE transport only)
+	Webhooks struct {
+		Enabled bool   `mapstructure:"enabled"`
+		Path    string `mapstructure:"path"`
+		Token   string `mapstructure:"token"` // Expected as a bearer token
+	} `mapstructure:"webhooks"`
+
+	// Tools invoked on a schedule
+	Scheduler []*ScheduledCall `mapstructure:"scheduler"`
+
+	// Built-in tools backed by other RoadRunner plugins
+	Builtin struct {
+		KV   KVToolsConfig   `mapstructure:"kv"`
+		Jobs JobsToolsConfig `mapstructure:"jobs"`
+	} `mapstructure:"builtin"`
+
+	// Wire format of worker payloads offered during the readiness check: "json", "msgpack" or "protobuf"
+	Codec string `mapstructure:"codec"`
+
+	// Worker readiness check at startup
+	Readiness struct {
+		// Workers that must answer a Ping event before the plugin reports ready, disabled when zero
+		MinWorkers int `mapstructure:"min_workers"`
+
+		// Start the listeners only once the workers are ready
+		DelayListeners bool `mapstructure:"delay_listeners"`
+
+		// How long to wait for the workers, startup fails when exceeded
+		Timeout time.Duration `mapstructure:"timeout"`
+	} `mapstructure:"readiness"`
+
+	// Opt-in admin endpoints on a separate listener
+	Admin struct {
+		// Listener address, admin endpoints are disabled when empty
+		Address string `mapstructure:"address"`
+
+		// Expose net/http/pprof under /debug/pprof/
+		Pprof bool `mapstructure:"pprof"`
+
+		// Number of recent tool calls kept for inspection, negative disables
+		RecentCalls int `mapstructure:"recent_calls"`
+	} `mapstructure:"admin"`
+
 	// Authentication
 	Auth struct {
 		Enabled      bool `mapstructure:"enabled"`
 		SkipForStdio bool `mapstructure:"skip_for_stdio"`
+
+		// Per-transport authentication ("sse", "stdio", "rest"), replaces
+		// enabled and skip_for_stdio for the listed transports
+		Transports map[string]*ListenerAuthConfig `mapstructure:"transports"`
+
+		// How long revoked tokens are rejected
+		RevocationTTL time.Duration `mapstructure:"revocation_ttl"`
+
+		// Action when granted credentials expire: "close" or "reauthenticate"
+		OnExpiry string `mapstructure:"on_expiry"`
+
+		// Credentials read from HTTP requests in addition to the bearer token
+		Credentials struct {
+			// Pass Basic auth as "username" and "password"
+			Basic bool `mapstructure:"basic"`
+
+			// Headers, query parameters and cookies
+			Sources []*CredentialSource `mapstructure:"sources"`
+		} `mapstructure:"credentials"`
 	} `mapstructure:"auth"`
 
 	// Logging
 	Debug bool `mapstructure:"debug"`
 }
 
+// ToolOverride replaces metadata of a tool declared by PHP and changes how
+// any registered tool of that name is served
+type ToolOverride struct {
+	Title string     `mapstructure:"title"`
+	Icons []ToolIcon `mapstructure:"icons"`
+
+	// Hide the tool and reject calls, an emergency switch needing no PHP change
+	Disabled bool `mapstructure:"disabled"`
+
+	// Replaces tools.call_timeout for the tool
+	Timeout time.Duration `mapstructure:"timeout"`
+
+	// Calls of the tool across all sessions
+	RateLimit *RateLimitConfig `mapstructure:"rate_limit"`
+
+	// Hints replacing the ones declared by PHP, unset hints are kept
+	Annotations *AnnotationsOverride `mapstructure:"annotations"`
+
+	// Marks the tool deprecated, replacing what PHP declared
+	Deprecation *ToolDeprecation `mapstructure:"deprecation"`
+}
+
+// AnnotationsOverride replaces tool annotations
+type AnnotationsOverride struct {
+	Title       string `mapstructure:"title"`
+	ReadOnly    *bool  `mapstructure:"read_only_hint"`
+	Destructive *bool  `mapstructure:"destructive_hint"`
+	Idempotent  *bool  `mapstructure:"idempotent_hint"`
+	OpenWorld   *bool  `mapstructure:"open_world_hint"`
+}
+
+// KVToolsConfig enables the built-in KV tools
+type KVToolsConfig struct {
+	// Storages from the kv section exposed to clients
+	Storages []string `mapstructure:"storages"`
+
+	// Only register kv_get and kv_has
+	ReadOnly bool `mapstructure:"read_only"`
+}
+
+// JobsToolsConfig enables the built-in jobs tools
+type JobsToolsConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+
+	// Pipelines jobs may be pushed to, all pipelines when empty
+	Pipelines []string `mapstructure:"pipelines"`
+
+	// Scope authenticated sessions need to use the tools
+	Scope string `mapstructure:"scope"`
+}
+
+// ScheduledCall invokes a tool on a schedule
+type ScheduledCall struct {
+	// Registered tool name
+	Tool string `mapstructure:"tool"`
+
+	// Cron expression, macro (@hourly) or "@every <duration>"
+	Schedule string `mapstructure:"schedule"`
+
+	Arguments map[string]interface{} `mapstructure:"arguments"`
+
+	// Push the result to sessions: "message" or "resource_updated"
+	Notify string `mapstructure:"notify"`
+	URI    string `mapstructure:"uri"`
+
+	// Timeout for a single invocation
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// RateLimitConfig limits tool calls with a token bucket
+type RateLimitConfig struct {
+	// Sustained calls per second, unlimited when zero
+	Rate float64 `mapstructure:"rate"`
+
+	// Calls allowed in a burst, defaults to the rate rounded up
+	Burst int `mapstructure:"burst"`
+}
+
+// TenantConfig overrides settings for the sessions of a tenant
+type TenantConfig struct {
+	// Dedicated worker pool, the shared pool is used when empty
+	Pool *pool.Config `mapstructure:"pool"`
+
+	// Replaces the default rate limit
+	RateLimit *RateLimitConfig `mapstructure:"rate_limit"`
+}
+
+// AuditSinkConfig describes a destination of audit records
+type AuditSinkConfig struct {
+	// "file", "syslog", "http", "jobs" or the name of a collected AuditSink
+	Type string `mapstructure:"type"`
+
+	// File the records are appended to as JSON lines
+	Path string `mapstructure:"path"`
+
+	// Syslog server, network is "udp", "tcp" or "unix"
+	Network string `mapstructure:"network"`
+	Address string `mapstructure:"address"`
+	AppName string `mapstructure:"app_name"`
+
+	// Endpoint batches are posted to as a JSON array
+	URL     string            `mapstructure:"url"`
+	Headers map[string]string `mapstructure:"headers"`
+
+	// Jobs pipeline records are pushed to, e.g. one of the Kafka driver
+	Pipeline string `mapstructure:"pipeline"`
+
+	// Records shipped at once and the longest a record waits for a batch
+	BatchSize     int           `mapstructure:"batch_size"`
+	FlushInterval time.Duration `mapstructure:"flush_interval"`
+
+	// Records waiting to be shipped, further records are dropped
+	QueueSize int `mapstructure:"queue_size"`
+
+	// Timeout of a write and the retries of a failed batch, the backoff
+	// doubles with every retry
+	Timeout      time.Duration `mapstructure:"timeout"`
+	MaxRetries   int           `mapstructure:"max_retries"`
+	RetryBackoff time.Duration `mapstructure:"retry_backoff"`
+}
+
+// InitDefaults sets default values for an audit sink
+func (a *AuditSinkConfig) InitDefaults() {
+	if a.Type == AuditSinkSyslog && a.Network == "" {
+		a.Network = "udp"
+	}
+	if a.AppName == "" {
+		a.AppName = "rr-mcp"
+	}
+	if a.BatchSize == 0 {
+		a.BatchSize = 100
+	}
+	if a.FlushInterval == 0 {
+		a.FlushInterval = time.Second
+	}
+	if a.QueueSize == 0 {
+		a.QueueSize = 10000
+	}
+	if a.Timeout == 0 {
+		a.Timeout = 10 * time.Second
+	}
+	if a.MaxRetries == 0 {
+		a.MaxRetries = 3
+	}
+	if a.RetryBackoff == 0 {
+		a.RetryBackoff = time.Second
+	}
+}
+
+// Validate validates an audit sink configuration, the types of collected
+// sinks are resolved on serve
+func (a *AuditSinkConfig) Validate() error {
+	switch a.Type {
+	case "":
+		return errors.Str("type is required")
+	case AuditSinkFile:
+		if a.Path == "" {
+			return errors.Str("file sinks require path")
+		}
+	case AuditSinkSyslog:
+		if a.Address == "" {
+			return errors.Str("syslog sinks require address")
+		}
+	case AuditSinkHTTP:
+		if u, err := url.Parse(a.URL); err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			return errors.Str("http sinks require an http or https url")
+		}
+	case AuditSinkJobs:
+		if a.Pipeline == "" {
+			return errors.Str("jobs sinks require pipeline")
+		}
+	}
+	if a.BatchSize < 1 || a.QueueSize < 1 {
+		return errors.Str("batch_size and queue_size must be at least 1")
+	}
+	if a.FlushInterval < 0 || a.Timeout < 0 || a.RetryBackoff < 0 {
+		return errors.Str("flush_interval, timeout and retry_backoff must not be negative")
+	}
+	if a.MaxRetries < 0 {
+		return errors.Str("max_retries must not be negative")
+	}
+	return nil
+}
+
+// UpstreamConfig describes an upstream MCP server mounted by the plugin
+type UpstreamConfig struct {
+	// Command spawning a stdio server
+	Command []string          `mapstructure:"command"`
+	Env     map[string]string `mapstructure:"env"`
+
+	// URL of an HTTP server
+	URL       string            `mapstructure:"url"`
+	Transport string            `mapstructure:"transport"` // "streamable" or "sse"
+	Headers   map[string]string `mapstructure:"headers"`
+
+	// Prefix for re-exposed tools and prompts (defaults to the upstream name)
+	Prefix string `mapstructure:"prefix"`
+
+	// Restricts the re-exposed features to sessions of a tenant
+	Tenant string `mapstructure:"tenant"`
+
+	// Timeout for listing and proxied calls
+	Timeout time.Duration `mapstructure:"timeout"`
+}
+
+// InitDefaults sets default values for an upstream
+func (u *UpstreamConfig) InitDefaults(name string) {
+	if u.Prefix == "" {
+		u.Prefix = name
+	}
+	if u.URL != "" && u.Transport == "" {
+		u.Transport = "streamable"
+	}
+	if u.Timeout == 0 {
+		u.Timeout = 30 * time.Second
+	}
+}
+
+// Validate validates an upstream configuration
+func (u *UpstreamConfig) Validate() error {
+	if (len(u.Command) == 0) == (u.URL == "") {
+		return errors.Str("exactly one of command or url is required")
+	}
+	if u.URL != "" && u.Transport != "streamable" && u.Transport != "sse" {
+		return errors.Str("transport must be 'streamable' or 'sse'")
+	}
+	if u.Tenant != "" && !validTenantID(u.Tenant) {
+		return errors.Errorf("invalid tenant %q", u.Tenant)
+	}
+	return nil
+}
+
 // InitDefaults sets default values for configuration
 func (c *Config) InitDefaults() error {
 	if c.Transport == "" {
 		c.Transport = "sse"
 	}
 
+	if c.Mode == "" {
+		c.Mode = ModeWorkers
+	}
+
 	if c.Address == "" {
 		c.Address = "127.0.0.1:9333"
 	}
 
+	// Server identity defaults
+	if c.Server.Name == "" {
+		c.Server.Name = "roadrunner-mcp"
+	}
+	if c.Server.Version == "" {
+		c.Server.Version = "1.0.0"
+	}
+
 	// Initialize pool defaults
 	if c.Pool == nil {
 		c.Pool = &pool.Config{}
 	}
 	c.Pool.InitDefaults()
+	for _, tenant := range c.Tenants {
+		if tenant != nil && tenant.Pool != nil {
+			tenant.Pool.InitDefaults()
+		}
+	}
+	if c.Canary.Pool != nil {
+		c.Canary.Pool.InitDefaults()
+	}
+	if c.Shadow.Pool != nil {
+		c.Shadow.Pool.InitDefaults()
+		if c.Shadow.Percentage == 0 {
+			c.Shadow.Percentage = 100
+		}
+		if c.Shadow.MaxInFlight == 0 {
+			c.Shadow.MaxInFlight = int(c.Shadow.Pool.NumWorkers)
+		}
+	}
 
 	// Client defaults
 	if c.Clients.MaxConnections == 0 {
@@ -69,15 +758,229 @@ func (c *Config) InitDefaults() error {
 	if c.Clients.WriteTimeout == 0 {
 		c.Clients.WriteTimeout = 10 * time.Second
 	}
+	if c.Clients.IdleTimeout == 0 {
+		c.Clients.IdleTimeout = c.Clients.ReadTimeout
+	}
+	if c.Clients.KeepAlive == 0 {
+		c.Clients.KeepAlive = 15 * time.Second
+	}
+
+	if c.Clients.NotificationQueue == 0 {
+		c.Clients.NotificationQueue = 64
+	}
+	if c.Clients.QueueOverflow == "" {
+		c.Clients.QueueOverflow = OverflowDropOldest
+	}
+	if c.Clients.ResumeBuffer == 0 {
+		c.Clients.ResumeBuffer = 32
+	}
+
+	if c.Clients.MetricMaxValues == 0 {
+		c.Clients.MetricMaxValues = 100
+	}
+	if c.Clients.Storage.MaxKeys == 0 {
+		c.Clients.Storage.MaxKeys = 100
+	}
+	if c.Clients.Storage.MaxBytes == 0 {
+		c.Clients.Storage.MaxBytes = 64 * 1024
+	}
+
+	if c.Stdio.RestartDelay == 0 {
+		c.Stdio.RestartDelay = time.Second
+	}
+
+	if c.TCP.Framing == "" {
+		c.TCP.Framing = FramingNDJSON
+	}
+
 	if c.Clients.PingInterval == 0 {
 		c.Clients.PingInterval = 30 * time.Second
 	}
 
+	// Event defaults
+	if c.Events.TopicPrefix == "" {
+		c.Events.TopicPrefix = "mcp"
+	}
+
+	// Compression defaults
+	if len(c.Compression.Algorithms) == 0 {
+		c.Compression.Algorithms = []string{EncodingZstd, EncodingGzip}
+	}
+	if c.Compression.MinSize == 0 {
+		c.Compression.MinSize = 1024
+	}
+
+	// Access log defaults
+	if c.AccessLog.Format == "" {
+		c.AccessLog.Format = AccessLogJSON
+	}
+	if c.AccessLog.Output == "" {
+		c.AccessLog.Output = "stdout"
+	}
+	if c.AccessLog.SampleRate == 0 {
+		c.AccessLog.SampleRate = 1
+	}
+
+	// Limit defaults
+	if c.Limits.MaxRequestSize == 0 {
+		c.Limits.MaxRequestSize = 4 << 20
+	}
+	if c.Limits.MaxHeaderSize == 0 {
+		c.Limits.MaxHeaderSize = http.DefaultMaxHeaderBytes
+	}
+	if c.Limits.MaxArgumentSize == 0 {
+		c.Limits.MaxArgumentSize = 1 << 20
+	}
+
 	// Tool defaults
 	c.Tools.NotifyClientsOnChange = true
+	if c.Tools.ResyncInterval == 0 {
+		c.Tools.ResyncInterval = 5 * time.Second
+	}
+
+	if c.Codec == "" {
+		c.Codec = CodecJSON
+	}
+
+	if c.Readiness.Timeout == 0 {
+		c.Readiness.Timeout = time.Minute
+	}
+
+	if c.Tools.Spill.Dir == "" {
+		c.Tools.Spill.Dir = filepath.Join(os.TempDir(), "rr-mcp-spill")
+	}
+
+	if c.Tools.Spill.TTL == 0 {
+		c.Tools.Spill.TTL = 15 * time.Minute
+	}
+
+	if c.Tools.SchemaCheck == "" {
+		c.Tools.SchemaCheck = SchemaCheckReject
+	}
+
+	if c.Tools.NotifyDebounce == 0 {
+		c.Tools.NotifyDebounce = 500 * time.Millisecond
+	}
+
+	// Logical server defaults
+	for _, server := range c.Servers {
+		if server != nil {
+			server.InitDefaults()
+		}
+	}
+
+	// Audit sink defaults
+	for _, sink := range c.Audit.Sinks {
+		if sink != nil {
+			sink.InitDefaults()
+		}
+	}
+
+	// Upstream defaults
+	for name, upstream := range c.Upstreams {
+		if upstream != nil {
+			upstream.InitDefaults(name)
+		}
+	}
+
+	// Prompt defaults
+	if c.Prompts.PageSize == 0 {
+		c.Prompts.PageSize = 100
+	}
+	if c.Prompts.CompletionTTL == 0 {
+		c.Prompts.CompletionTTL = 5 * time.Second
+	}
+
+	// Resource defaults
+	if c.Resources.CacheSize == 0 {
+		c.Resources.CacheSize = 32 << 20
+	}
+	if c.Resources.MaxSize == 0 {
+		c.Resources.MaxSize = 16 << 20
+	}
+
+	// REST bridge defaults
+	if c.REST.Path == "" {
+		c.REST.Path = "/tools"
+	}
+	c.REST.Path = "/" + strings.Trim(c.REST.Path, "/")
+	if c.REST.OpenAPIPath == "" {
+		c.REST.OpenAPIPath = "/openapi.json"
+	}
+
+	// Scheduler defaults
+	for _, call := range c.Scheduler {
+		if call != nil && call.Timeout == 0 {
+			call.Timeout = time.Minute
+		}
+	}
+
+	// Webhook defaults
+	if c.Webhooks.Path == "" {
+		c.Webhooks.Path = "/notify"
+	}
+
+	// Built-in tools defaults
+	if c.Builtin.Jobs.Scope == "" {
+		c.Builtin.Jobs.Scope = "jobs"
+	}
+
+	// Result filter defaults
+	if c.Results.RedactReplacement == "" {
+		c.Results.RedactReplacement = "[REDACTED]"
+	}
+
+	if c.Results.Sanitize.InvalidUTF8 == "" {
+		c.Results.Sanitize.InvalidUTF8 = SanitizeReplace
+	}
+
+	if c.Results.Sanitize.Control == "" {
+		c.Results.Sanitize.Control = SanitizeStrip
+	}
+
+	if c.Results.Sanitize.ANSI == "" {
+		c.Results.Sanitize.ANSI = SanitizeStrip
+	}
+
+	// Redaction defaults
+	if c.Redaction.Builtin == nil {
+		c.Redaction.Builtin = defaultRedactionBuiltin
+	}
+	if c.Redaction.Keys == nil {
+		c.Redaction.Keys = defaultRedactionKeys
+	}
+	if c.Redaction.Replacement == "" {
+		c.Redaction.Replacement = "[REDACTED]"
+	}
+	for _, rule := range c.Redaction.Rules {
+		if rule != nil && rule.Entropy > 0 && rule.MinLength == 0 {
+			rule.MinLength = 20
+		}
+	}
+
+	// Injection scanning defaults
+	if len(c.Injection.Scanners) == 0 {
+		c.Injection.Scanners = []string{InjectionScannerHeuristic}
+	}
+
+	// Admin defaults
+	if c.Admin.RecentCalls == 0 {
+		c.Admin.RecentCalls = 100
+	}
 
 	// Auth defaults
 	c.Auth.SkipForStdio = true
+	if c.Auth.RevocationTTL == 0 {
+		c.Auth.RevocationTTL = 24 * time.Hour
+	}
+	if c.Auth.OnExpiry == "" {
+		c.Auth.OnExpiry = ExpiryClose
+	}
+	for _, source := range c.Auth.Credentials.Sources {
+		if source != nil && source.Key == "" {
+			source.Key = source.Name
+		}
+	}
 
 	return c.Validate()
 }
@@ -86,14 +989,204 @@ func (c *Config) InitDefaults() error {
 func (c *Config) Validate() error {
 	const op = errors.Op("mcp_config_validate")
 
-	if c.Transport != "sse" && c.Transport != "stdio" {
-		return errors.E(op, errors.Str("transport must be 'sse' or 'stdio'"))
+	if c.Transport != "sse" && c.Transport != "stdio" && c.Transport != transportTCP {
+		return errors.E(op, errors.Str("transport must be 'sse', 'stdio' or 'tcp'"))
+	}
+
+	if c.TCP.Framing != FramingNDJSON && c.TCP.Framing != FramingLSP {
+		return errors.E(op, errors.Errorf("tcp.framing must be %q or %q", FramingNDJSON, FramingLSP))
+	}
+
+	if c.Mode != ModeWorkers && c.Mode != ModeMock {
+		return errors.E(op, errors.Str("mode must be 'workers' or 'mock'"))
+	}
+
+	if c.Mode == ModeMock && c.Auth.Enabled {
+		return errors.E(op, errors.Str("auth requires PHP workers and cannot be enabled in mock mode"))
+	}
+
+	if c.Auth.OnExpiry != ExpiryClose && c.Auth.OnExpiry != ExpiryReauthenticate {
+		return errors.E(op, errors.Str("auth.on_expiry must be 'close' or 'reauthenticate'"))
+	}
+
+	for i, source := range c.Auth.Credentials.Sources {
+		if source == nil {
+			return errors.E(op, errors.Errorf("auth.credentials.sources[%d]: configuration is empty", i))
+		}
+		if err := source.Validate(); err != nil {
+			return errors.E(op, errors.Errorf("auth.credentials.sources[%d]: %v", i, err))
+		}
+		if source.Key == "ip" || source.Key == "user_agent" || source.Key == "client_cert_subject" {
+			return errors.E(op, errors.Errorf("auth.credentials.sources[%d]: key %q is reserved", i, source.Key))
+		}
+	}
+
+	if c.TLS != nil {
+		if err := c.TLS.Validate(); err != nil {
+			return errors.E(op, errors.Errorf("tls: %v", err))
+		}
+	}
+
+	for transport, auth := range c.Auth.Transports {
+		if transport != "sse" && transport != "stdio" && transport != transportTCP && transport != transportREST {
+			return errors.E(op, errors.Errorf("auth.transports: unknown transport %q", transport))
+		}
+		if auth == nil {
+			return errors.E(op, errors.Errorf("auth.transports.%s: configuration is empty", transport))
+		}
+		if err := auth.Validate(); err != nil {
+			return errors.E(op, errors.Errorf("auth.transports.%s: %v", transport, err))
+		}
+		if auth.Mode == AuthModePHP && c.Mode == ModeMock {
+			return errors.E(op, errors.Errorf("auth.transports.%s: php auth requires PHP workers and cannot be enabled in mock mode", transport))
+		}
+		if auth.Mode == AuthModeMTLS && transport == "stdio" {
+			return errors.E(op, errors.Str("auth.transports.stdio: mtls is only available for network transports"))
+		}
+		if auth.Mode == AuthModeMTLS && (c.TLS == nil || c.TLS.ClientCA == "") {
+			return errors.E(op, errors.Errorf("auth.transports.%s: mtls auth requires tls.client_ca", transport))
+		}
+	}
+
+	for _, algorithm := range c.Compression.Algorithms {
+		if algorithm != EncodingZstd && algorithm != EncodingGzip {
+			return errors.E(op, errors.Errorf("unknown compression algorithm %q, expected zstd or gzip", algorithm))
+		}
+	}
+
+	if c.Compression.MinSize < 0 {
+		return errors.E(op, errors.Str("compression.min_size must not be negative"))
+	}
+
+	if c.AccessLog.Format != AccessLogJSON && c.AccessLog.Format != AccessLogCombined {
+		return errors.E(op, errors.Errorf("unknown access_log.format %q, expected json or combined", c.AccessLog.Format))
+	}
+	if c.AccessLog.SampleRate < 0 || c.AccessLog.SampleRate > 1 {
+		return errors.E(op, errors.Str("access_log.sample_rate must be between 0 and 1"))
+	}
+
+	if c.Limits.MaxRequestSize < 0 || c.Limits.MaxHeaderSize < 0 || c.Limits.MaxArgumentSize < 0 {
+		return errors.E(op, errors.Str("limits must not be negative"))
+	}
+
+	if c.Mode == ModeMock && c.Tools.Filter {
+		return errors.E(op, errors.Str("tools.filter requires PHP workers and cannot be enabled in mock mode"))
+	}
+
+	if c.Mode == ModeMock && c.Policy.Enabled {
+		return errors.E(op, errors.Str("policy requires PHP workers and cannot be enabled in mock mode"))
+	}
+
+	for _, pattern := range c.Policy.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.E(op, errors.Errorf("policy.tools: invalid pattern %q", pattern))
+		}
+	}
+
+	for _, pattern := range c.Results.RedactPatterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.E(op, errors.Errorf("results.redact_patterns: invalid pattern %q: %v", pattern, err))
+		}
+	}
+
+	if slices.Contains(c.Results.Filters, ResultFilterRedactPII) && !c.Redaction.Enabled {
+		return errors.E(op, errors.Str("the redact_pii filter requires redaction.enabled"))
+	}
+
+	for _, name := range c.Redaction.Builtin {
+		if _, ok := builtinRedactionRules[name]; !ok {
+			return errors.E(op, errors.Errorf("redaction.builtin: unknown rule %q", name))
+		}
+	}
+
+	for _, rule := range c.Redaction.Rules {
+		if rule == nil || rule.Name == "" {
+			return errors.E(op, errors.Str("redaction.rules: name is required"))
+		}
+		if (rule.Pattern == "") == (rule.Entropy <= 0) {
+			return errors.E(op, errors.Errorf("redaction rule %q: exactly one of pattern and entropy is required", rule.Name))
+		}
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			return errors.E(op, errors.Errorf("redaction rule %q: invalid pattern: %v", rule.Name, err))
+		}
+	}
+
+	if c.Injection.Mode != "" && c.Injection.Mode != InjectionModeFlag && c.Injection.Mode != InjectionModeStrip {
+		return errors.E(op, errors.Str("injection.mode must be 'flag' or 'strip'"))
+	}
+
+	for _, pattern := range c.Injection.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return errors.E(op, errors.Errorf("injection.patterns: invalid pattern %q: %v", pattern, err))
+		}
+	}
+
+	if slices.Contains(c.Results.Filters, ResultFilterTruncate) && c.Results.MaxLength <= 0 {
+		return errors.E(op, errors.Str("results.max_length must be positive to use the truncate filter"))
+	}
+
+	for name, action := range map[string]string{
+		"invalid_utf8": c.Results.Sanitize.InvalidUTF8,
+		"control":      c.Results.Sanitize.Control,
+		"ansi":         c.Results.Sanitize.ANSI,
+	} {
+		if !validSanitizeAction(action) {
+			return errors.E(op, errors.Errorf("results.sanitize.%s must be 'allow', 'strip', 'replace' or 'reject'", name))
+		}
 	}
 
 	if c.Transport == "sse" && c.Address == "" {
 		return errors.E(op, errors.Str("address is required for SSE transport"))
 	}
 
+	if c.Transport == transportTCP && c.Address == "" {
+		return errors.E(op, errors.Str("address is required for TCP transport"))
+	}
+
+	if network, _ := listenAddress(c.Address); network != "tcp" && c.Transport != transportTCP {
+		return errors.E(op, errors.Str("unix socket and named pipe addresses require the tcp transport"))
+	}
+
+	if c.Transport == transportTCP {
+		if err := validateListenAddress(c.Address); err != nil {
+			return errors.E(op, errors.Errorf("address: %v", err))
+		}
+	}
+
+	if c.REST.Enabled && c.Transport != "sse" {
+		return errors.E(op, errors.Str("rest requires the SSE transport"))
+	}
+
+	if c.REST.Enabled && c.REST.Path == "/" {
+		return errors.E(op, errors.Str("rest.path must not be the root path"))
+	}
+
+	if c.Webhooks.Enabled && c.Transport != "sse" {
+		return errors.E(op, errors.Str("webhooks require the SSE transport"))
+	}
+
+	if c.Webhooks.Enabled && c.Webhooks.Token == "" {
+		return errors.E(op, errors.Str("webhooks.token is required"))
+	}
+
+	for i, call := range c.Scheduler {
+		if call == nil || call.Tool == "" {
+			return errors.E(op, errors.Errorf("scheduler[%d]: tool is required", i))
+		}
+		if _, err := parseSchedule(call.Schedule); err != nil {
+			return errors.E(op, errors.Errorf("scheduler[%d]: invalid schedule: %v", i, err))
+		}
+		switch call.Notify {
+		case "", webhookMessage:
+		case webhookResourceUpdated:
+			if call.URI == "" {
+				return errors.E(op, errors.Errorf("scheduler[%d]: uri is required for resource_updated", i))
+			}
+		default:
+			return errors.E(op, errors.Errorf("scheduler[%d]: notify must be 'message' or 'resource_updated'", i))
+		}
+	}
+
 	if c.Clients.MaxConnections < 1 {
 		return errors.E(op, errors.Str("max_connections must be at least 1"))
 	}
@@ -106,9 +1199,247 @@ func (c *Config) Validate() error {
 		return errors.E(op, errors.Str("write_timeout must be at least 1 second"))
 	}
 
+	if c.Clients.IdleTimeout < time.Second {
+		return errors.E(op, errors.Str("idle_timeout must be at least 1 second"))
+	}
+
 	if c.Clients.PingInterval < time.Second {
 		return errors.E(op, errors.Str("ping_interval must be at least 1 second"))
 	}
 
+	if c.Clients.NotificationQueue < 1 {
+		return errors.E(op, errors.Str("notification_queue must be at least 1"))
+	}
+
+	switch c.Clients.QueueOverflow {
+	case OverflowDropOldest, OverflowDisconnect:
+	default:
+		return errors.E(op, errors.Errorf("unknown queue_overflow %q, supported are drop_oldest and disconnect", c.Clients.QueueOverflow))
+	}
+
+	if c.Stdio.RestartDelay < 0 {
+		return errors.E(op, errors.Str("stdio.restart_delay must not be negative"))
+	}
+
+	if c.Clients.ResumeGrace < 0 {
+		return errors.E(op, errors.Str("resume_grace must not be negative"))
+	}
+
+	if c.Clients.ResumeBuffer < 1 {
+		return errors.E(op, errors.Str("resume_buffer must be at least 1"))
+	}
+	if err := validateMetricAttributes(c.Clients.MetricAttributes, c.Clients.MetricTenant); err != nil {
+		return errors.E(op, err)
+	}
+	labels := sessionMetricLabels(c)
+	for name := range c.Clients.MetricValues {
+		if !slices.Contains(labels, name) {
+			return errors.E(op, errors.Errorf("clients.metric_values: %q is not a label of mcp_session_calls_total", name))
+		}
+	}
+	if c.Clients.MetricMaxValues < 1 {
+		return errors.E(op, errors.Str("clients.metric_max_values must be at least 1"))
+	}
+	if c.Clients.Storage.MaxKeys < 1 || c.Clients.Storage.MaxBytes < 1 {
+		return errors.E(op, errors.Str("clients.storage quotas must be at least 1"))
+	}
+
+	if c.Tools.NotifyDebounce < 0 {
+		return errors.E(op, errors.Str("notify_debounce must not be negative"))
+	}
+
+	if c.Tools.ResyncInterval < 0 {
+		return errors.E(op, errors.Str("resync_interval must not be negative"))
+	}
+
+	if c.Tools.CallTimeout < 0 {
+		return errors.E(op, errors.Str("call_timeout must not be negative"))
+	}
+
+	switch c.Tools.SchemaCheck {
+	case SchemaCheckReject, SchemaCheckWarn, SchemaCheckOff:
+	default:
+		return errors.E(op, errors.Errorf("unknown tools.schema_check %q, supported are reject, warn and off", c.Tools.SchemaCheck))
+	}
+
+	if c.Tools.MaxResultBytes < 0 {
+		return errors.E(op, errors.Str("max_result_bytes must not be negative"))
+	}
+
+	if c.Tools.Spill.Enabled && c.Tools.MaxResultBytes == 0 {
+		return errors.E(op, errors.Str("tools.spill requires tools.max_result_bytes"))
+	}
+
+	if c.Tools.Spill.TTL < 0 {
+		return errors.E(op, errors.Str("tools.spill.ttl must not be negative"))
+	}
+
+	if _, ok := codecContentTypes[c.Codec]; !ok {
+		return errors.E(op, errors.Errorf("unknown codec %q, supported are json, msgpack and protobuf", c.Codec))
+	}
+
+	if c.Readiness.MinWorkers < 0 {
+		return errors.E(op, errors.Str("readiness.min_workers must not be negative"))
+	}
+	if err := c.validateSupervisors(); err != nil {
+		return errors.E(op, err)
+	}
+
+	if c.Readiness.MinWorkers > 0 && c.Pool != nil && c.Pool.NumWorkers > 0 && uint64(c.Readiness.MinWorkers) > c.Pool.NumWorkers {
+		return errors.E(op, errors.Errorf("readiness.min_workers (%d) exceeds pool.num_workers (%d)", c.Readiness.MinWorkers, c.Pool.NumWorkers))
+	}
+
+	for name, sink := range c.Audit.Sinks {
+		if sink == nil {
+			return errors.E(op, errors.Errorf("audit.sinks.%s: configuration is empty", name))
+		}
+		if err := sink.Validate(); err != nil {
+			return errors.E(op, errors.Errorf("audit.sinks.%s: %v", name, err))
+		}
+	}
+
+	for name, upstream := range c.Upstreams {
+		if upstream == nil {
+			return errors.E(op, errors.Errorf("upstreams.%s: configuration is empty", name))
+		}
+		if err := upstream.Validate(); err != nil {
+			return errors.E(op, errors.Errorf("upstreams.%s: %v", name, err))
+		}
+	}
+
+	if c.Resources.CacheTTL < 0 {
+		return errors.E(op, errors.Str("resources.cache_ttl must not be negative"))
+	}
+	if c.Resources.MaxSize < 0 {
+		return errors.E(op, errors.Str("resources.max_size must not be negative"))
+	}
+
+	if c.Prompts.PageSize < 0 {
+		return errors.E(op, errors.Str("prompts.page_size must not be negative"))
+	}
+
+	for name, server := range c.Servers {
+		if server == nil {
+			return errors.E(op, errors.Errorf("servers.%s: configuration is empty", name))
+		}
+		if c.Transport != "sse" {
+			return errors.E(op, errors.Errorf("servers.%s: logical servers require the sse transport", name))
+		}
+		if err := server.Validate(); err != nil {
+			return errors.E(op, errors.Errorf("servers.%s: %v", name, err))
+		}
+		if server.Auth != nil && server.Auth.Mode == AuthModePHP && c.Mode == ModeMock {
+			return errors.E(op, errors.Errorf("servers.%s: php auth requires PHP workers and cannot be enabled in mock mode", name))
+		}
+		if server.Auth != nil && server.Auth.Mode == AuthModeMTLS {
+			listenerTLS := c.TLS
+			if server.Address != "" {
+				listenerTLS = server.TLS
+			}
+			if listenerTLS == nil || listenerTLS.ClientCA == "" {
+				return errors.E(op, errors.Errorf("servers.%s: mtls auth requires tls with a client_ca on the server's listener", name))
+			}
+		}
+		if server.Address == "" && (server.Path == c.Webhooks.Path || strings.HasPrefix(server.Path, c.REST.Path)) {
+			return errors.E(op, errors.Errorf("servers.%s: path %q collides with the REST or webhook endpoint", name, server.Path))
+		}
+	}
+
+	if c.RateLimit.Rate < 0 || c.RateLimit.Burst < 0 {
+		return errors.E(op, errors.Str("rate_limit must not be negative"))
+	}
+
+	for id, tenant := range c.Tenants {
+		if !validTenantID(id) {
+			return errors.E(op, errors.Errorf("tenants: invalid tenant %q", id))
+		}
+		if tenant == nil {
+			continue
+		}
+		if tenant.Pool != nil && c.Mode == ModeMock {
+			return errors.E(op, errors.Errorf("tenants.%s: pools are not available in mock mode", id))
+		}
+		if tenant.RateLimit != nil && (tenant.RateLimit.Rate < 0 || tenant.RateLimit.Burst < 0) {
+			return errors.E(op, errors.Errorf("tenants.%s: rate_limit must not be negative", id))
+		}
+	}
+
+	if c.Canary.Pool != nil {
+		if c.Mode == ModeMock {
+			return errors.E(op, errors.Str("canary: pools are not available in mock mode"))
+		}
+		if len(c.Canary.Tools) == 0 {
+			return errors.E(op, errors.Str("canary.tools is required with a canary pool"))
+		}
+	}
+	if c.Canary.Percentage < 0 || c.Canary.Percentage > 100 {
+		return errors.E(op, errors.Str("canary.percentage must be between 0 and 100"))
+	}
+	for _, pattern := range c.Canary.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.E(op, errors.Errorf("canary.tools: invalid pattern %q", pattern))
+		}
+	}
+
+	if c.Shadow.Pool != nil {
+		if c.Mode == ModeMock {
+			return errors.E(op, errors.Str("shadow: pools are not available in mock mode"))
+		}
+		if len(c.Shadow.Tools) == 0 {
+			return errors.E(op, errors.Str("shadow.tools is required with a shadow pool"))
+		}
+		if c.Shadow.MaxInFlight < 1 {
+			return errors.E(op, errors.Str("shadow.max_in_flight must be at least 1"))
+		}
+	}
+	if c.Shadow.Percentage < 0 || c.Shadow.Percentage > 100 {
+		return errors.E(op, errors.Str("shadow.percentage must be between 0 and 100"))
+	}
+	for _, pattern := range c.Shadow.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.E(op, errors.Errorf("shadow.tools: invalid pattern %q", pattern))
+		}
+	}
+
+	for flag, rule := range c.Flags.Rules {
+		if rule == nil {
+			return errors.E(op, errors.Errorf("flags.rules.%s: configuration is empty", flag))
+		}
+		if err := rule.Validate(); err != nil {
+			return errors.E(op, errors.Errorf("flags.rules.%s: %v", flag, err))
+		}
+	}
+
+	for alias, target := range c.Tools.Aliases {
+		if target == "" || target == alias {
+			return errors.E(op, errors.Errorf("tools.aliases.%s: invalid tool name %q", alias, target))
+		}
+		if _, ok := c.Tools.Aliases[target]; ok {
+			return errors.E(op, errors.Errorf("tools.aliases.%s: %q is an alias itself", alias, target))
+		}
+	}
+
+	for name, override := range c.Tools.Overrides {
+		if override == nil {
+			continue
+		}
+		if override.Timeout < 0 {
+			return errors.E(op, errors.Errorf("tools.overrides.%s: timeout must not be negative", name))
+		}
+		if override.RateLimit != nil && (override.RateLimit.Rate < 0 || override.RateLimit.Burst < 0) {
+			return errors.E(op, errors.Errorf("tools.overrides.%s: rate_limit must not be negative", name))
+		}
+		for _, icon := range override.Icons {
+			if icon.Src == "" {
+				return errors.E(op, errors.Errorf("tools.overrides.%s: icon src is required", name))
+			}
+		}
+		if override.Deprecation != nil {
+			if _, err := override.Deprecation.disabledAt(); err != nil {
+				return errors.E(op, errors.Errorf("tools.overrides.%s.deprecation: %v", name, err))
+			}
+		}
+	}
+
 	return nil
 }
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// Prefixes of the feature keys
+const (
+	featureTool     = "tool/"
+	featurePrompt   = "prompt/"
+	featureResource = "resource/"
+)
+
+// serverFeature is a tool, prompt or resource the servers of all
+// connections offer
+type serverFeature struct {
+	add    func(s *mcp.Server)
+	remove func(s *mcp.Server)
+}
+
+// connServerSet holds the MCP server of every connection. Features are
+// registered once and applied to all servers, so a server only differs from
+// the others in what its session is given on its own. Locking order is the
+// plugin lock first.
+type connServerSet struct {
+	mu       sync.Mutex
+	features map[string]*serverFeature // feature key -> feature
+	servers  map[string]*mcp.Server    // session ID -> server
+}
+
+func newConnServerSet() *connServerSet {
+	return &connServerSet{
+		features: make(map[string]*serverFeature),
+		servers:  make(map[string]*mcp.Server),
+	}
+}
+
+// addFeature registers a feature on the servers of all connections and of
+// connections to come, replacing a feature of the same key
+func (p *Plugin) addFeature(key string, add, remove func(s *mcp.Server)) {
+	s := p.connServers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.features[key] = &serverFeature{add: add, remove: remove}
+	for _, server := range s.servers {
+		add(server)
+	}
+}
+
+// removeFeature removes a feature from the servers of all connections
+func (p *Plugin) removeFeature(key string) {
+	s := p.connServers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	feature, ok := s.features[key]
+	if !ok {
+		return
+	}
+	delete(s.features, key)
+	for _, server := range s.servers {
+		feature.remove(server)
+	}
+}
+
+// addTool registers a tool with a raw handler
+func (p *Plugin) addTool(tool *mcp.Tool, handler mcp.ToolHandler) {
+	name := tool.Name
+	p.addFeature(featureTool+name,
+		func(s *mcp.Server) { s.AddTool(tool, handler) },
+		func(s *mcp.Server) { s.RemoveTools(name) },
+	)
+}
+
+// addPrompt registers a prompt
+func (p *Plugin) addPrompt(prompt *mcp.Prompt, handler mcp.PromptHandler) {
+	name := prompt.Name
+	p.addFeature(featurePrompt+name,
+		func(s *mcp.Server) { s.AddPrompt(prompt, handler) },
+		func(s *mcp.Server) { s.RemovePrompts(name) },
+	)
+}
+
+// addResource registers a resource
+func (p *Plugin) addResource(resource *mcp.Resource, handler mcp.ResourceHandler) {
+	uri := resource.URI
+	p.addFeature(featureResource+uri,
+		func(s *mcp.Server) { s.AddResource(resource, handler) },
+		func(s *mcp.Server) { s.RemoveResources(uri) },
+	)
+}
+
+// newServer creates an MCP server with the plugin's options and middleware
+// and without features
+func (p *Plugin) newServer() *mcp.Server {
+	p.mu.RLock()
+	opts := &mcp.ServerOptions{
+		Instructions:            p.instructions,
+		RootsListChangedHandler: p.rootsChanged,
+	}
+	p.mu.RUnlock()
+
+	// Prompts only come from upstreams, which answer their completions
+	if len(p.cfg.Upstreams) > 0 {
+		opts.CompletionHandler = p.completePrompt
+	}
+
+	server := mcp.NewServer(p.serverImpl, opts)
+
+	// Capture client info and authenticate on initialize, record tool calls
+	server.AddReceivingMiddleware(p.sessionMiddleware, p.requestIDMiddleware, p.traceMiddleware, p.limitsMiddleware, p.aliasMiddleware, p.serverMiddleware, p.promptPageMiddleware, p.tenantMiddleware, p.overrideMiddleware, p.deprecationMiddleware, p.flagMiddleware, p.argumentsMiddleware, p.callMiddleware, p.resultSizeMiddleware, p.resultFilterMiddleware, p.injectionMiddleware, p.toolFilterMiddleware, p.policyMiddleware)
+
+	// Coalesce tool list notifications
+	server.AddSendingMiddleware(p.notificationMiddleware)
+
+	return server
+}
+
+// connServer returns the server of a connection, created with all
+// registered features on first use. Must not be called under lock.
+func (p *Plugin) connServer(sessionID string) *mcp.Server {
+	s := p.connServers
+	s.mu.Lock()
+	server, ok := s.servers[sessionID]
+	s.mu.Unlock()
+	if ok {
+		return server
+	}
+
+	server = p.newServer()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.servers[sessionID]; ok {
+		return existing
+	}
+	for _, feature := range s.features {
+		feature.add(server)
+	}
+	s.servers[sessionID] = server
+
+	p.log.Debug("session server created",
+		zap.String("session_id", sessionID),
+		zap.Int("features", len(s.features)),
+	)
+
+	return server
+}
+
+// dropConnServer forgets the server of a closed connection
+func (p *Plugin) dropConnServer(sessionID string) {
+	s := p.connServers
+	s.mu.Lock()
+	delete(s.servers, sessionID)
+	s.mu.Unlock()
+}
+
+// serverSessions returns the SDK sessions of all connections
+func (p *Plugin) serverSessions() []*mcp.ServerSession {
+	s := p.connServers
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sessions := make([]*mcp.ServerSession, 0, len(s.servers))
+	for _, server := range s.servers {
+		for ss := range server.Sessions() {
+			sessions = append(sessions, ss)
+		}
+	}
+
+	return sessions
+}
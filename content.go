@@ -0,0 +1,104 @@
+package mcp
+
+import (
+	"encoding/base64"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// convertContent converts worker content into MCP content
+func convertContent(contents []MCPContent) ([]mcp.Content, error) {
+	mcpContent := make([]mcp.Content, len(contents))
+
+	for i, c := range contents {
+		switch c.Type {
+		case "text":
+			mcpContent[i] = &mcp.TextContent{Text: c.Text}
+		case "image":
+			data, err := decodeData(c)
+			if err != nil {
+				return nil, fmt.Errorf("content %d: %w", i, err)
+			}
+			mcpContent[i] = &mcp.ImageContent{Data: data, MIMEType: c.MimeType}
+		case "audio":
+			data, err := decodeData(c)
+			if err != nil {
+				return nil, fmt.Errorf("content %d: %w", i, err)
+			}
+			mcpContent[i] = &mcp.AudioContent{Data: data, MIMEType: c.MimeType}
+		case "resource":
+			resource, err := convertResource(c)
+			if err != nil {
+				return nil, fmt.Errorf("content %d: %w", i, err)
+			}
+			mcpContent[i] = &mcp.EmbeddedResource{Resource: resource}
+		case "resource_link":
+			if c.URI == "" {
+				return nil, fmt.Errorf("content %d: resource_link requires uri", i)
+			}
+			mcpContent[i] = &mcp.ResourceLink{
+				URI:         c.URI,
+				Name:        c.Name,
+				Title:       c.Title,
+				Description: c.Description,
+				MIMEType:    c.MimeType,
+				Size:        c.Size,
+			}
+		default:
+			mcpContent[i] = &mcp.TextContent{Text: c.Text}
+		}
+	}
+
+	return mcpContent, nil
+}
+
+// convertResource builds embedded resource contents from either the nested
+// "resource" object or the flat uri/mimeType/text/blob fields
+func convertResource(c MCPContent) (*mcp.ResourceContents, error) {
+	src := c.Resource
+	if src == nil {
+		src = &ResourceContent{
+			URI:      c.URI,
+			MimeType: c.MimeType,
+			Text:     c.Text,
+			Blob:     c.Blob,
+		}
+	}
+
+	if src.URI == "" {
+		return nil, fmt.Errorf("resource requires uri")
+	}
+
+	resource := &mcp.ResourceContents{
+		URI:      src.URI,
+		MIMEType: src.MimeType,
+		Text:     src.Text,
+	}
+
+	// Blob is base64 on the wire, SDK expects raw bytes
+	if src.Blob != "" {
+		blob, err := base64.StdEncoding.DecodeString(src.Blob)
+		if err != nil {
+			return nil, fmt.Errorf("invalid resource blob: %w", err)
+		}
+		resource.Blob = blob
+	}
+
+	return resource, nil
+}
+
+// decodeData decodes base64 image or audio data, SDK re-encodes it on the
+// wire. Images were accepted without mimeType before audio existed and still are
+func decodeData(c MCPContent) ([]byte, error) {
+	if c.MimeType == "" && c.Type != "image" {
+		return nil, fmt.Errorf("%s requires mimeType", c.Type)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(c.Data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s data: %w", c.Type, err)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,81 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"github.com/roadrunner-server/pool/worker"
+	"go.uber.org/zap"
+)
+
+// workerCrashMessage is the tool result of a call whose worker died
+const workerCrashMessage = "worker terminated during execution"
+
+// workerCrashed reports whether a failed execution lost its worker, e.g. to
+// a segfault or the OOM killer, and logs the lost workers when it did.
+// Errors PHP reported itself leave the worker running, a crash breaks the
+// relay to the worker or takes a worker listed before the execution out of
+// the pool. The error text is not looked at, PHP errors may read like anything
+func (p *Plugin) workerCrashed(ctx context.Context, event string, pool Pool, workers []*worker.Process, err error) bool {
+	if ctx.Err() != nil || errors.Is(errors.SoftJob, err) {
+		return false
+	}
+
+	pids := lostWorkers(pool, workers)
+	if !errors.Is(errors.Network, err) && len(pids) == 0 {
+		return false
+	}
+
+	p.callLogger(ctx).Warn("worker terminated during execution",
+		zap.String("event", event),
+		zap.Int64s("pids", pids),
+		zap.Error(err),
+	)
+	return true
+}
+
+// lostWorkers returns the PIDs of workers listed before an execution that
+// are no longer in the pool
+func lostWorkers(pool Pool, workers []*worker.Process) []int64 {
+	alive := make(map[int64]bool)
+	for _, w := range pool.Workers() {
+		alive[w.Pid()] = true
+	}
+
+	var pids []int64
+	for _, w := range workers {
+		if !alive[w.Pid()] {
+			pids = append(pids, w.Pid())
+		}
+	}
+	return pids
+}
+
+// crashRetryable reports whether a call of a tool that lost its worker may
+// run again: tools.crash_retry is on and the tool is read-only or idempotent,
+// so a partial first run cannot be repeated with side effects
+func (p *Plugin) crashRetryable(name string) bool {
+	if !p.cfg.Tools.CrashRetry {
+		return false
+	}
+
+	entry, ok := p.toolSnapshot()[name]
+	if !ok || entry.Tool == nil || entry.Tool.Annotations == nil {
+		return false
+	}
+
+	annotations := entry.Tool.Annotations
+	return annotations.ReadOnlyHint || annotations.IdempotentHint
+}
+
+// workerCrashResult is the tool error result of a call whose worker died
+func workerCrashResult() *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: workerCrashMessage}},
+		IsError: true,
+		Meta: mcp.Meta{
+			"error": &ErrorInfo{Type: ErrWorkerCrash.Type, Message: workerCrashMessage, Retryable: ErrWorkerCrash.Retryable},
+		},
+	}
+}
@@ -0,0 +1,42 @@
+package mcp
+
+import (
+	"context"
+	"time"
+)
+
+// metaTimeout is the _meta key clients set to bound a tool call, in milliseconds
+const metaTimeout = "timeoutMs"
+
+// codeRequestTimeout is returned to the client when a tool call exceeds its deadline
+const codeRequestTimeout = -32001
+
+// callTimeout returns the deadline of a tool call, the client's timeout may
+// shorten the tool's override or tools.call_timeout but not extend it. Zero
+// means no deadline.
+func (p *Plugin) callTimeout(tool string, meta map[string]interface{}) time.Duration {
+	timeout := p.cfg.Tools.CallTimeout
+	if override := p.toolOverride(tool); override != nil && override.Timeout > 0 {
+		timeout = override.Timeout
+	}
+
+	// JSON numbers decode as float64
+	if ms, ok := meta[metaTimeout].(float64); ok && ms > 0 {
+		if requested := time.Duration(ms * float64(time.Millisecond)); timeout == 0 || requested < timeout {
+			timeout = requested
+		}
+	}
+
+	return timeout
+}
+
+// withCallDeadline bounds the worker execution of a tool call
+func (p *Plugin) withCallDeadline(ctx context.Context, tool string, meta map[string]interface{}) (context.Context, context.CancelFunc, time.Duration) {
+	timeout := p.callTimeout(tool, meta)
+	if timeout == 0 {
+		return ctx, func() {}, 0
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, cancel, timeout
+}
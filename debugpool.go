@@ -0,0 +1,25 @@
+package mcp
+
+import (
+	"context"
+
+	"go.uber.org/zap"
+)
+
+// debugPool reports whether the main pool runs in debug mode: it keeps no
+// workers and starts a fresh one with the current code for every event
+func (c *Config) debugPool() bool {
+	return c.Pool != nil && c.Pool.Debug
+}
+
+// bootDebugWorker starts a single worker of a debug pool, so the tools its
+// bootstrap declares over RPC are registered before the first client call
+func (p *Plugin) bootDebugWorker() {
+	ctx, cancel := context.WithTimeout(p.ctx, resyncTimeout)
+	defer cancel()
+
+	// Workers that declare their tools on boot may not answer pings
+	if _, err := p.sendEvent(ctx, "", EventPing, p.newPingPayload()); err != nil {
+		p.log.Debug("debug worker ping failed", zap.Error(err))
+	}
+}
@@ -0,0 +1,203 @@
+package mcp
+
+import (
+	"slices"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// serverDefinitionFormat is the version of the ServerDefinition document
+const serverDefinitionFormat = 1
+
+// ServerDefinition is everything PHP declared, exported as one document and
+// imported as a whole, e.g. to promote a definition between environments
+type ServerDefinition struct {
+	Format       int                    `json:"format"`
+	ExportedAt   time.Time              `json:"exportedAt,omitzero"`
+	Instructions string                 `json:"instructions,omitempty"`
+	Tools        []*DeclareToolsRequest `json:"tools,omitempty"` // By namespace and tenant
+	Resources    []ResourceDefinition   `json:"resources,omitempty"`
+
+	// Served by upstreams, exported for reference and ignored on import
+	Prompts []DefinitionPrompt `json:"prompts,omitempty"`
+}
+
+// DefinitionPrompt is a prompt re-exposed from an upstream server
+type DefinitionPrompt struct {
+	Name     string `json:"name"`
+	Upstream string `json:"upstream"`
+}
+
+// ImportDefinitionResponse lists what an import changed
+type ImportDefinitionResponse struct {
+	Registered       []string `json:"registered"`
+	Updated          []string `json:"updated"`
+	Unchanged        []string `json:"unchanged,omitempty"`
+	Removed          []string `json:"removed"` // Tools declared before and missing in the document
+	Resources        []string `json:"resources"`
+	RemovedResources []string `json:"removedResources"`
+
+	Diagnostics []ToolDiagnostic `json:"diagnostics,omitempty"` // Schema warnings
+}
+
+// exportDefinition returns the tools and resources PHP declared with the
+// current instructions
+func (p *Plugin) exportDefinition() *ServerDefinition {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	def := &ServerDefinition{
+		Format:       serverDefinitionFormat,
+		ExportedAt:   time.Now().UTC(),
+		Instructions: p.instructions,
+	}
+
+	// One declaration per namespace and tenant, in the order of their first tool
+	declarations := make(map[[2]string]*DeclareToolsRequest)
+	for _, name := range sortedKeys(p.tools) {
+		entry := p.tools[name]
+		if entry.Source != ToolSourcePHP || entry.Definition == nil {
+			continue
+		}
+
+		key := [2]string{entry.Namespace, entry.Tenant}
+		declaration, ok := declarations[key]
+		if !ok {
+			declaration = &DeclareToolsRequest{Namespace: entry.Namespace, Tenant: entry.Tenant}
+			declarations[key] = declaration
+			def.Tools = append(def.Tools, declaration)
+		}
+		declaration.Tools = append(declaration.Tools, *entry.Definition)
+	}
+
+	for _, uri := range sortedKeys(p.resources) {
+		def.Resources = append(def.Resources, *p.resources[uri])
+	}
+
+	for _, up := range p.upstreams {
+		for _, name := range sortedKeys(up.prompts) {
+			def.Prompts = append(def.Prompts, DefinitionPrompt{Name: name, Upstream: up.name})
+		}
+	}
+	slices.SortFunc(def.Prompts, func(a, b DefinitionPrompt) int {
+		if a.Name < b.Name {
+			return -1
+		}
+		if a.Name > b.Name {
+			return 1
+		}
+		return 0
+	})
+
+	return def
+}
+
+// importDefinition replaces the tools, resources and instructions declared
+// by PHP with the ones of a definition. The definition is validated as a
+// whole first, nothing changes when it is rejected.
+func (p *Plugin) importDefinition(def *ServerDefinition, resp *ImportDefinitionResponse) error {
+	if def.Format != serverDefinitionFormat {
+		return errors.Errorf("unsupported definition format %d, expected %d", def.Format, serverDefinitionFormat)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	resp.Removed = []string{}
+	resp.RemovedResources = []string{}
+
+	// Declarations of the document are applied as given
+	requests := make([]*DeclareToolsRequest, 0, len(def.Tools))
+	for _, r := range def.Tools {
+		if r == nil {
+			continue
+		}
+		requests = append(requests, &DeclareToolsRequest{
+			Namespace: r.Namespace,
+			Tenant:    r.Tenant,
+			Tools:     r.Tools,
+			Force:     true,
+		})
+	}
+
+	if err := p.validateDeclarations(requests, true); err != nil {
+		return err
+	}
+	if err := p.validateResources(def.Resources); err != nil {
+		return err
+	}
+
+	// Tools with schema errors would be skipped, the document applies as a whole
+	for _, r := range requests {
+		for _, toolDef := range r.Tools {
+			name := tenantName(r.Tenant, p.qualifiedToolName(r.Namespace, toolDef.Name))
+			for _, d := range p.checkToolSchemas(name, &toolDef) {
+				if d.Severity == SeverityError {
+					return errors.Str(d.Message)
+				}
+			}
+		}
+	}
+
+	// Tools and resources missing in the document go first
+	imported := make(map[string]string)
+	for _, r := range requests {
+		for _, toolDef := range r.Tools {
+			imported[tenantName(r.Tenant, p.qualifiedToolName(r.Namespace, toolDef.Name))] = r.Namespace
+		}
+	}
+	for _, name := range sortedKeys(p.tools) {
+		entry := p.tools[name]
+		if entry.Source != ToolSourcePHP {
+			continue
+		}
+		if namespace, ok := imported[name]; ok && namespace == entry.Namespace {
+			continue
+		}
+		p.deleteTool(name)
+		if _, ok := imported[name]; !ok {
+			resp.Removed = append(resp.Removed, name)
+		}
+	}
+
+	for _, uri := range sortedKeys(p.resources) {
+		if !slices.ContainsFunc(def.Resources, func(r ResourceDefinition) bool { return r.URI == uri }) {
+			resp.RemovedResources = append(resp.RemovedResources, uri)
+		}
+	}
+	p.removeResources(resp.RemovedResources)
+
+	declared := &DeclareToolsResponse{Registered: []string{}, Updated: []string{}}
+	for _, r := range requests {
+		p.declareTools(r, declared)
+	}
+	resp.Registered = declared.Registered
+	resp.Updated = declared.Updated
+	resp.Unchanged = declared.Unchanged
+	resp.Diagnostics = declared.Diagnostics
+
+	registered, err := p.declareResources(def.Resources)
+	if err != nil {
+		// Validated above
+		return err
+	}
+	resp.Resources = registered
+
+	p.instructions = def.Instructions
+
+	p.log.Info("server definition imported",
+		zap.Int("registered", len(resp.Registered)),
+		zap.Int("updated", len(resp.Updated)),
+		zap.Int("removed", len(resp.Removed)),
+		zap.Int("resources", len(resp.Resources)),
+		zap.Int("removed_resources", len(resp.RemovedResources)),
+	)
+
+	if p.cfg.Tools.NotifyClientsOnChange && len(resp.Registered)+len(resp.Updated)+len(resp.Removed) > 0 {
+		p.notifyToolsChanged()
+	}
+
+	return nil
+}
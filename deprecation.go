@@ -0,0 +1,178 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// deprecatedMetaKey is the _meta key of the deprecation of a tool, in its
+// listing and the results of its calls
+const deprecatedMetaKey = "deprecated"
+
+// Outcomes of calls to deprecated tools
+const (
+	deprecationServed   = "served"
+	deprecationRejected = "rejected"
+)
+
+// disabledAt returns when calls of the tool are rejected, zero when never.
+// A date disables the tool at the end of the day (UTC).
+func (d *ToolDeprecation) disabledAt() (time.Time, error) {
+	if d.DisableAfter == "" {
+		return time.Time{}, nil
+	}
+
+	if day, err := time.Parse(time.DateOnly, d.DisableAfter); err == nil {
+		return day.AddDate(0, 0, 1), nil
+	}
+
+	t, err := time.Parse(time.RFC3339, d.DisableAfter)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid disable_after %q, expected a date (2006-01-02) or an RFC 3339 time", d.DisableAfter)
+	}
+	return t, nil
+}
+
+// disabled reports whether calls of the tool are rejected by now
+func (d *ToolDeprecation) disabled(now time.Time) bool {
+	at, err := d.disabledAt()
+	return err == nil && !at.IsZero() && !now.Before(at)
+}
+
+// notice returns the text telling the model a tool is deprecated
+func (d *ToolDeprecation) notice(tool string) string {
+	text := fmt.Sprintf("Tool %q is deprecated", tool)
+	if d.ReplacedBy != "" {
+		text += fmt.Sprintf(", use %q instead", d.ReplacedBy)
+	}
+	if d.DisableAfter != "" {
+		text += fmt.Sprintf(". It will be disabled after %s", d.DisableAfter)
+	}
+	text += "."
+	if d.Message != "" {
+		text += " " + d.Message
+	}
+	return text
+}
+
+// toolDeprecation returns the deprecation of a registered tool, the one in
+// tools.overrides before the one PHP declared. Nil when it is current.
+func (p *Plugin) toolDeprecation(name string) *ToolDeprecation {
+	if override := p.toolOverride(name); override != nil && override.Deprecation != nil {
+		return override.Deprecation
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if entry, ok := p.tools[name]; ok {
+		return entry.Deprecation
+	}
+	return nil
+}
+
+// deprecationMiddleware annotates the results of deprecated tools with a
+// notice and hides and rejects them once they are disabled. It runs after
+// tenantMiddleware and sees registered names.
+func (p *Plugin) deprecationMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "tools/list" && method != "tools/call" {
+			return next(ctx, method, req)
+		}
+
+		if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok {
+			deprecation := p.toolDeprecation(params.Name)
+			if deprecation == nil {
+				return next(ctx, method, req)
+			}
+
+			if deprecation.disabled(time.Now()) {
+				p.deprecatedCalls.add(params.Name, deprecationRejected)
+				msg := fmt.Sprintf("tool %q was disabled after %s", params.Name, deprecation.DisableAfter)
+				if deprecation.ReplacedBy != "" {
+					msg += fmt.Sprintf(", use %q instead", deprecation.ReplacedBy)
+				}
+				return nil, newJSONRPCError(codeInvalidParams, msg, nil)
+			}
+
+			result, err := next(ctx, method, req)
+			if err != nil {
+				return nil, err
+			}
+
+			p.deprecatedCalls.add(params.Name, deprecationServed)
+			p.callLogger(ctx).Debug("deprecated tool called",
+				zap.String("tool", params.Name),
+				zap.String("replaced_by", deprecation.ReplacedBy),
+			)
+
+			if res, ok := result.(*mcp.CallToolResult); ok {
+				res.Content = append(res.Content, &mcp.TextContent{Text: deprecation.notice(params.Name)})
+				if res.Meta == nil {
+					res.Meta = mcp.Meta{}
+				}
+				res.Meta[deprecatedMetaKey] = deprecation
+			}
+
+			return result, nil
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res, ok := result.(*mcp.ListToolsResult); ok {
+			now := time.Now()
+			tools := make([]*mcp.Tool, 0, len(res.Tools))
+			for _, tool := range res.Tools {
+				if deprecation := p.toolDeprecation(tool.Name); deprecation == nil || !deprecation.disabled(now) {
+					tools = append(tools, tool)
+				}
+			}
+			res.Tools = tools
+		}
+
+		return result, nil
+	}
+}
+
+// deprecatedCallKey identifies a counter of deprecated tool calls
+type deprecatedCallKey struct {
+	tool    string
+	outcome string
+}
+
+// deprecatedCalls counts calls of deprecated tools
+type deprecatedCalls struct {
+	mu     sync.Mutex
+	counts map[deprecatedCallKey]uint64
+}
+
+func newDeprecatedCalls() *deprecatedCalls {
+	return &deprecatedCalls{counts: make(map[deprecatedCallKey]uint64)}
+}
+
+// add counts a call of a deprecated tool
+func (d *deprecatedCalls) add(tool, outcome string) {
+	d.mu.Lock()
+	d.counts[deprecatedCallKey{tool: tool, outcome: outcome}]++
+	d.mu.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (d *deprecatedCalls) snapshot() map[deprecatedCallKey]uint64 {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	counts := make(map[deprecatedCallKey]uint64, len(d.counts))
+	for k, v := range d.counts {
+		counts[k] = v
+	}
+	return counts
+}
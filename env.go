@@ -0,0 +1,27 @@
+package mcp
+
+import (
+	"maps"
+	"strconv"
+)
+
+// workerEnv returns the environment workers are started with, so the PHP
+// bootstrap can adapt to the server without extra config. extra holds the
+// variables of dedicated pools.
+func (p *Plugin) workerEnv(extra map[string]string) map[string]string {
+	env := map[string]string{
+		"RR_MODE":                 "mcp",
+		"RR_MCP_TRANSPORT":        p.cfg.Transport,
+		"RR_MCP_SERVER_NAME":      p.cfg.Server.Name,
+		"RR_MCP_SERVER_VERSION":   p.cfg.Server.Version,
+		"RR_MCP_PROTOCOL_VERSION": strconv.Itoa(PayloadProtocolVersion),
+	}
+
+	// stdio has no address
+	if p.cfg.Transport != "stdio" {
+		env["RR_MCP_ADDRESS"] = p.cfg.Address
+	}
+
+	maps.Copy(env, extra)
+	return env
+}
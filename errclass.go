@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// Failure classes counted by mcp_errors_total
+const (
+	errorClassAuth         = "auth_failures"
+	errorClassSchema       = "schema_validation_failures"
+	errorClassWorker       = "worker_exec_errors"
+	errorClassWorkerCrash  = "worker_crashes"
+	errorClassTimeout      = "timeouts"
+	errorClassCancellation = "cancellations"
+	errorClassOversized    = "oversized_payloads"
+	errorClassPanic        = "panics"
+	errorClassRateLimited  = "rate_limited"
+)
+
+// errorCounts counts failures by class
+type errorCounts struct {
+	mu     sync.Mutex
+	counts map[string]uint64 // class -> failures
+}
+
+// newErrorCounts starts every class at zero so alerts see the series before
+// the first failure
+func newErrorCounts() *errorCounts {
+	return &errorCounts{counts: map[string]uint64{
+		errorClassAuth:         0,
+		errorClassSchema:       0,
+		errorClassWorker:       0,
+		errorClassWorkerCrash:  0,
+		errorClassTimeout:      0,
+		errorClassCancellation: 0,
+		errorClassOversized:    0,
+		errorClassPanic:        0,
+		errorClassRateLimited:  0,
+	}}
+}
+
+// add counts a failure
+func (e *errorCounts) add(class string) {
+	e.mu.Lock()
+	e.counts[class]++
+	e.mu.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (e *errorCounts) snapshot() map[string]uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	counts := make(map[string]uint64, len(e.counts))
+	for k, v := range e.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// workerError counts a failed worker execution in mcp_errors_total and
+// classifies err by its cause and whether the worker crashed, failures of
+// mirrored calls are not counted
+func (p *Plugin) workerError(ctx context.Context, cause error, crashed bool, err error) error {
+	if isShadow(ctx) {
+		return err
+	}
+	return p.countError(workerErrorClass(ctx, cause, crashed), err)
+}
+
+// workerErrorClass classifies a failed worker execution
+func workerErrorClass(ctx context.Context, err error, crashed bool) string {
+	switch {
+	case ctx.Err() == context.Canceled:
+		return errorClassCancellation
+	case ctx.Err() == context.DeadlineExceeded, errors.Is(errors.ExecTTL, err), errors.Is(errors.TimeOut, err):
+		return errorClassTimeout
+	case crashed:
+		return errorClassWorkerCrash
+	}
+	return errorClassWorker
+}
+
+// isSchemaValidationError reports whether the SDK rejected tool arguments or
+// a structured result against the tool's schemas
+func isSchemaValidationError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, `validating "arguments"`) || strings.Contains(msg, "validating tool output")
+}
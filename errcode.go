@@ -0,0 +1,176 @@
+package mcp
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"net/http"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// JSON-RPC error codes of the taxonomy besides codeRequestTimeout and
+// codeInvalidParams
+const (
+	codeAuthFailed  = -32003
+	codeRateLimited = -32029
+	codeInternal    = -32603
+)
+
+// ErrorCode classifies plugin failures the same way for clients, PHP, logs
+// and metrics
+type ErrorCode struct {
+	Type      string // Stable name sent to clients and PHP
+	Code      int64  // JSON-RPC error code
+	Status    int    // HTTP status of REST responses
+	Retryable bool   // Whether the same request may succeed later
+
+	class string // mcp_errors_total class
+}
+
+// Error taxonomy of the plugin
+var (
+	ErrAuth        = &ErrorCode{Type: "auth", Code: codeAuthFailed, Status: http.StatusUnauthorized, class: errorClassAuth}
+	ErrTimeout     = &ErrorCode{Type: "timeout", Code: codeRequestTimeout, Status: http.StatusGatewayTimeout, Retryable: true, class: errorClassTimeout}
+	ErrWorker      = &ErrorCode{Type: "worker", Code: codeInternal, Status: http.StatusBadGateway, Retryable: true, class: errorClassWorker}
+	ErrWorkerCrash = &ErrorCode{Type: "worker_crash", Code: codeInternal, Status: http.StatusBadGateway, Retryable: true, class: errorClassWorkerCrash}
+	ErrSchema      = &ErrorCode{Type: "schema", Code: codeInvalidParams, Status: http.StatusBadRequest, class: errorClassSchema}
+	ErrRateLimited = &ErrorCode{Type: "rate_limited", Code: codeRateLimited, Status: http.StatusTooManyRequests, Retryable: true, class: errorClassRateLimited}
+)
+
+// errorCodes lists the taxonomy
+var errorCodes = []*ErrorCode{ErrAuth, ErrTimeout, ErrWorker, ErrWorkerCrash, ErrSchema, ErrRateLimited}
+
+// errorCodeByType returns the code of a type name, nil when unknown
+func errorCodeByType(typ string) *ErrorCode {
+	for _, code := range errorCodes {
+		if code.Type == typ {
+			return code
+		}
+	}
+	return nil
+}
+
+func (c *ErrorCode) Error() string {
+	return c.Type
+}
+
+// ErrorInfo is the classification of a failure sent as JSON-RPC error data,
+// in REST error bodies and RPC responses
+type ErrorInfo struct {
+	Type      string `json:"type"`
+	Message   string `json:"message,omitempty"`
+	Retryable bool   `json:"retryable"`
+}
+
+// codedError attaches an ErrorCode to a failure
+type codedError struct {
+	code *ErrorCode
+	err  error
+}
+
+func (e *codedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *codedError) Unwrap() error {
+	return e.err
+}
+
+func (e *codedError) Is(target error) bool {
+	return target == e.code
+}
+
+// withCode classifies a failure
+func withCode(code *ErrorCode, err error) error {
+	return &codedError{code: code, err: err}
+}
+
+// countError counts a failure in mcp_errors_total and classifies it when the
+// class belongs to the taxonomy
+func (p *Plugin) countError(class string, err error) error {
+	p.errorCounts.add(class)
+
+	for _, code := range errorCodes {
+		if code.class == class {
+			return withCode(code, err)
+		}
+	}
+
+	return err
+}
+
+// ErrorCodeOf returns the classification of a failure, nil when it is not
+// classified. Unlike the standard errors.As it follows errors.E chains.
+func ErrorCodeOf(err error) *ErrorCode {
+	for err != nil {
+		switch e := err.(type) {
+		case *ErrorCode:
+			return e
+		case *codedError:
+			return e.code
+		case *errors.Error:
+			err = e.Err
+		default:
+			err = stderrors.Unwrap(err)
+		}
+	}
+
+	return nil
+}
+
+// errorInfo returns the classification of a failure, either attached by the
+// plugin or received as JSON-RPC error data
+func errorInfo(err error) *ErrorInfo {
+	if code := ErrorCodeOf(err); code != nil {
+		return &ErrorInfo{Type: code.Type, Message: err.Error(), Retryable: code.Retryable}
+	}
+
+	// The SDK's wire error type is internal, its fields are read from JSON
+	for ; err != nil; err = stderrors.Unwrap(err) {
+		raw, marshalErr := json.Marshal(err)
+		if marshalErr != nil {
+			continue
+		}
+
+		var wire ToolError
+		if json.Unmarshal(raw, &wire) != nil || len(wire.Data) == 0 {
+			continue
+		}
+
+		var info ErrorInfo
+		if json.Unmarshal(wire.Data, &info) == nil && info.Type != "" {
+			info.Message = wire.Message
+			return &info
+		}
+	}
+
+	return nil
+}
+
+// jsonRPCError returns a classified failure as a JSON-RPC error carrying its
+// code and classification, other failures are returned as they are
+func jsonRPCError(err error) error {
+	code := ErrorCodeOf(err)
+	if code == nil {
+		return err
+	}
+
+	data, _ := json.Marshal(&ErrorInfo{Type: code.Type, Retryable: code.Retryable})
+
+	return newJSONRPCError(code.Code, err.Error(), data)
+}
+
+// errorFields returns the log fields of a classified failure
+func errorFields(err error) []zap.Field {
+	code := ErrorCodeOf(err)
+	if code == nil {
+		return []zap.Field{zap.Error(err)}
+	}
+
+	return []zap.Field{
+		zap.Error(err),
+		zap.String("error_type", code.Type),
+		zap.Bool("retryable", code.Retryable),
+	}
+}
@@ -0,0 +1,177 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// Actions taken when the credentials of a session expire
+const (
+	ExpiryClose          = "close"
+	ExpiryReauthenticate = "reauthenticate"
+)
+
+// sessionExpiries counts sessions closed after their credentials expired
+type sessionExpiries struct {
+	mu     sync.Mutex
+	counts map[string]uint64 // transport -> closed sessions
+}
+
+func newSessionExpiries() *sessionExpiries {
+	return &sessionExpiries{counts: make(map[string]uint64)}
+}
+
+// add counts a closed session
+func (e *sessionExpiries) add(transport string) {
+	e.mu.Lock()
+	e.counts[transport]++
+	e.mu.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (e *sessionExpiries) snapshot() map[string]uint64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	counts := make(map[string]uint64, len(e.counts))
+	for k, v := range e.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// scheduleExpiry arms the expiry timer of a session, a zero time disables
+// it. Must be called under lock.
+func (p *Plugin) scheduleExpiry(info *SessionInfo, expiresAt time.Time) {
+	if info.expiryTimer != nil {
+		info.expiryTimer.Stop()
+		info.expiryTimer = nil
+	}
+
+	info.ExpiresAt = expiresAt
+	if expiresAt.IsZero() {
+		return
+	}
+
+	sessionID := info.ID
+	info.expiryTimer = time.AfterFunc(time.Until(expiresAt), func() {
+		if !p.renewSession(p.ctx, sessionID) {
+			p.closeExpiredSession(sessionID)
+		}
+	})
+}
+
+// sessionExpired reports whether the credentials of a session have expired
+func (p *Plugin) sessionExpired(sessionID string) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	info, ok := p.sessions.get(sessionID)
+	return ok && !info.ExpiresAt.IsZero() && !time.Now().Before(info.ExpiresAt)
+}
+
+// renewSession re-authenticates a session with expired credentials when
+// configured, it reports whether the session may continue
+func (p *Plugin) renewSession(ctx context.Context, sessionID string) bool {
+	if p.cfg.Auth.OnExpiry != ExpiryReauthenticate {
+		return false
+	}
+
+	if err := p.reauthenticate(ctx, sessionID); err != nil {
+		p.errorCounts.add(errorClassAuth)
+		p.log.Warn("re-authentication failed",
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	return true
+}
+
+// reauthenticate repeats the ClientConnected event with the credentials the
+// session connected with and applies the new grant
+func (p *Plugin) reauthenticate(ctx context.Context, sessionID string) error {
+	const op = errors.Op("mcp_reauthenticate")
+
+	p.mu.RLock()
+	info, ok := p.sessions.get(sessionID)
+	var credentials map[string]string
+	params := &mcp.InitializeParams{}
+	if ok {
+		credentials = info.Credentials
+		params.ClientInfo = info.ClientInfo
+		params.Capabilities = info.Capabilities
+	}
+	p.mu.RUnlock()
+
+	if !ok {
+		return errors.E(op, errors.Errorf("unknown session: %s", sessionID))
+	}
+
+	if p.revoked.has(credentials["token"]) {
+		return errors.E(op, errors.Str("token revoked"))
+	}
+
+	authResp, err := p.authenticateSession(ctx, sessionID, credentials, params)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	if !authResp.ExpiresAt.IsZero() && !authResp.ExpiresAt.After(time.Now()) {
+		return errors.E(op, errors.Str("credentials already expired"))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	info, ok = p.sessions.get(sessionID)
+	if !ok {
+		return errors.E(op, errors.Errorf("unknown session: %s", sessionID))
+	}
+
+	info.Token = authResp.Token
+	info.Scopes = authResp.Scopes
+	info.Tenant = authResp.Tenant
+	p.scheduleExpiry(info, authResp.ExpiresAt)
+
+	p.log.Debug("session re-authenticated", zap.String("session_id", sessionID))
+
+	return nil
+}
+
+// closeExpiredSession closes a session whose credentials expired, telling
+// the client why when it accepts log messages
+func (p *Plugin) closeExpiredSession(sessionID string) {
+	p.mu.Lock()
+	info, ok := p.sessions.get(sessionID)
+	if !ok || info.expired {
+		p.mu.Unlock()
+		return
+	}
+	info.expired = true
+	ss := info.Session
+	transport := info.Transport
+	if ss == nil {
+		p.sessions.delete(sessionID)
+	}
+	p.mu.Unlock()
+
+	p.expiries.add(transport)
+
+	p.log.Info("session expired", zap.String("session_id", sessionID))
+
+	if ss != nil {
+		_ = ss.Log(p.ctx, &mcp.LoggingMessageParams{
+			Level:  "error",
+			Logger: "auth",
+			Data:   "session credentials expired, reconnect to authenticate",
+		})
+		_ = ss.Close()
+	}
+}
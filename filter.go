@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// codeInvalidParams is the JSON-RPC code the SDK uses for unknown tools
+const codeInvalidParams = -32602
+
+// toolFilterMiddleware hides the tools PHP did not allow for a session from
+// tools/list and rejects calls to them as if they did not exist
+func (p *Plugin) toolFilterMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if !p.cfg.Tools.Filter || (method != "tools/list" && method != "tools/call") {
+			return next(ctx, method, req)
+		}
+
+		sessionID := sessionIDFromContext(ctx)
+		if isTrustedTransport(p.sessionTransport(sessionID)) {
+			return next(ctx, method, req)
+		}
+
+		visible, err := p.visibleTools(ctx, sessionID)
+		if err != nil {
+			p.log.Warn("tool filter failed",
+				zap.String("session_id", sessionID),
+				zap.Error(err),
+			)
+			return nil, errors.Str("tool filter failed")
+		}
+
+		if method == "tools/call" {
+			if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok && !visible[params.Name] {
+				return nil, newJSONRPCError(codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name), nil)
+			}
+			return next(ctx, method, req)
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res, ok := result.(*mcp.ListToolsResult); ok {
+			tools := make([]*mcp.Tool, 0, len(res.Tools))
+			for _, tool := range res.Tools {
+				if visible[tool.Name] {
+					tools = append(tools, tool)
+				}
+			}
+			res.Tools = tools
+		}
+
+		return result, nil
+	}
+}
+
+// visibleTools returns the tools a session may see. Decisions are cached per
+// session and PHP is asked again when tools were registered since, or when
+// the cache expired.
+func (p *Plugin) visibleTools(ctx context.Context, sessionID string) (map[string]bool, error) {
+	const op = errors.Op("mcp_visible_tools")
+
+	p.mu.RLock()
+	info, _ := p.sessions.get(sessionID)
+	if info == nil {
+		p.mu.RUnlock()
+		return nil, errors.E(op, fmt.Errorf("unknown session: %s", sessionID))
+	}
+
+	names := sortedKeys(p.tools)
+	cached := info.VisibleTools
+	fresh := cached != nil && (p.cfg.Tools.FilterTTL == 0 || time.Since(info.VisibleToolsAt) < p.cfg.Tools.FilterTTL)
+	for _, name := range names {
+		if !fresh {
+			break
+		}
+		_, fresh = cached[name]
+	}
+
+	payloadData := &FilterToolsPayload{
+		SessionID:    sessionID,
+		Tools:        names,
+		ClientInfo:   info.ClientInfo,
+		Capabilities: info.Capabilities,
+		Scopes:       info.Scopes,
+	}
+	p.mu.RUnlock()
+
+	if fresh {
+		return cached, nil
+	}
+
+	phpResp, err := p.sendEvent(ctx, sessionID, EventFilterTools, payloadData)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	var filterResp FilterToolsResponse
+	if err := json.Unmarshal(phpResp, &filterResp); err != nil {
+		return nil, errors.E(op, fmt.Errorf("invalid worker response: %w", err))
+	}
+
+	visible := make(map[string]bool, len(names))
+	for _, name := range names {
+		visible[name] = false
+	}
+	for _, name := range filterResp.Tools {
+		if _, ok := visible[name]; ok {
+			visible[name] = true
+		}
+	}
+
+	p.mu.Lock()
+	info.VisibleTools = visible
+	info.VisibleToolsAt = time.Now()
+	p.mu.Unlock()
+
+	p.log.Debug("tool filter applied",
+		zap.String("session_id", sessionID),
+		zap.Int("visible", len(filterResp.Tools)),
+		zap.Int("registered", len(names)),
+	)
+
+	return visible, nil
+}
+
+// resetToolFilter drops cached filter decisions of a session, or of all
+// sessions when sessionID is empty, and tells the affected clients to list
+// tools again. It returns the number of affected sessions.
+func (p *Plugin) resetToolFilter(sessionID string) int {
+	p.mu.Lock()
+	var sessions []*mcp.ServerSession
+	for id, info := range p.sessions.snapshot() {
+		if sessionID != "" && id != sessionID {
+			continue
+		}
+		info.VisibleTools = nil
+		if info.Session != nil {
+			sessions = append(sessions, info.Session)
+		}
+	}
+	p.mu.Unlock()
+
+	if p.sendNotification == nil {
+		return len(sessions)
+	}
+
+	for _, ss := range sessions {
+		p.queueToolListChanged(ss)
+	}
+
+	return len(sessions)
+}
@@ -0,0 +1,193 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// FlagRule rolls tools out to some sessions. A session gets the tools when
+// its tenant is listed or it falls into the percentage.
+type FlagRule struct {
+	// Glob patterns of tool names, tenant tools match by their declared name
+	Tools []string `mapstructure:"tools"`
+
+	// Share of sessions getting the tools, 0 to 100
+	Percentage float64 `mapstructure:"percentage"`
+
+	// Tenants always getting the tools
+	Tenants []string `mapstructure:"tenants"`
+}
+
+// FlagProvider is implemented by RoadRunner plugins evaluating feature flags,
+// e.g. backed by an external flag service. The collected provider named in
+// flags.provider is consulted for every tool the rules allow.
+type FlagProvider interface {
+	// Name returns the provider name referenced in flags.provider
+	Name() string
+	// ToolEnabled reports whether a session may see and call a tool
+	ToolEnabled(ctx context.Context, tool string, session *FlagSession) (bool, error)
+}
+
+// FlagSession describes the session a flag is evaluated for
+type FlagSession struct {
+	ID         string
+	Tenant     string
+	Server     string
+	ClientName string
+}
+
+// Validate checks the rule
+func (r *FlagRule) Validate() error {
+	if len(r.Tools) == 0 {
+		return errors.Str("tools is required")
+	}
+	for _, pattern := range r.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Errorf("invalid tool pattern %q", pattern)
+		}
+	}
+	if r.Percentage < 0 || r.Percentage > 100 {
+		return errors.Str("percentage must be between 0 and 100")
+	}
+	return nil
+}
+
+// matches reports whether the rule covers a tool
+func (r *FlagRule) matches(name string) bool {
+	declared, _, _ := strings.Cut(name, tenantSeparator)
+
+	return slices.ContainsFunc(r.Tools, func(pattern string) bool {
+		matched, _ := path.Match(pattern, name)
+		if !matched {
+			matched, _ = path.Match(pattern, declared)
+		}
+		return matched
+	})
+}
+
+// enables reports whether the rule named flag gives a session the tools. The
+// bucket of a session is stable per flag, so raising the percentage only
+// adds sessions.
+func (r *FlagRule) enables(flag string, session *FlagSession) bool {
+	if session.Tenant != "" && slices.Contains(r.Tenants, session.Tenant) {
+		return true
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(flag))
+	_, _ = h.Write([]byte{0})
+	_, _ = h.Write([]byte(session.ID))
+
+	return float64(h.Sum32()%10000) < r.Percentage*100
+}
+
+// initFlagProvider resolves the provider named in flags.provider
+func (p *Plugin) initFlagProvider(collected []FlagProvider) error {
+	const op = errors.Op("mcp_init_flag_provider")
+
+	p.flagProvider = nil
+	if p.cfg.Flags.Provider == "" {
+		return nil
+	}
+
+	idx := slices.IndexFunc(collected, func(f FlagProvider) bool { return f.Name() == p.cfg.Flags.Provider })
+	if idx < 0 {
+		return errors.E(op, errors.Errorf("unknown flag provider %q", p.cfg.Flags.Provider))
+	}
+	p.flagProvider = collected[idx]
+
+	return nil
+}
+
+// flagSession returns the description of a session for flag evaluation
+func (p *Plugin) flagSession(sessionID string) *FlagSession {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	session := &FlagSession{ID: sessionID}
+	if info, ok := p.sessions.get(sessionID); ok {
+		session.Tenant = info.Tenant
+		session.Server = info.Server
+		if info.ClientInfo != nil {
+			session.ClientName = info.ClientInfo.Name
+		}
+	}
+
+	return session
+}
+
+// toolEnabled reports whether the flags give a session a tool. Every rule
+// covering the tool must enable it, then the provider decides. A failing
+// provider leaves the decision to the rules.
+func (p *Plugin) toolEnabled(ctx context.Context, name string, session *FlagSession) bool {
+	for flag, rule := range p.cfg.Flags.Rules {
+		if rule.matches(name) && !rule.enables(flag, session) {
+			return false
+		}
+	}
+
+	if p.flagProvider == nil {
+		return true
+	}
+
+	enabled, err := p.flagProvider.ToolEnabled(ctx, name, session)
+	if err != nil {
+		p.log.Warn("flag provider failed",
+			zap.String("provider", p.flagProvider.Name()),
+			zap.String("tool", name),
+			zap.String("session_id", session.ID),
+			zap.Error(err),
+		)
+		return true
+	}
+
+	return enabled
+}
+
+// flagMiddleware hides tools the feature flags do not give a session. It runs
+// after tenantMiddleware and sees registered names.
+func (p *Plugin) flagMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if (len(p.cfg.Flags.Rules) == 0 && p.flagProvider == nil) || (method != "tools/list" && method != "tools/call") {
+			return next(ctx, method, req)
+		}
+
+		sessionID := sessionIDFromContext(ctx)
+		if isTrustedTransport(p.sessionTransport(sessionID)) {
+			return next(ctx, method, req)
+		}
+		session := p.flagSession(sessionID)
+
+		if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok {
+			if !p.toolEnabled(ctx, params.Name, session) {
+				return nil, newJSONRPCError(codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name), nil)
+			}
+			return next(ctx, method, req)
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res, ok := result.(*mcp.ListToolsResult); ok {
+			tools := make([]*mcp.Tool, 0, len(res.Tools))
+			for _, tool := range res.Tools {
+				if p.toolEnabled(ctx, tool.Name, session) {
+					tools = append(tools, tool)
+				}
+			}
+			res.Tools = tools
+		}
+
+		return result, nil
+	}
+}
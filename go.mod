@@ -3,7 +3,9 @@ module github.com/roadrunner-plugins/mcp-server
 go 1.25
 
 require (
+	github.com/google/jsonschema-go v0.3.0
 	github.com/google/uuid v1.6.0
+	github.com/klauspost/compress v1.18.0
 	github.com/modelcontextprotocol/go-sdk v1.0.0
 	github.com/prometheus/client_golang v1.20.5
 	github.com/roadrunner-server/api/v4 v4.18.0
@@ -14,5 +16,23 @@ require (
 	github.com/roadrunner-server/pool/pool/static_pool v1.1.3
 	github.com/roadrunner-server/pool/state/process v1.1.3
 	github.com/roadrunner-server/pool/worker v1.1.3
+	github.com/vmihailenco/msgpack/v5 v5.4.1
 	go.uber.org/zap v1.27.0
+	golang.org/x/crypto v0.38.0
+	golang.org/x/sys v0.33.0
+	google.golang.org/protobuf v1.36.9
+)
+
+require (
+	github.com/beorn7/perks v1.0.1 // indirect
+	github.com/cespare/xxhash/v2 v2.3.0 // indirect
+	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
+	github.com/prometheus/client_model v0.6.1 // indirect
+	github.com/prometheus/common v0.55.0 // indirect
+	github.com/prometheus/procfs v0.15.1 // indirect
+	github.com/vmihailenco/tagparser/v2 v2.0.0 // indirect
+	github.com/yosida95/uritemplate/v3 v3.0.2 // indirect
+	go.uber.org/multierr v1.11.0 // indirect
+	golang.org/x/net v0.34.0 // indirect
+	golang.org/x/text v0.25.0 // indirect
 )
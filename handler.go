@@ -4,107 +4,246 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net/http"
 
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/roadrunner-server/errors"
 	"github.com/roadrunner-server/pool/payload"
 	"go.uber.org/zap"
 )
 
+// workerRequest is the request context of an event in the format of the
+// RoadRunner http plugin, so PSR-7 workers see the event headers
+type workerRequest struct {
+	RemoteAddr string              `json:"remoteAddr"`
+	Protocol   string              `json:"protocol"`
+	Method     string              `json:"method"`
+	URI        string              `json:"uri"`
+	Headers    map[string][]string `json:"headers"`
+	RawQuery   string              `json:"rawQuery"`
+	Parsed     bool                `json:"parsed"`
+}
+
+// methodPost is the X-MCP-Method header of every event
+var methodPost = []string{http.MethodPost}
+
 // sendEvent sends an event to PHP worker via WorkerPool
 func (p *Plugin) sendEvent(ctx context.Context, sessionID, eventName string, payloadData interface{}) ([]byte, error) {
 	const op = errors.Op("mcp_send_event")
 
+	// Buffers are reused across calls and released once the worker answered
+	payloadBuf, versionedBuf, contextBuf := getBuffer(), getBuffer(), getBuffer()
+	defer func() {
+		putBuffer(payloadBuf)
+		putBuffer(versionedBuf)
+		putBuffer(contextBuf)
+	}()
+
 	// Marshal payload to JSON
-	payloadJSON, err := json.Marshal(payloadData)
+	payloadJSON, err := marshalTo(payloadBuf, payloadData)
 	if err != nil {
 		return nil, errors.E(op, fmt.Errorf("failed to marshal payload: %w", err))
 	}
 
 	// Get session info for token
 	p.mu.RLock()
-	sessionInfo := p.sessions[sessionID]
+	sessionInfo, _ := p.sessions.get(sessionID)
 	p.mu.RUnlock()
 
 	// Build headers
-	headers := map[string][]string{
-		"X-MCP-Event":  {eventName},
-		"X-Session-ID": {sessionID},
-		"Content-Type": {"application/json"},
-		"X-MCP-Method": {"POST"},
-	}
+	headers := getHeaders()
+	defer putHeaders(headers)
+
+	headers["X-MCP-Event"] = []string{eventName}
+	headers["X-Session-ID"] = []string{sessionID}
+	headers["X-MCP-Method"] = methodPost
 
 	if sessionInfo != nil && sessionInfo.Token != "" {
 		headers["X-Client-Token"] = []string{sessionInfo.Token}
 	}
 
+	// Tool handlers know the tenant of the called tool, other events use the session's
+	tenant := tenantFromContext(ctx)
+	if tenant == "" && sessionInfo != nil {
+		tenant = sessionInfo.Tenant
+	}
+	if tenant != "" {
+		headers["X-MCP-Tenant"] = []string{tenant}
+	}
+
+	// Lets handlers skip side effects of mirrored calls
+	if isShadow(ctx) {
+		headers[headerShadow] = []string{"true"}
+	}
+
+	// Correlates worker logs with the tool call
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		headers[headerRequestID] = []string{requestID}
+	}
+
+	// Links the worker's spans to the caller's trace
+	for name, value := range traceFromContext(ctx) {
+		headers[name] = []string{value}
+	}
+
+	// Shape the body for the protocol version and codec the workers speak
+	payloadJSON = encodePayload(versionedBuf, p.protocolVersion(), payloadJSON, headers)
+
+	codec := p.codec()
+	body, err := encodeBody(codec, payloadJSON)
+	if err != nil {
+		return nil, errors.E(op, fmt.Errorf("failed to encode payload as %s: %w", codec, err))
+	}
+	headers["Content-Type"] = codecHeaders[codec]
+	if codec != CodecJSON {
+		headers[headerCodec] = []string{codec}
+	}
+
+	// Headers travel in the request context PSR7Worker decodes
+	requestContext, err := marshalTo(contextBuf, &workerRequest{
+		Protocol: "HTTP/1.1",
+		Method:   http.MethodPost,
+		URI:      "/",
+		Headers:  headers,
+	})
+	if err != nil {
+		return nil, errors.E(op, fmt.Errorf("failed to marshal request context: %w", err))
+	}
+
 	// Create payload for worker
 	workerPayload := &payload.Payload{
-		Context: payloadJSON,
-		Body:    payloadJSON,
+		Context: requestContext,
+		Body:    body,
 	}
 
 	// Execute via worker pool
-	p.log.Debug("sending event to worker",
+	p.callLogger(ctx).Debug("sending event to worker",
 		zap.String("event", eventName),
 		zap.String("session_id", sessionID),
 	)
 
-	// Create stop channel
+	p.mu.RLock()
+	eventHandler := p.eventHandler
+	p.mu.RUnlock()
+
+	if eventHandler != nil {
+		resp, err := eventHandler(ctx, headers, body)
+		if err != nil {
+			return nil, errors.E(op, p.workerError(ctx, err, false, err))
+		}
+		return p.workerPayload(codec, resp)
+	}
+
+	workerPool := p.poolFor(tenant)
+	switch {
+	case isShadow(ctx) && p.shadowPool != nil:
+		workerPool = p.shadowPool
+	case isCanary(ctx) && p.canaryPool != nil:
+		workerPool = p.canaryPool
+	}
+	if workerPool == nil {
+		return nil, errors.E(op, errors.Str("no worker pool is running"))
+	}
+
+	// Create stop channel, signalled to release the worker when the call is abandoned
 	stopCh := make(chan struct{}, 1)
 
-	// Execute on pool
-	responseCh, err := p.pool.Exec(ctx, workerPayload, stopCh)
+	// Workers lost to a crash are told apart by their absence afterwards
+	workers := workerPool.Workers()
+
+	// Execute on pool, the worker execution is bound to the context deadline
+	responseCh, err := workerPool.Exec(ctx, workerPayload, stopCh)
 	if err != nil {
-		return nil, errors.E(op, fmt.Errorf("worker execution failed: %w", err))
+		crashed := p.workerCrashed(ctx, eventName, workerPool, workers, err)
+		return nil, errors.E(op, p.workerError(ctx, err, crashed, fmt.Errorf("worker execution failed: %w", err)))
 	}
 
 	// Read response from channel
-	for response := range responseCh {
+	select {
+	case response, ok := <-responseCh:
+		if !ok {
+			return nil, errors.E(op, errors.Str("no response from worker"))
+		}
 		if response.Error() != nil {
-			return nil, errors.E(op, response.Error())
+			crashed := p.workerCrashed(ctx, eventName, workerPool, workers, response.Error())
+			return nil, errors.E(op, p.workerError(ctx, response.Error(), crashed, response.Error()))
 		}
 
-		return response.Body(), nil
+		return p.workerPayload(codec, response.Body())
+	case <-ctx.Done():
+		select {
+		case stopCh <- struct{}{}:
+		default:
+		}
+		return nil, errors.E(op, p.workerError(ctx, ctx.Err(), false, ctx.Err()))
 	}
+}
 
-	return nil, errors.E(op, errors.Str("no response from worker"))
+// workerPayload decompresses a worker response when compressed payloads are
+// enabled and converts it from the codec's wire format to JSON
+func (p *Plugin) workerPayload(codec string, body []byte) ([]byte, error) {
+	const op = errors.Op("mcp_worker_payload")
+
+	if p.cfg.Compression.WorkerPayloads {
+		decompressed, err := decodeWorkerPayload(body)
+		if err != nil {
+			if err == errPayloadTooLarge {
+				p.errorCounts.add(errorClassOversized)
+			}
+			return nil, errors.E(op, err)
+		}
+		body = decompressed
+	}
+
+	decoded, err := decodeBody(codec, body)
+	if err != nil {
+		return nil, errors.E(op, fmt.Errorf("invalid %s response: %w", codec, err))
+	}
+
+	return decoded, nil
 }
 
 // authenticateSession authenticates a new client session via PHP worker
-func (p *Plugin) authenticateSession(ctx context.Context, sessionID string, credentials map[string]string) (string, error) {
+func (p *Plugin) authenticateSession(ctx context.Context, sessionID string, credentials map[string]string, params *mcp.InitializeParams) (*ClientConnectedResponse, error) {
 	const op = errors.Op("mcp_authenticate_session")
 
-	// Skip authentication if disabled
-	if !p.cfg.Auth.Enabled {
-		return "", nil
-	}
-
 	// Create payload
 	payloadData := &ClientConnectedPayload{
 		SessionID:   sessionID,
 		Credentials: credentials,
 	}
 
+	p.mu.RLock()
+	if info, ok := p.sessions.get(sessionID); ok {
+		payloadData.Server = info.Server
+	}
+	p.mu.RUnlock()
+
+	if params != nil {
+		payloadData.ClientInfo = params.ClientInfo
+		payloadData.Capabilities = params.Capabilities
+	}
+
 	// Send event to PHP
 	phpResp, err := p.sendEvent(ctx, sessionID, EventClientConnected, payloadData)
 	if err != nil {
-		return "", errors.E(op, err)
+		return nil, errors.E(op, err)
 	}
 
 	// Parse response
 	var authResp ClientConnectedResponse
 	if err := json.Unmarshal(phpResp, &authResp); err != nil {
-		return "", errors.E(op, fmt.Errorf("invalid worker response: %w", err))
+		return nil, errors.E(op, fmt.Errorf("invalid worker response: %w", err))
 	}
 
 	// Check if allowed
 	if !authResp.Allowed {
-		return "", errors.E(op, fmt.Errorf("authentication failed: %s", authResp.Message))
+		return nil, errors.E(op, fmt.Errorf("authentication failed: %s", authResp.Message))
 	}
 
 	p.log.Info("session authenticated",
 		zap.String("session_id", sessionID),
 	)
 
-	return authResp.Token, nil
+	return &authResp, nil
 }
@@ -0,0 +1,93 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// benchResponse is the worker's answer to benchCallPayload
+var benchResponse = []byte(`{"content":[{"type":"text","text":"3 orders found"}],"structuredContent":{"orders":[{"id":"o-1","status":"open"},{"id":"o-2","status":"shipped"},{"id":"o-3","status":"open"}]}}`)
+
+// newBenchPlugin returns a plugin with a single session whose events are
+// answered with benchResponse. A pool's PExec cannot be built outside the
+// pool package, the event handler stands in for the worker so a call
+// completes and its response is decoded.
+func newBenchPlugin() *Plugin {
+	p := &Plugin{
+		cfg:      &Config{},
+		log:      zap.NewNop(),
+		sessions: newSessionRegistry(),
+	}
+	p.sessions.set("bench", &SessionInfo{ID: "bench", Token: "token", Transport: "sse"})
+	p.UseEventHandler(func(context.Context, map[string][]string, []byte) ([]byte, error) {
+		return benchResponse, nil
+	})
+	return p
+}
+
+// benchCallPayload is a CallTool event of a typical size
+func benchCallPayload() *CallToolPayload {
+	return &CallToolPayload{
+		SessionID: "bench",
+		ToolName:  "search_orders",
+		Namespace: "shop",
+		Arguments: json.RawMessage(`{"customer":"c-1042","status":["open","shipped"],"limit":25,"query":"blue sneakers"}`),
+		Meta:      map[string]interface{}{"progressToken": "p-1"},
+	}
+}
+
+func BenchmarkSendEvent(b *testing.B) {
+	p := newBenchPlugin()
+	ctx := withRequestID(context.Background(), "r-1")
+	payloadData := benchCallPayload()
+
+	if _, err := p.sendEvent(ctx, "bench", EventCallTool, payloadData); err != nil {
+		b.Fatalf("sendEvent: %v", err)
+	}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		_, _ = p.sendEvent(ctx, "bench", EventCallTool, payloadData)
+	}
+}
+
+// BenchmarkMarshalEvent compares encoding an event body and request context
+// with fresh allocations to the pooled buffers and header maps of sendEvent
+func BenchmarkMarshalEvent(b *testing.B) {
+	payloadData := benchCallPayload()
+
+	b.Run("unpooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			body, _ := json.Marshal(payloadData)
+			headers := map[string][]string{
+				"X-MCP-Event":  {EventCallTool},
+				"X-Session-ID": {"bench"},
+				"X-MCP-Method": {http.MethodPost},
+			}
+			requestContext, _ := json.Marshal(&workerRequest{Protocol: "HTTP/1.1", Method: http.MethodPost, URI: "/", Headers: headers})
+			_, _ = body, requestContext
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+		for b.Loop() {
+			payloadBuf, contextBuf := getBuffer(), getBuffer()
+			body, _ := marshalTo(payloadBuf, payloadData)
+			headers := getHeaders()
+			headers["X-MCP-Event"] = []string{EventCallTool}
+			headers["X-Session-ID"] = []string{"bench"}
+			headers["X-MCP-Method"] = methodPost
+			requestContext, _ := marshalTo(contextBuf, &workerRequest{Protocol: "HTTP/1.1", Method: http.MethodPost, URI: "/", Headers: headers})
+			_, _ = body, requestContext
+			putHeaders(headers)
+			putBuffer(payloadBuf)
+			putBuffer(contextBuf)
+		}
+	})
+}
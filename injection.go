@@ -0,0 +1,349 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"regexp"
+	"slices"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// Injection scanning modes
+const (
+	InjectionModeFlag  = "flag"
+	InjectionModeStrip = "strip"
+)
+
+// InjectionScannerHeuristic is the name of the built-in scanner
+const InjectionScannerHeuristic = "heuristic"
+
+// injectionMetaKey is the _meta key warning clients about suspected injections
+const injectionMetaKey = "promptInjection"
+
+// injectionRemoved replaces stripped content
+const injectionRemoved = "[removed: suspected prompt injection]"
+
+// Scanned content sources reported in metrics
+const (
+	injectionSourceTool     = "tool"
+	injectionSourceResource = "resource"
+)
+
+// InjectionScanner is implemented by RoadRunner plugins detecting prompt
+// injection in content returned to clients. Collected scanners are used when
+// listed by name in injection.scanners.
+type InjectionScanner interface {
+	// Name returns the scanner name referenced in injection.scanners
+	Name() string
+	// ScanText returns the suspicious spans of text
+	ScanText(ctx context.Context, text string) ([]InjectionFinding, error)
+}
+
+// InjectionFinding is a span of text suspected to carry a prompt injection
+type InjectionFinding struct {
+	Rule string
+
+	// Byte offsets of the span, removed in strip mode
+	Start int
+	End   int
+}
+
+// injectionReport is attached to results under the promptInjection _meta key
+type injectionReport struct {
+	Mode     string              `json:"mode"`
+	Findings []*injectionFinding `json:"findings"`
+}
+
+// injectionFinding is a reported finding
+type injectionFinding struct {
+	Scanner string `json:"scanner"`
+	Rule    string `json:"rule"`
+}
+
+// injectionRule is a heuristic rule of the built-in scanner
+type injectionRule struct {
+	name    string
+	pattern *regexp.Regexp
+}
+
+// injectionHeuristics match phrases and markup typical for injected instructions
+var injectionHeuristics = []*injectionRule{
+	{"ignore_instructions", regexp.MustCompile(`(?i)\b(?:ignore|disregard|forget|override)\s+(?:all\s+|any\s+|the\s+|your\s+)*(?:previous|prior|above|earlier|preceding|system)\s+(?:instructions|prompts?|messages|rules|directions|context)`)},
+	{"role_override", regexp.MustCompile(`(?i)\byou\s+are\s+now\s+(?:a|an|in|the)\b|\bfrom\s+now\s+on,?\s+you\s+(?:are|will|must)\b|\bact\s+as\s+(?:a|an)\s+(?:unrestricted|jailbroken|different)`)},
+	{"new_instructions", regexp.MustCompile(`(?i)\b(?:new|updated|real|actual)\s+(?:system\s+)?instructions?\s*:`)},
+	{"prompt_markup", regexp.MustCompile(`(?im)<\|?(?:im_start|im_end|system|endoftext)\|?>|\[/?INST\]|<</?SYS>>|^#{2,}\s*(?:system|instructions?)\b`)},
+	{"exfiltration", regexp.MustCompile(`(?i)\b(?:reveal|print|output|show|send|leak)\s+(?:me\s+)?(?:your|the)\s+(?:system\s+prompt|instructions|api\s+keys?|secrets?|credentials)`)},
+	{"conceal", regexp.MustCompile(`(?i)\b(?:do\s+not|don't|never)\s+(?:tell|inform|mention\s+(?:this\s+)?to|reveal\s+(?:this\s+)?to)\s+the\s+user`)},
+	{"invisible_characters", regexp.MustCompile(`[\x{200B}-\x{200F}\x{202A}-\x{202E}\x{2066}-\x{2069}\x{E0000}-\x{E007F}]+`)},
+}
+
+// heuristicScanner is the built-in regular expression scanner
+type heuristicScanner struct {
+	rules []*injectionRule
+}
+
+// newHeuristicScanner creates the built-in scanner with extra patterns
+func newHeuristicScanner(extra []string) (*heuristicScanner, error) {
+	s := &heuristicScanner{rules: append([]*injectionRule{}, injectionHeuristics...)}
+
+	for _, expr := range extra {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, err
+		}
+		s.rules = append(s.rules, &injectionRule{name: "custom", pattern: re})
+	}
+
+	return s, nil
+}
+
+func (s *heuristicScanner) Name() string {
+	return InjectionScannerHeuristic
+}
+
+func (s *heuristicScanner) ScanText(_ context.Context, text string) ([]InjectionFinding, error) {
+	var findings []InjectionFinding
+	for _, rule := range s.rules {
+		for _, loc := range rule.pattern.FindAllStringIndex(text, -1) {
+			findings = append(findings, InjectionFinding{Rule: rule.name, Start: loc[0], End: loc[1]})
+		}
+	}
+	return findings, nil
+}
+
+// injectionHitKey identifies a detection counter
+type injectionHitKey struct {
+	scanner string
+	rule    string
+	source  string
+}
+
+// injectionHits counts detections per scanner, rule and source
+type injectionHits struct {
+	mu     sync.Mutex
+	counts map[injectionHitKey]uint64
+}
+
+func newInjectionHits() *injectionHits {
+	return &injectionHits{counts: make(map[injectionHitKey]uint64)}
+}
+
+// add counts a detection
+func (h *injectionHits) add(scanner, rule, source string) {
+	h.mu.Lock()
+	h.counts[injectionHitKey{scanner: scanner, rule: rule, source: source}]++
+	h.mu.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (h *injectionHits) snapshot() map[injectionHitKey]uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counts := make(map[injectionHitKey]uint64, len(h.counts))
+	for k, v := range h.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// initInjectionScanners builds the configured scanners
+func (p *Plugin) initInjectionScanners(collected []InjectionScanner) error {
+	const op = errors.Op("mcp_init_injection_scanners")
+
+	p.injectionScanners = nil
+	if p.cfg.Injection.Mode == "" {
+		return nil
+	}
+
+	for _, name := range p.cfg.Injection.Scanners {
+		if name == InjectionScannerHeuristic {
+			scanner, err := newHeuristicScanner(p.cfg.Injection.Patterns)
+			if err != nil {
+				return errors.E(op, err)
+			}
+			p.injectionScanners = append(p.injectionScanners, scanner)
+			continue
+		}
+
+		idx := slices.IndexFunc(collected, func(s InjectionScanner) bool { return s.Name() == name })
+		if idx < 0 {
+			return errors.E(op, errors.Errorf("unknown injection scanner %q", name))
+		}
+		p.injectionScanners = append(p.injectionScanners, collected[idx])
+	}
+
+	return nil
+}
+
+// injectionMiddleware scans tool results and resource reads for prompt
+// injection, flagging or stripping suspicious content
+func (p *Plugin) injectionMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if len(p.injectionScanners) == 0 || (method != "tools/call" && method != "resources/read") {
+			return next(ctx, method, req)
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		scan := &injectionScan{plugin: p, ctx: ctx}
+
+		switch res := result.(type) {
+		case *mcp.CallToolResult:
+			scan.source = injectionSourceTool
+			for _, content := range res.Content {
+				switch c := content.(type) {
+				case *mcp.TextContent:
+					c.Text = scan.text(c.Text)
+				case *mcp.EmbeddedResource:
+					if c.Resource != nil {
+						c.Resource.Text = scan.text(c.Resource.Text)
+					}
+				}
+			}
+			if res.StructuredContent != nil {
+				data, err := json.Marshal(res.StructuredContent)
+				if err != nil {
+					return nil, err
+				}
+
+				var structured interface{}
+				if err := json.Unmarshal(data, &structured); err != nil {
+					return nil, err
+				}
+
+				res.StructuredContent = scan.value(structured)
+			}
+			if report := scan.report(); report != nil {
+				if res.Meta == nil {
+					res.Meta = mcp.Meta{}
+				}
+				res.Meta[injectionMetaKey] = report
+			}
+
+		case *mcp.ReadResourceResult:
+			scan.source = injectionSourceResource
+			for _, contents := range res.Contents {
+				if contents != nil {
+					contents.Text = scan.text(contents.Text)
+				}
+			}
+			if report := scan.report(); report != nil {
+				if res.Meta == nil {
+					res.Meta = mcp.Meta{}
+				}
+				res.Meta[injectionMetaKey] = report
+			}
+		}
+
+		if len(scan.findings) > 0 {
+			p.callLogger(ctx).Warn("suspected prompt injection",
+				zap.String("method", method),
+				zap.String("session_id", sessionIDFromContext(ctx)),
+				zap.String("mode", p.cfg.Injection.Mode),
+				zap.Int("findings", len(scan.findings)),
+			)
+		}
+
+		return result, nil
+	}
+}
+
+// injectionScan collects the findings of a single result
+type injectionScan struct {
+	plugin   *Plugin
+	ctx      context.Context
+	source   string
+	findings []*injectionFinding
+}
+
+// text scans text and returns it, stripped of findings in strip mode
+func (s *injectionScan) text(text string) string {
+	if text == "" {
+		return text
+	}
+
+	var spans [][2]int
+	for _, scanner := range s.plugin.injectionScanners {
+		findings, err := scanner.ScanText(s.ctx, text)
+		if err != nil {
+			// Scanning is advisory, content is passed through when a scanner fails
+			s.plugin.callLogger(s.ctx).Warn("injection scanner failed",
+				zap.String("scanner", scanner.Name()),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		for _, f := range findings {
+			s.findings = append(s.findings, &injectionFinding{Scanner: scanner.Name(), Rule: f.Rule})
+			s.plugin.injectionHits.add(scanner.Name(), f.Rule, s.source)
+			if f.Start >= 0 && f.End > f.Start && f.End <= len(text) {
+				spans = append(spans, [2]int{f.Start, f.End})
+			}
+		}
+	}
+
+	if s.plugin.cfg.Injection.Mode != InjectionModeStrip || len(spans) == 0 {
+		return text
+	}
+
+	return stripSpans(text, spans)
+}
+
+// value scans the strings of structured content
+func (s *injectionScan) value(v interface{}) interface{} {
+	switch value := v.(type) {
+	case string:
+		return s.text(value)
+	case []interface{}:
+		for i := range value {
+			value[i] = s.value(value[i])
+		}
+	case map[string]interface{}:
+		for k := range value {
+			value[k] = s.value(value[k])
+		}
+	}
+	return v
+}
+
+// report returns the _meta warning, nil when nothing was found
+func (s *injectionScan) report() *injectionReport {
+	if len(s.findings) == 0 {
+		return nil
+	}
+	return &injectionReport{Mode: s.plugin.cfg.Injection.Mode, Findings: s.findings}
+}
+
+// stripSpans replaces the spans of text, overlapping spans are merged
+func stripSpans(text string, spans [][2]int) string {
+	slices.SortFunc(spans, func(a, b [2]int) int { return a[0] - b[0] })
+
+	var out []byte
+	last := 0
+	for i := 0; i < len(spans); i++ {
+		start, end := spans[i][0], spans[i][1]
+		for i+1 < len(spans) && spans[i+1][0] <= end {
+			i++
+			end = max(end, spans[i][1])
+		}
+		if start < last {
+			start = last
+		}
+
+		out = append(out, text[last:start]...)
+		out = append(out, injectionRemoved...)
+		last = end
+	}
+	out = append(out, text[last:]...)
+
+	return string(out)
+}
@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// limitRequests rejects HTTP request bodies over limits.max_request_size
+// before they are read
+func (p *Plugin) limitRequests(next http.Handler) http.Handler {
+	limit := p.cfg.Limits.MaxRequestSize
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > limit {
+			p.errorCounts.add(errorClassOversized)
+			writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", limit))
+			return
+		}
+
+		// Chunked bodies fail while being read
+		r.Body = http.MaxBytesReader(w, r.Body, limit)
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// limitsMiddleware rejects tool calls with arguments over limits.max_argument_size
+// before they are decoded and sent to workers
+func (p *Plugin) limitsMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok {
+			return next(ctx, method, req)
+		}
+
+		limit := p.cfg.Limits.MaxArgumentSize
+		if len(params.Arguments) <= limit {
+			return next(ctx, method, req)
+		}
+
+		// Arguments that are not an object are rejected by the SDK
+		var arguments map[string]json.RawMessage
+		if err := json.Unmarshal(params.Arguments, &arguments); err != nil {
+			return next(ctx, method, req)
+		}
+
+		for name, value := range arguments {
+			if len(value) > limit {
+				p.errorCounts.add(errorClassOversized)
+				p.callLogger(ctx).Debug("oversize tool argument rejected",
+					zap.String("session_id", sessionIDFromContext(ctx)),
+					zap.String("tool", params.Name),
+					zap.String("argument", name),
+					zap.Int("size", len(value)),
+				)
+				return nil, newJSONRPCError(codeInvalidParams, fmt.Sprintf("argument %q of tool %q is %d bytes, the limit is %d", name, params.Name, len(value), limit), nil)
+			}
+		}
+
+		return next(ctx, method, req)
+	}
+}
@@ -0,0 +1,94 @@
+package mcp
+
+import (
+	"context"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// Address schemes of local socket listeners
+const (
+	schemeUnix = "unix://"
+	schemePipe = "pipe://"
+)
+
+// pipePrefix is the namespace of Windows named pipes
+const pipePrefix = `\\.\pipe\`
+
+// maxUnixSocketPath is the longest socket path all platforms accept
+const maxUnixSocketPath = 104
+
+// listenAddress splits an address into the network and address to listen
+// on: "unix://path" for unix sockets, "pipe://name" or \\.\pipe\name for
+// Windows named pipes, anything else is a TCP host:port
+func listenAddress(address string) (string, string) {
+	switch {
+	case strings.HasPrefix(address, schemeUnix):
+		return "unix", strings.TrimPrefix(address, schemeUnix)
+	case strings.HasPrefix(address, schemePipe):
+		return "pipe", pipePrefix + strings.TrimPrefix(address, schemePipe)
+	case strings.HasPrefix(strings.ToLower(address), strings.ToLower(pipePrefix)):
+		return "pipe", address
+	}
+	return "tcp", address
+}
+
+// validateListenAddress checks a local socket address on the current platform
+func validateListenAddress(address string) error {
+	network, addr := listenAddress(address)
+	switch network {
+	case "unix":
+		if addr == "" {
+			return errors.Str("unix socket path is empty")
+		}
+		if len(addr) > maxUnixSocketPath {
+			return errors.Errorf("unix socket path is longer than %d bytes", maxUnixSocketPath)
+		}
+	case "pipe":
+		if !pipeSupported {
+			return errors.Str("named pipes are only available on Windows")
+		}
+		if len(addr) == len(pipePrefix) {
+			return errors.Str("named pipe name is empty")
+		}
+	}
+	return nil
+}
+
+// listen opens a listener on a TCP, unix socket or named pipe address, TCP
+// connections send keepalive probes every keepAlive, negative disables them
+func listen(address string, keepAlive time.Duration) (net.Listener, error) {
+	network, addr := listenAddress(address)
+	switch network {
+	case "unix":
+		removeStaleSocket(addr)
+		return net.Listen("unix", addr)
+	case "pipe":
+		return listenPipe(addr)
+	}
+
+	lc := net.ListenConfig{KeepAlive: keepAlive}
+	return lc.Listen(context.Background(), "tcp", addr)
+}
+
+// removeStaleSocket removes a socket file left behind by a process that did
+// not shut down cleanly, a socket something still listens on is kept so the
+// listen fails. Windows reports sockets as irregular files rather than as
+// sockets, so only regular files and directories are left alone.
+func removeStaleSocket(path string) {
+	stat, err := os.Lstat(path)
+	if err != nil || stat.Mode().IsRegular() || stat.IsDir() {
+		return
+	}
+
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		_ = conn.Close()
+		return
+	}
+
+	_ = os.Remove(path)
+}
@@ -0,0 +1,249 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// Drain period of a replaced SSE listener
+const (
+	defaultListenerDrain = 30 * time.Second
+	maxListenerDrain     = 10 * time.Minute
+)
+
+// transportListener is the address and certificates the SSE or TCP
+// transport listens with
+type transportListener struct {
+	address string
+	tls     *TLSConfig
+	certs   *tlsCertificates // nil without TLS
+}
+
+// newClientServer creates the HTTP server of a listener clients connect to
+func (p *Plugin) newClientServer(address string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:           address,
+		Handler:        handler,
+		ReadTimeout:    p.cfg.Clients.ReadTimeout,
+		WriteTimeout:   p.cfg.Clients.WriteTimeout,
+		IdleTimeout:    p.cfg.Clients.IdleTimeout,
+		MaxHeaderBytes: p.cfg.Limits.MaxHeaderSize,
+	}
+}
+
+// swapListener moves the transport to a new address or certificates without
+// a restart and returns the number of sessions told to reconnect. New
+// certificates on the same address apply to new connections in place.
+// Otherwise the new listener starts first; TCP connections stay open, SSE
+// sessions of the replaced listener are told to reconnect and closed once
+// the drain period ends.
+func (p *Plugin) swapListener(address string, tlsCfg *TLSConfig, drain time.Duration) (int, error) {
+	const op = errors.Op("mcp_swap_listener")
+
+	if p.cfg.Transport != "sse" && p.cfg.Transport != transportTCP {
+		return 0, errors.E(op, errors.Errorf("the %s transport has no listener", p.cfg.Transport))
+	}
+	if tlsCfg != nil {
+		if err := tlsCfg.Validate(); err != nil {
+			return 0, errors.E(op, errors.Errorf("tls: %v", err))
+		}
+	}
+	if drain <= 0 {
+		drain = defaultListenerDrain
+	}
+	drain = min(drain, maxListenerDrain)
+
+	p.swapMu.Lock()
+	defer p.swapMu.Unlock()
+
+	p.mu.RLock()
+	current := p.listener
+	p.mu.RUnlock()
+
+	if current == nil {
+		return 0, errors.E(op, errors.Str("the transport is not listening"))
+	}
+	if address == "" {
+		address = current.address
+	}
+	if network, _ := listenAddress(address); network != "tcp" && p.cfg.Transport != transportTCP {
+		return 0, errors.E(op, errors.Str("unix socket and named pipe addresses require the tcp transport"))
+	}
+	if err := validateListenAddress(address); err != nil {
+		return 0, errors.E(op, errors.Errorf("address: %v", err))
+	}
+
+	if address == current.address {
+		return 0, p.replaceCertificates(current, tlsCfg)
+	}
+
+	protocols := httpProtocols
+	if p.cfg.Transport == transportTCP {
+		protocols = nil
+	}
+
+	ln, certs, err := listenTLS(address, tlsCfg, p.cfg.Clients.KeepAlive, protocols)
+	if err != nil {
+		return 0, errors.E(op, err)
+	}
+	next := &transportListener{address: address, tls: tlsCfg, certs: certs}
+
+	p.mu.Lock()
+	if p.ctx.Err() != nil {
+		p.mu.Unlock()
+		_ = ln.Close()
+		return 0, errors.E(op, errors.Str("the plugin is stopping"))
+	}
+
+	if p.cfg.Transport == transportTCP {
+		old := p.tcpListener
+		p.tcpListener = ln
+		p.listener = next
+		p.mu.Unlock()
+
+		go func() {
+			defer p.watchCertificates(certs)()
+			if err := p.acceptTCP(ln); err != nil {
+				p.log.Error("transport error", zap.Error(err))
+			}
+		}()
+		_ = old.Close()
+
+		p.log.Info("TCP transport moved",
+			zap.String("previous_address", current.address),
+			zap.String("address", address),
+		)
+
+		return 0, nil
+	}
+
+	old := p.httpServer
+	srv := p.newClientServer(address, old.Handler)
+	p.httpServer = srv
+	p.listener = next
+	sessions := p.mainListenerSessions()
+	p.mu.Unlock()
+
+	go func() {
+		defer p.watchCertificates(certs)()
+		if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+			p.log.Error("transport error", zap.Error(err))
+		}
+	}()
+
+	p.log.Info("SSE transport moved",
+		zap.String("previous_address", current.address),
+		zap.String("address", address),
+		zap.Int("draining_sessions", len(sessions)),
+		zap.Duration("drain", drain),
+	)
+
+	go p.drainListener(old, sessions, address, drain)
+
+	return len(sessions), nil
+}
+
+// replaceCertificates loads new certificates for the current listener
+func (p *Plugin) replaceCertificates(current *transportListener, tlsCfg *TLSConfig) error {
+	const op = errors.Op("mcp_replace_certificates")
+
+	if (tlsCfg == nil) != (current.certs == nil) {
+		return errors.E(op, errors.Str("switching between TLS and plain connections requires a new address"))
+	}
+	if tlsCfg == nil {
+		return nil
+	}
+
+	if err := current.certs.load(tlsCfg); err != nil {
+		return errors.E(op, err)
+	}
+
+	p.mu.Lock()
+	p.listener = &transportListener{address: current.address, tls: tlsCfg, certs: current.certs}
+	p.mu.Unlock()
+
+	p.log.Info("listener certificates replaced", zap.String("address", current.address))
+
+	return nil
+}
+
+// mainListenerSessions returns the initialized SSE sessions served by the
+// main listener, must be called under lock
+func (p *Plugin) mainListenerSessions() map[string]*mcp.ServerSession {
+	sessions := make(map[string]*mcp.ServerSession)
+	for id, info := range p.sessions.snapshot() {
+		if info.Transport != "sse" || info.Session == nil {
+			continue
+		}
+		if server, ok := p.cfg.Servers[info.Server]; ok && server.Address != "" {
+			continue
+		}
+		sessions[id] = info.Session
+	}
+	return sessions
+}
+
+// drainListener tells the sessions of a replaced SSE listener where the
+// transport moved and closes the listener once they reconnected or the
+// drain period ended. Messages of the remaining sessions are accepted
+// until then.
+func (p *Plugin) drainListener(old *http.Server, sessions map[string]*mcp.ServerSession, address string, drain time.Duration) {
+	for _, ss := range sessions {
+		p.enqueueNotification(ss, notificationMessage, func(ctx context.Context) (err error) {
+			defer p.recoverNotification(ss, &err)
+			return ss.Log(ctx, &mcp.LoggingMessageParams{
+				Level:  "warning",
+				Logger: "transport",
+				Data: map[string]any{
+					"event":   "listener_moved",
+					"address": address,
+				},
+			})
+		})
+	}
+
+	deadline := time.NewTimer(drain)
+	defer deadline.Stop()
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+wait:
+	for p.remainingSessions(sessions) > 0 {
+		select {
+		case <-deadline.C:
+			break wait
+		case <-p.ctx.Done():
+			break wait
+		case <-ticker.C:
+		}
+	}
+
+	remaining := p.remainingSessions(sessions)
+	if err := old.Close(); err != nil {
+		p.log.Error("failed to close replaced listener", zap.Error(err))
+	}
+
+	p.log.Info("replaced listener closed",
+		zap.String("address", old.Addr),
+		zap.Int("closed_sessions", remaining),
+	)
+}
+
+// remainingSessions counts the sessions still connected
+func (p *Plugin) remainingSessions(sessions map[string]*mcp.ServerSession) int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	remaining := 0
+	for id := range sessions {
+		if _, ok := p.sessions.get(id); ok {
+			remaining++
+		}
+	}
+	return remaining
+}
@@ -0,0 +1,187 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// transportLoadTest is the transport of load test sessions
+const transportLoadTest = "loadtest"
+
+// Load test limits
+const (
+	loadTestDefaultConcurrency = 10
+	loadTestDefaultDuration    = 10 * time.Second
+	loadTestMaxConcurrency     = 1000
+	loadTestMaxDuration        = 5 * time.Minute
+)
+
+// loadTestWorker is the outcome of a single caller
+type loadTestWorker struct {
+	latencies  []time.Duration
+	errors     int
+	toolErrors int
+	firstError string
+}
+
+// loadTest calls a tool from concurrent local sessions for the requested
+// duration. Calls go through the regular middleware and the worker pool but
+// are not recorded in the call history.
+func (p *Plugin) loadTest(ctx context.Context, req *LoadTestRequest) (*LoadTestResponse, error) {
+	if req.Tool == "" {
+		return nil, fmt.Errorf("tool is required")
+	}
+
+	if _, exists := p.toolSnapshot()[req.Tool]; !exists {
+		return nil, fmt.Errorf("tool %q is not registered", req.Tool)
+	}
+
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = loadTestDefaultConcurrency
+	}
+	if concurrency > loadTestMaxConcurrency {
+		return nil, fmt.Errorf("concurrency must not exceed %d", loadTestMaxConcurrency)
+	}
+
+	duration := req.Duration
+	if duration <= 0 {
+		duration = loadTestDefaultDuration
+	}
+	if duration > loadTestMaxDuration {
+		return nil, fmt.Errorf("duration must not exceed %s", loadTestMaxDuration)
+	}
+
+	params := &mcp.CallToolParams{Name: req.Tool}
+	if len(req.Arguments) > 0 {
+		params.Arguments = req.Arguments
+	}
+
+	sessions := make([]*mcp.ClientSession, 0, concurrency)
+	closers := make([]func(), 0, concurrency)
+	defer func() {
+		for _, closeSession := range closers {
+			closeSession()
+		}
+	}()
+
+	for range concurrency {
+		cs, closeSession, err := p.connectLocal(ctx, transportLoadTest, map[string]string{})
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, cs)
+		closers = append(closers, closeSession)
+	}
+
+	p.log.Info("load test started",
+		zap.String("tool", req.Tool),
+		zap.Int("concurrency", concurrency),
+		zap.Duration("duration", duration),
+	)
+
+	runCtx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	workers := make([]*loadTestWorker, concurrency)
+	started := time.Now()
+
+	var wg sync.WaitGroup
+	for i, cs := range sessions {
+		workers[i] = &loadTestWorker{}
+		wg.Add(1)
+		go func(w *loadTestWorker, cs *mcp.ClientSession) {
+			defer wg.Done()
+
+			for runCtx.Err() == nil {
+				start := time.Now()
+				result, err := cs.CallTool(runCtx, params)
+				if runCtx.Err() != nil {
+					// Calls interrupted by the end of the test are not counted
+					return
+				}
+
+				w.latencies = append(w.latencies, time.Since(start))
+				switch {
+				case err != nil:
+					w.errors++
+					if w.firstError == "" {
+						w.firstError = err.Error()
+					}
+				case result.IsError:
+					w.toolErrors++
+				}
+			}
+		}(workers[i], cs)
+	}
+	wg.Wait()
+
+	resp := loadTestReport(workers, time.Since(started))
+	resp.Tool = req.Tool
+	resp.Concurrency = concurrency
+
+	p.log.Info("load test finished",
+		zap.String("tool", req.Tool),
+		zap.Int("calls", resp.Calls),
+		zap.Int("errors", resp.Errors),
+		zap.Duration("p99", resp.Latency.P99),
+	)
+
+	return resp, nil
+}
+
+// loadTestReport aggregates the outcome of the callers
+func loadTestReport(workers []*loadTestWorker, elapsed time.Duration) *LoadTestResponse {
+	resp := &LoadTestResponse{Duration: elapsed}
+
+	var latencies []time.Duration
+	for _, w := range workers {
+		latencies = append(latencies, w.latencies...)
+		resp.Errors += w.errors
+		resp.ToolErrors += w.toolErrors
+		if resp.FirstError == "" {
+			resp.FirstError = w.firstError
+		}
+	}
+
+	resp.Calls = len(latencies)
+	if resp.Calls == 0 {
+		return resp
+	}
+
+	slices.Sort(latencies)
+
+	var total time.Duration
+	for _, l := range latencies {
+		total += l
+	}
+
+	resp.Throughput = float64(resp.Calls) / elapsed.Seconds()
+	resp.Latency = LoadTestStats{
+		Min:  latencies[0],
+		Mean: total / time.Duration(resp.Calls),
+		P50:  percentile(latencies, 50),
+		P90:  percentile(latencies, 90),
+		P95:  percentile(latencies, 95),
+		P99:  percentile(latencies, 99),
+		Max:  latencies[len(latencies)-1],
+	}
+
+	return resp
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, pct int) time.Duration {
+	rank := (pct*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+
+	return sorted[rank-1]
+}
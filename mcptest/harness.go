@@ -0,0 +1,215 @@
+// Package mcptest runs the MCP plugin in-process for unit tests, without
+// RoadRunner or PHP workers. Worker events are answered by a fake Worker and
+// clients connect over in-memory transports.
+package mcptest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	mcpserver "github.com/roadrunner-plugins/mcp-server"
+	"github.com/roadrunner-server/pool/pool"
+	"github.com/roadrunner-server/pool/pool/static_pool"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest"
+)
+
+// Harness is a running plugin backed by a fake worker
+type Harness struct {
+	Plugin *mcpserver.Plugin
+	Worker *Worker
+
+	tb testing.TB
+}
+
+// New initializes and serves the plugin with cfg. A nil cfg uses the defaults.
+// The SSE listener binds a random local port and the plugin stops on cleanup.
+func New(tb testing.TB, cfg *mcpserver.Config) *Harness {
+	tb.Helper()
+
+	if cfg == nil {
+		cfg = &mcpserver.Config{}
+	}
+	if cfg.Transport == "" || cfg.Transport == "sse" {
+		cfg.Transport = "sse"
+		cfg.Address = "127.0.0.1:0"
+	}
+
+	h := &Harness{
+		Plugin: &mcpserver.Plugin{},
+		Worker: NewWorker(),
+		tb:     tb,
+	}
+
+	logger := zaptest.NewLogger(tb)
+	if err := h.Plugin.Init(&configurer{cfg: cfg}, &namedLogger{log: logger}, server{}); err != nil {
+		tb.Fatalf("mcptest: init: %v", err)
+	}
+
+	h.Plugin.UseEventHandler(h.Worker.Handle)
+
+	errCh := h.Plugin.Serve()
+	select {
+	case err := <-errCh:
+		tb.Fatalf("mcptest: serve: %v", err)
+	default:
+	}
+
+	tb.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = h.Plugin.Stop(ctx)
+	})
+
+	return h
+}
+
+// DeclareTools declares tools as a PHP worker would over RPC
+func (h *Harness) DeclareTools(req *mcpserver.DeclareToolsRequest) *mcpserver.DeclareToolsResponse {
+	h.tb.Helper()
+
+	rpc, ok := h.Plugin.RPC().(interface {
+		DeclareTools(*mcpserver.DeclareToolsRequest, *mcpserver.DeclareToolsResponse) error
+	})
+	if !ok {
+		h.tb.Fatal("mcptest: plugin RPC has no DeclareTools method")
+	}
+
+	resp := &mcpserver.DeclareToolsResponse{}
+	if err := rpc.DeclareTools(req, resp); err != nil {
+		h.tb.Fatalf("mcptest: declare tools: %v", err)
+	}
+
+	return resp
+}
+
+// DeclareResources declares resources as a PHP worker would over RPC
+func (h *Harness) DeclareResources(req *mcpserver.DeclareResourcesRequest) *mcpserver.DeclareResourcesResponse {
+	h.tb.Helper()
+
+	rpc, ok := h.Plugin.RPC().(interface {
+		DeclareResources(*mcpserver.DeclareResourcesRequest, *mcpserver.DeclareResourcesResponse) error
+	})
+	if !ok {
+		h.tb.Fatal("mcptest: plugin RPC has no DeclareResources method")
+	}
+
+	resp := &mcpserver.DeclareResourcesResponse{}
+	if err := rpc.DeclareResources(req, resp); err != nil {
+		h.tb.Fatalf("mcptest: declare resources: %v", err)
+	}
+
+	return resp
+}
+
+// Connect opens an initialized client session, failing the test on error
+func (h *Harness) Connect(credentials map[string]string) *Client {
+	h.tb.Helper()
+
+	c, err := h.Dial(credentials)
+	if err != nil {
+		h.tb.Fatalf("mcptest: connect: %v", err)
+	}
+
+	return c
+}
+
+// Dial opens a client session and returns the error of the handshake,
+// useful to test rejected credentials
+func (h *Harness) Dial(credentials map[string]string) (*Client, error) {
+	cs, closeSession, err := h.Plugin.ConnectInMemory(context.Background(), credentials)
+	if err != nil {
+		return nil, err
+	}
+	h.tb.Cleanup(closeSession)
+
+	return &Client{Session: cs, tb: h.tb}, nil
+}
+
+// Client drives a session the way an MCP client would
+type Client struct {
+	Session *mcp.ClientSession
+
+	tb testing.TB
+}
+
+// InitializeResult returns the server's answer to initialize
+func (c *Client) InitializeResult() *mcp.InitializeResult {
+	return c.Session.InitializeResult()
+}
+
+// ListTools returns all tools listed to the client
+func (c *Client) ListTools() []*mcp.Tool {
+	c.tb.Helper()
+
+	var tools []*mcp.Tool
+	for tool, err := range c.Session.Tools(context.Background(), nil) {
+		if err != nil {
+			c.tb.Fatalf("mcptest: list tools: %v", err)
+		}
+		tools = append(tools, tool)
+	}
+
+	return tools
+}
+
+// CallTool calls a tool, failing the test on protocol errors
+func (c *Client) CallTool(name string, arguments any) *mcp.CallToolResult {
+	c.tb.Helper()
+
+	result, err := c.CallToolErr(name, arguments)
+	if err != nil {
+		c.tb.Fatalf("mcptest: call tool %q: %v", name, err)
+	}
+
+	return result
+}
+
+// CallToolErr calls a tool and returns protocol errors
+func (c *Client) CallToolErr(name string, arguments any) (*mcp.CallToolResult, error) {
+	params := &mcp.CallToolParams{Name: name}
+	if arguments != nil {
+		params.Arguments = arguments
+	}
+
+	return c.Session.CallTool(context.Background(), params)
+}
+
+// configurer serves the harness configuration to the plugin
+type configurer struct {
+	cfg *mcpserver.Config
+}
+
+func (c *configurer) UnmarshalKey(name string, out any) error {
+	cfg, ok := out.(*mcpserver.Config)
+	if !ok || name != mcpserver.PluginName {
+		return errors.New("mcptest: unsupported configuration key " + name)
+	}
+
+	*cfg = *c.cfg
+
+	return nil
+}
+
+func (c *configurer) Has(name string) bool {
+	return name == mcpserver.PluginName
+}
+
+// namedLogger hands the test logger to the plugin
+type namedLogger struct {
+	log *zap.Logger
+}
+
+func (l *namedLogger) NamedLogger(name string) *zap.Logger {
+	return l.log.Named(name)
+}
+
+// server refuses to create pools, workers are replaced by the fake Worker
+type server struct{}
+
+func (server) NewPool(context.Context, *pool.Config, map[string]string, *zap.Logger) (*static_pool.Pool, error) {
+	return nil, errors.New("mcptest: worker pools are not available")
+}
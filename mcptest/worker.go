@@ -0,0 +1,218 @@
+package mcptest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	mcpserver "github.com/roadrunner-plugins/mcp-server"
+)
+
+// ToolFunc answers a CallTool event in place of a PHP tool handler
+type ToolFunc func(ctx context.Context, call *mcpserver.CallToolPayload) (*mcpserver.CallToolResponse, error)
+
+// AuthFunc answers a ClientConnected event in place of the PHP auth handler
+type AuthFunc func(ctx context.Context, client *mcpserver.ClientConnectedPayload) (*mcpserver.ClientConnectedResponse, error)
+
+// FilterFunc answers a FilterTools event with the tools visible to a session
+type FilterFunc func(ctx context.Context, filter *mcpserver.FilterToolsPayload) ([]string, error)
+
+// PolicyFunc answers a BeforeToolCall event in place of a PHP policy
+type PolicyFunc func(ctx context.Context, call *mcpserver.BeforeToolCallPayload) (*mcpserver.BeforeToolCallResponse, error)
+
+// ResourceFunc answers a ReadResource event in place of a PHP resource handler
+type ResourceFunc func(ctx context.Context, read *mcpserver.ReadResourcePayload) (*mcpserver.ReadResourceResponse, error)
+
+// Worker is a fake PHP worker answering plugin events
+type Worker struct {
+	mu        sync.RWMutex
+	auth      AuthFunc
+	filter    FilterFunc
+	policy    PolicyFunc
+	tools     map[string]ToolFunc
+	resources map[string]ResourceFunc
+	calls     []*mcpserver.CallToolPayload
+}
+
+// NewWorker creates a worker allowing every client and knowing no tools
+func NewWorker() *Worker {
+	return &Worker{tools: make(map[string]ToolFunc), resources: make(map[string]ResourceFunc)}
+}
+
+// HandleTool registers the handler of a tool by its declared name
+func (w *Worker) HandleTool(name string, fn ToolFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.tools[name] = fn
+}
+
+// HandleResource registers the handler of a resource by its URI
+func (w *Worker) HandleResource(uri string, fn ResourceFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.resources[uri] = fn
+}
+
+// HandleAuth sets the handler deciding whether clients may connect
+func (w *Worker) HandleAuth(fn AuthFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.auth = fn
+}
+
+// HandleFilter sets the handler deciding which tools a session may see,
+// all tools are visible by default
+func (w *Worker) HandleFilter(fn FilterFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.filter = fn
+}
+
+// HandlePolicy sets the handler deciding on tool calls, all calls are
+// allowed by default
+func (w *Worker) HandlePolicy(fn PolicyFunc) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.policy = fn
+}
+
+// Calls returns the CallTool events received so far
+func (w *Worker) Calls() []*mcpserver.CallToolPayload {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return append([]*mcpserver.CallToolPayload(nil), w.calls...)
+}
+
+// Handle implements mcpserver.EventHandler
+func (w *Worker) Handle(ctx context.Context, headers map[string][]string, body []byte) ([]byte, error) {
+	var event string
+	if values := headers["X-MCP-Event"]; len(values) > 0 {
+		event = values[0]
+	}
+
+	switch event {
+	case mcpserver.EventClientConnected:
+		var client mcpserver.ClientConnectedPayload
+		if err := json.Unmarshal(body, &client); err != nil {
+			return nil, err
+		}
+
+		w.mu.RLock()
+		auth := w.auth
+		w.mu.RUnlock()
+
+		if auth == nil {
+			return json.Marshal(&mcpserver.ClientConnectedResponse{Allowed: true})
+		}
+
+		resp, err := auth(ctx, &client)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp)
+
+	case mcpserver.EventCallTool:
+		var call mcpserver.CallToolPayload
+		if err := json.Unmarshal(body, &call); err != nil {
+			return nil, err
+		}
+
+		w.mu.Lock()
+		w.calls = append(w.calls, &call)
+		fn, ok := w.tools[call.ToolName]
+		w.mu.Unlock()
+
+		if !ok {
+			return nil, fmt.Errorf("mcptest: no handler for tool %q", call.ToolName)
+		}
+
+		resp, err := fn(ctx, &call)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp)
+
+	case mcpserver.EventReadResource:
+		var read mcpserver.ReadResourcePayload
+		if err := json.Unmarshal(body, &read); err != nil {
+			return nil, err
+		}
+
+		w.mu.RLock()
+		fn, ok := w.resources[read.URI]
+		w.mu.RUnlock()
+
+		if !ok {
+			return nil, fmt.Errorf("mcptest: no handler for resource %q", read.URI)
+		}
+
+		resp, err := fn(ctx, &read)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp)
+
+	case mcpserver.EventFilterTools:
+		var filter mcpserver.FilterToolsPayload
+		if err := json.Unmarshal(body, &filter); err != nil {
+			return nil, err
+		}
+
+		w.mu.RLock()
+		fn := w.filter
+		w.mu.RUnlock()
+
+		if fn == nil {
+			return json.Marshal(&mcpserver.FilterToolsResponse{Tools: filter.Tools})
+		}
+
+		tools, err := fn(ctx, &filter)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(&mcpserver.FilterToolsResponse{Tools: tools})
+
+	case mcpserver.EventBeforeToolCall:
+		var call mcpserver.BeforeToolCallPayload
+		if err := json.Unmarshal(body, &call); err != nil {
+			return nil, err
+		}
+
+		w.mu.RLock()
+		fn := w.policy
+		w.mu.RUnlock()
+
+		if fn == nil {
+			return json.Marshal(&mcpserver.BeforeToolCallResponse{Allowed: true})
+		}
+
+		resp, err := fn(ctx, &call)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(resp)
+
+	case mcpserver.EventPing:
+		return json.Marshal(&mcpserver.PingResponse{ProtocolVersion: mcpserver.PayloadProtocolVersion})
+
+	case mcpserver.EventTokenRevoked, mcpserver.EventSessionTokenRotated, mcpserver.EventRootsChanged:
+		return []byte("{}"), nil
+
+	default:
+		return nil, fmt.Errorf("mcptest: unexpected event %q", event)
+	}
+}
+
+// Text builds a tool response with a single text content
+func Text(text string) *mcpserver.CallToolResponse {
+	return &mcpserver.CallToolResponse{
+		Content: []mcpserver.MCPContent{{Type: "text", Text: text}},
+	}
+}
@@ -1,6 +1,8 @@
 package mcp
 
 import (
+	"strings"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/zap"
 )
@@ -15,9 +17,41 @@ type StatsExporter struct {
 	toolDuration    *prometheus.Desc
 	toolErrors      *prometheus.Desc
 
+	// Failures by class
+	errors *prometheus.Desc
+
+	// Redaction and prompt injection metrics
+	redactions          *prometheus.Desc
+	injectionDetections *prometheus.Desc
+
 	// Session metrics
-	activeSessions *prometheus.Desc
-	totalSessions  *prometheus.Desc
+	activeSessions       *prometheus.Desc
+	totalSessions        *prometheus.Desc
+	expiredSessions      *prometheus.Desc
+	slowConsumers        *prometheus.Desc
+	notificationsDropped *prometheus.Desc
+
+	// Canary routing metrics
+	canaryCalls *prometheus.Desc
+
+	// Tool calls by session attributes
+	sessionCalls *prometheus.Desc
+
+	// Shadow mirroring metrics
+	shadowCalls    *prometheus.Desc
+	shadowDuration *prometheus.Desc
+
+	// Audit metrics
+	auditRecords *prometheus.Desc
+
+	// Calls of deprecated tools and by alias
+	deprecatedCalls *prometheus.Desc
+	aliasCalls      *prometheus.Desc
+
+	// Ping RPC metrics
+	pings         *prometheus.Desc
+	pingDuration  *prometheus.Desc
+	pingRoundTrip *prometheus.Desc
 
 	// Worker metrics
 	workersTotal  *prometheus.Desc
@@ -58,6 +92,27 @@ func newStatsExporter(p *Plugin) *StatsExporter {
 			nil,
 		),
 
+		errors: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "errors_total"),
+			"Total number of failures by class",
+			[]string{"class"},
+			nil,
+		),
+
+		redactions: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "redactions_total"),
+			"Total number of redacted values by rule and target",
+			[]string{"rule", "target"},
+			nil,
+		),
+
+		injectionDetections: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "injection_detections_total"),
+			"Total number of suspected prompt injections by scanner, rule and source",
+			[]string{"scanner", "rule", "source"},
+			nil,
+		),
+
 		activeSessions: prometheus.NewDesc(
 			prometheus.BuildFQName("mcp", "", "active_sessions"),
 			"Number of active MCP sessions",
@@ -72,6 +127,97 @@ func newStatsExporter(p *Plugin) *StatsExporter {
 			nil,
 		),
 
+		expiredSessions: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "expired_sessions_total"),
+			"Total number of sessions closed after their credentials expired",
+			[]string{"transport"},
+			nil,
+		),
+
+		slowConsumers: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "slow_consumer_evictions_total"),
+			"Total number of SSE sessions closed for not reading their stream",
+			[]string{"server"},
+			nil,
+		),
+
+		notificationsDropped: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "notifications_dropped_total"),
+			"Total number of notifications dropped from full session queues",
+			[]string{"method", "policy"},
+			nil,
+		),
+
+		canaryCalls: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "canary_calls_total"),
+			"Total number of tool calls eligible for the canary by the pool that ran them",
+			[]string{"tool", "pool", "outcome"},
+			nil,
+		),
+
+		sessionCalls: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "session_calls_total"),
+			"Total number of tool calls by the attributes of the calling session",
+			append([]string{"tool", "outcome"}, sessionMetricLabels(p.cfg)...),
+			nil,
+		),
+
+		shadowCalls: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "shadow_calls_total"),
+			"Total number of mirrored tool calls by the pool that ran them",
+			[]string{"tool", "pool", "outcome"},
+			nil,
+		),
+
+		shadowDuration: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "shadow_duration_seconds_total"),
+			"Total execution time of mirrored tool calls by the pool that ran them",
+			[]string{"tool", "pool", "outcome"},
+			nil,
+		),
+
+		auditRecords: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "audit_records_total"),
+			"Total number of audit records by sink and whether they were shipped or dropped",
+			[]string{"sink", "outcome"},
+			nil,
+		),
+
+		deprecatedCalls: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "deprecated_tool_calls_total"),
+			"Total number of calls of deprecated tools by tool and whether they were served or rejected",
+			[]string{"tool", "outcome"},
+			nil,
+		),
+
+		aliasCalls: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "tool_alias_calls_total"),
+			"Total number of tool calls made by an alias, by alias and tool",
+			[]string{"alias", "tool"},
+			nil,
+		),
+
+		pings: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "worker_pings_total"),
+			"Total number of worker pings sent by the Ping RPC",
+			[]string{"outcome"},
+			nil,
+		),
+
+		pingDuration: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "worker_ping_duration_seconds_total"),
+			"Total round trip time of worker pings sent by the Ping RPC",
+			[]string{"outcome"},
+			nil,
+		),
+
+		pingRoundTrip: prometheus.NewDesc(
+			prometheus.BuildFQName("mcp", "", "worker_ping_round_trip_seconds"),
+			"Round trip time of the last answered worker ping",
+			nil,
+			nil,
+		),
+
 		workersTotal: prometheus.NewDesc(
 			prometheus.BuildFQName("mcp", "", "workers_total"),
 			"Total number of PHP workers",
@@ -101,28 +247,75 @@ func (s *StatsExporter) Describe(ch chan<- *prometheus.Desc) {
 	ch <- s.toolCalls
 	ch <- s.toolDuration
 	ch <- s.toolErrors
+	ch <- s.errors
+	ch <- s.redactions
+	ch <- s.injectionDetections
 	ch <- s.activeSessions
 	ch <- s.totalSessions
+	ch <- s.expiredSessions
+	ch <- s.slowConsumers
+	ch <- s.notificationsDropped
+	ch <- s.canaryCalls
+	ch <- s.sessionCalls
+	ch <- s.shadowCalls
+	ch <- s.shadowDuration
+	ch <- s.auditRecords
+	ch <- s.deprecatedCalls
+	ch <- s.aliasCalls
+	ch <- s.pings
+	ch <- s.pingDuration
+	ch <- s.pingRoundTrip
 	ch <- s.workersTotal
 	ch <- s.workersActive
 	ch <- s.workersIdle
 }
 
-// Collect implements prometheus.Collector
+// Collect implements prometheus.Collector, it reads registry snapshots and
+// counters without the plugin lock so scrapes do not stall tool calls
 func (s *StatsExporter) Collect(ch chan<- prometheus.Metric) {
-	s.plugin.mu.RLock()
-	defer s.plugin.mu.RUnlock()
-
 	// Tools registered
 	ch <- prometheus.MustNewConstMetric(
 		s.toolsRegistered,
 		prometheus.GaugeValue,
-		float64(len(s.plugin.tools)),
+		float64(len(s.plugin.toolSnapshot())),
 	)
 
+	// Failures by class
+	for class, count := range s.plugin.errorCounts.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.errors,
+			prometheus.CounterValue,
+			float64(count),
+			class,
+		)
+	}
+
+	// Redactions by rule and target
+	for key, count := range s.plugin.redactionHits.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.redactions,
+			prometheus.CounterValue,
+			float64(count),
+			key.rule,
+			key.target,
+		)
+	}
+
+	// Prompt injection detections
+	for key, count := range s.plugin.injectionHits.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.injectionDetections,
+			prometheus.CounterValue,
+			float64(count),
+			key.scanner,
+			key.rule,
+			key.source,
+		)
+	}
+
 	// Active sessions by transport
 	sessionsByTransport := make(map[string]int)
-	for _, info := range s.plugin.sessions {
+	for _, info := range s.plugin.sessions.snapshot() {
 		sessionsByTransport[info.Transport]++
 	}
 
@@ -135,9 +328,148 @@ func (s *StatsExporter) Collect(ch chan<- prometheus.Metric) {
 		)
 	}
 
+	// Sessions closed on expiry
+	for transport, count := range s.plugin.expiries.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.expiredSessions,
+			prometheus.CounterValue,
+			float64(count),
+			transport,
+		)
+	}
+
+	// SSE sessions evicted as slow consumers, the main endpoint has an empty server
+	for server, count := range s.plugin.slowConsumers.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.slowConsumers,
+			prometheus.CounterValue,
+			float64(count),
+			server,
+		)
+	}
+
+	// Notifications dropped from full session queues
+	for key, count := range s.plugin.notifications.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.notificationsDropped,
+			prometheus.CounterValue,
+			float64(count),
+			key.method,
+			key.policy,
+		)
+	}
+
+	// Canary routed calls
+	for key, count := range s.plugin.canaryCalls.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.canaryCalls,
+			prometheus.CounterValue,
+			float64(count),
+			key.tool,
+			key.pool,
+			key.outcome,
+		)
+	}
+
+	// Calls by session attributes
+	for key, count := range s.plugin.attributedCalls.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.sessionCalls,
+			prometheus.CounterValue,
+			float64(count),
+			strings.Split(key, "\x00")...,
+		)
+	}
+
+	// Mirrored calls
+	for key, stat := range s.plugin.shadowCalls.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.shadowCalls,
+			prometheus.CounterValue,
+			float64(stat.count),
+			key.tool,
+			key.pool,
+			key.outcome,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			s.shadowDuration,
+			prometheus.CounterValue,
+			stat.seconds,
+			key.tool,
+			key.pool,
+			key.outcome,
+		)
+	}
+
+	// Audit records
+	s.plugin.mu.RLock()
+	audit := s.plugin.audit
+	s.plugin.mu.RUnlock()
+	for sink, stats := range audit.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.auditRecords,
+			prometheus.CounterValue,
+			float64(stats.shipped),
+			sink,
+			"shipped",
+		)
+		ch <- prometheus.MustNewConstMetric(
+			s.auditRecords,
+			prometheus.CounterValue,
+			float64(stats.dropped),
+			sink,
+			"dropped",
+		)
+	}
+
+	// Calls of deprecated tools
+	for key, count := range s.plugin.deprecatedCalls.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.deprecatedCalls,
+			prometheus.CounterValue,
+			float64(count),
+			key.tool,
+			key.outcome,
+		)
+	}
+
+	// Tool calls by alias
+	for key, count := range s.plugin.aliasCalls.snapshot() {
+		ch <- prometheus.MustNewConstMetric(
+			s.aliasCalls,
+			prometheus.CounterValue,
+			float64(count),
+			key.alias,
+			key.tool,
+		)
+	}
+
+	// Worker pings
+	pings, roundTrip := s.plugin.pings.snapshot()
+	for outcome, stat := range pings {
+		ch <- prometheus.MustNewConstMetric(
+			s.pings,
+			prometheus.CounterValue,
+			float64(stat.count),
+			outcome,
+		)
+		ch <- prometheus.MustNewConstMetric(
+			s.pingDuration,
+			prometheus.CounterValue,
+			stat.seconds,
+			outcome,
+		)
+	}
+	if pings[canaryOutcomeSuccess].count > 0 {
+		ch <- prometheus.MustNewConstMetric(
+			s.pingRoundTrip,
+			prometheus.GaugeValue,
+			roundTrip.Seconds(),
+		)
+	}
+
 	// Worker metrics
-	if s.plugin.pool != nil {
-		workers := s.plugin.Workers()
+	if workers := s.plugin.Workers(); workers != nil {
 		totalWorkers := len(workers)
 		activeWorkers := 0
 		idleWorkers := 0
@@ -174,11 +506,8 @@ func (s *StatsExporter) Collect(ch chan<- prometheus.Metric) {
 
 // logMetrics logs current metrics
 func (s *StatsExporter) logMetrics() {
-	s.plugin.mu.RLock()
-	defer s.plugin.mu.RUnlock()
-
 	s.plugin.log.Info("current metrics",
-		zap.Int("tools_registered", len(s.plugin.tools)),
-		zap.Int("active_sessions", len(s.plugin.sessions)),
+		zap.Int("tools_registered", len(s.plugin.toolSnapshot())),
+		zap.Int("active_sessions", s.plugin.sessions.len()),
 	)
 }
@@ -0,0 +1,181 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"text/template"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// Plugin modes
+const (
+	ModeWorkers = "workers"
+	ModeMock    = "mock"
+)
+
+// MockTool is a tool answered with a canned response in mock mode
+type MockTool struct {
+	ToolDefinition `mapstructure:",squash"`
+
+	Response MockResponse `json:"response" mapstructure:"response"`
+}
+
+// MockResponse is the canned result of a mock tool. Text fields are Go
+// templates rendered with the call arguments, e.g. "Sunny in {{.city}}"
+type MockResponse struct {
+	Content           []MCPContent           `json:"content" mapstructure:"content"`
+	IsError           bool                   `json:"isError" mapstructure:"isError"`
+	StructuredContent map[string]interface{} `json:"structuredContent,omitempty" mapstructure:"structuredContent"`
+
+	// Simulated execution time
+	Delay time.Duration `json:"delay,omitempty" mapstructure:"delay"`
+}
+
+// mockFixture is the format of the fixture file
+type mockFixture struct {
+	Tools []*MockTool `json:"tools"`
+}
+
+// loadMockTools returns the mock tools from the config and the fixture file
+func (p *Plugin) loadMockTools() ([]*MockTool, error) {
+	const op = errors.Op("mcp_load_mock_tools")
+
+	tools := append([]*MockTool{}, p.cfg.Mock.Tools...)
+
+	if p.cfg.Mock.Fixture != "" {
+		data, err := os.ReadFile(p.cfg.Mock.Fixture)
+		if err != nil {
+			return nil, errors.E(op, err)
+		}
+
+		var fixture mockFixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			return nil, errors.E(op, fmt.Errorf("invalid fixture %s: %w", p.cfg.Mock.Fixture, err))
+		}
+
+		tools = append(tools, fixture.Tools...)
+	}
+
+	return tools, nil
+}
+
+// registerMockTools registers the mock tools
+func (p *Plugin) registerMockTools() error {
+	const op = errors.Op("mcp_register_mock_tools")
+
+	tools, err := p.loadMockTools()
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, mt := range tools {
+		if mt == nil || mt.Name == "" {
+			return errors.E(op, errors.Str("mock tool name is required"))
+		}
+
+		if mt.InputSchema == nil {
+			mt.InputSchema = map[string]interface{}{"type": "object"}
+		}
+		if mt.InputSchema["type"] != "object" {
+			return errors.E(op, errors.Errorf("mock tool %q: inputSchema must have type \"object\"", mt.Name))
+		}
+
+		name := p.qualifiedToolName("", mt.Name)
+		if _, exists := p.tools[name]; exists {
+			return errors.E(op, errors.Errorf("mock tool %q is declared twice", name))
+		}
+
+		tool := p.newTool(name, mt.ToolDefinition)
+		p.addTool(tool, p.mockToolHandler(mt))
+
+		now := time.Now()
+		p.setTool(name, &toolEntry{
+			Tool:         tool,
+			Version:      mt.Version,
+			Schema:       mt.InputSchema,
+			Source:       ToolSourceMock,
+			RegisteredAt: now,
+			UpdatedAt:    now,
+		})
+	}
+
+	p.log.Info("mock tools registered", zap.Int("tools", len(tools)))
+
+	return nil
+}
+
+// mockToolHandler answers calls with the canned response of a mock tool
+func (p *Plugin) mockToolHandler(mt *MockTool) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		args := make(map[string]interface{})
+		if len(req.Params.Arguments) > 0 {
+			if err := json.Unmarshal(req.Params.Arguments, &args); err != nil {
+				return toolErrorResult(fmt.Errorf("invalid arguments: %w", err)), nil
+			}
+		}
+
+		if mt.Response.Delay > 0 {
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(mt.Response.Delay):
+			}
+		}
+
+		contents := make([]MCPContent, len(mt.Response.Content))
+		for i, c := range mt.Response.Content {
+			text, err := renderMockTemplate(c.Text, args)
+			if err != nil {
+				return toolErrorResult(fmt.Errorf("mock template of %q: %w", mt.Name, err)), nil
+			}
+			c.Text = text
+			contents[i] = c
+		}
+
+		content, err := convertContent(contents)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mock response: %w", err)
+		}
+
+		result := &mcp.CallToolResult{
+			Content: content,
+			IsError: mt.Response.IsError,
+		}
+		if mt.Response.StructuredContent != nil {
+			result.StructuredContent = mt.Response.StructuredContent
+		}
+
+		p.log.Debug("mock tool called", zap.String("tool", mt.Name))
+
+		return result, nil
+	}
+}
+
+// renderMockTemplate renders a text template with the call arguments
+func renderMockTemplate(text string, args map[string]interface{}) (string, error) {
+	if text == "" {
+		return "", nil
+	}
+
+	tmpl, err := template.New("response").Option("missingkey=zero").Parse(text)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, args); err != nil {
+		return "", err
+	}
+
+	return buf.String(), nil
+}
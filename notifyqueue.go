@@ -0,0 +1,149 @@
+package mcp
+
+import (
+	"context"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// Overflow policies of session notification queues
+const (
+	OverflowDropOldest = "drop_oldest"
+	OverflowDisconnect = "disconnect"
+)
+
+// queuedNotification is a notification waiting for delivery to one session
+type queuedNotification struct {
+	method string
+	send   func(ctx context.Context) error
+}
+
+// notificationQueue holds the pending notifications of one session, drained
+// in order by a goroutine that exits once the queue is empty
+type notificationQueue struct {
+	pending  []*queuedNotification
+	draining bool
+}
+
+// notificationQueues decouples broadcasts from slow clients, every session
+// gets its own bounded queue
+type notificationQueues struct {
+	mu      sync.Mutex
+	queues  map[*mcp.ServerSession]*notificationQueue
+	dropped map[droppedKey]uint64
+}
+
+// droppedKey partitions dropped notifications
+type droppedKey struct {
+	method string
+	policy string
+}
+
+func newNotificationQueues() *notificationQueues {
+	return &notificationQueues{
+		queues:  make(map[*mcp.ServerSession]*notificationQueue),
+		dropped: make(map[droppedKey]uint64),
+	}
+}
+
+// snapshot returns a copy of the dropped notification counters
+func (n *notificationQueues) snapshot() map[droppedKey]uint64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	counts := make(map[droppedKey]uint64, len(n.dropped))
+	for k, v := range n.dropped {
+		counts[k] = v
+	}
+	return counts
+}
+
+// countDropped counts a notification dropped under a policy
+func (n *notificationQueues) countDropped(method, policy string) {
+	n.mu.Lock()
+	n.dropped[droppedKey{method: method, policy: policy}]++
+	n.mu.Unlock()
+}
+
+// enqueueNotification queues a notification for a session without blocking.
+// A full queue drops its oldest notification or disconnects the session,
+// depending on clients.queue_overflow.
+func (p *Plugin) enqueueNotification(ss *mcp.ServerSession, method string, send func(ctx context.Context) error) {
+	n := p.notifications
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	q, ok := n.queues[ss]
+	if !ok {
+		q = &notificationQueue{}
+		n.queues[ss] = q
+	}
+
+	if len(q.pending) >= p.cfg.Clients.NotificationQueue {
+		switch p.cfg.Clients.QueueOverflow {
+		case OverflowDisconnect:
+			for _, dropped := range q.pending {
+				n.dropped[droppedKey{method: dropped.method, policy: OverflowDisconnect}]++
+			}
+			n.dropped[droppedKey{method: method, policy: OverflowDisconnect}]++
+			q.pending = nil
+
+			p.log.Warn("notification queue full, disconnecting session",
+				zap.String("session_id", ss.ID()),
+				zap.String("method", method),
+			)
+			go func() { _ = ss.Close() }()
+			return
+		default:
+			oldest := q.pending[0]
+			q.pending[0] = nil
+			q.pending = q.pending[1:]
+			n.dropped[droppedKey{method: oldest.method, policy: OverflowDropOldest}]++
+
+			p.log.Debug("notification queue full, oldest notification dropped",
+				zap.String("session_id", ss.ID()),
+				zap.String("method", oldest.method),
+			)
+		}
+	}
+
+	q.pending = append(q.pending, &queuedNotification{method: method, send: send})
+
+	if !q.draining {
+		q.draining = true
+		go p.drainNotifications(ss, q)
+	}
+}
+
+// drainNotifications delivers the queued notifications of a session in order
+func (p *Plugin) drainNotifications(ss *mcp.ServerSession, q *notificationQueue) {
+	n := p.notifications
+
+	for {
+		n.mu.Lock()
+		if len(q.pending) == 0 {
+			q.draining = false
+			delete(n.queues, ss)
+			n.mu.Unlock()
+			return
+		}
+		next := q.pending[0]
+		q.pending[0] = nil
+		q.pending = q.pending[1:]
+		n.mu.Unlock()
+
+		ctx, cancel := context.WithTimeout(p.ctx, p.cfg.Clients.WriteTimeout)
+		err := next.send(ctx)
+		cancel()
+
+		if err != nil {
+			p.log.Warn("failed to deliver notification",
+				zap.String("session_id", ss.ID()),
+				zap.String("method", next.method),
+				zap.Error(err),
+			)
+		}
+	}
+}
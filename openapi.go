@@ -0,0 +1,169 @@
+package mcp
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// openAPIVersion is the OpenAPI version of the generated document
+const openAPIVersion = "3.1.0"
+
+// serveOpenAPI serves the OpenAPI document of the REST bridge. The caller is
+// authenticated like a REST call and sees the tools its session would list,
+// under the names the REST bridge accepts.
+func (p *Plugin) serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if !p.authorizeRequest(w, r, "", transportREST) {
+		return
+	}
+
+	sessionID := uuid.New().String()
+	cs, closeSession, err := p.connectLocalSession(r.Context(), sessionID, transportREST, p.requestCredentials(r))
+	if err != nil {
+		p.log.Warn("OpenAPI request rejected", zap.Error(err))
+		writeJSONError(w, http.StatusUnauthorized, "authentication failed")
+		return
+	}
+	defer closeSession()
+
+	var tools []*mcp.Tool
+	for tool, err := range cs.Tools(r.Context(), nil) {
+		if err != nil {
+			p.log.Warn("failed to list tools for OpenAPI document", zap.Error(err))
+			writeJSONError(w, http.StatusInternalServerError, "failed to list tools")
+			return
+		}
+		tools = append(tools, tool)
+	}
+
+	writeJSON(w, http.StatusOK, p.openAPIDocument(p.sessionTenant(sessionID), tools))
+}
+
+// openAPIDocument generates an OpenAPI document from the tools listed to a
+// session of tenant, it is built per request so it always reflects the
+// registered tools
+func (p *Plugin) openAPIDocument(tenant string, tools []*mcp.Tool) map[string]interface{} {
+	registered := p.toolSnapshot()
+
+	paths := make(map[string]interface{}, len(tools))
+	for _, tool := range tools {
+		// Namespaces tag the operations of tools declared through the registry
+		var namespace string
+		if name, ok := p.resolveTenantTool(tenant, tool.Name); ok && registered[name] != nil {
+			namespace = registered[name].Namespace
+		}
+		paths[p.cfg.REST.Path+"/"+tool.Name] = map[string]interface{}{
+			"post": openAPIOperation(tool, namespace),
+		}
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	title := p.cfg.Server.Title
+	if title == "" {
+		title = p.cfg.Server.Name
+	}
+
+	doc := map[string]interface{}{
+		"openapi": openAPIVersion,
+		"info": map[string]interface{}{
+			"title":       title,
+			"version":     p.cfg.Server.Version,
+			"description": p.instructions,
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": map[string]interface{}{
+				"CallToolResult": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"content": map[string]interface{}{
+							"type":  "array",
+							"items": map[string]interface{}{"type": "object"},
+						},
+						"structuredContent": map[string]interface{}{},
+						"isError":           map[string]interface{}{"type": "boolean"},
+						"_meta":             map[string]interface{}{"type": "object"},
+					},
+				},
+				"Error": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"error": map[string]interface{}{"type": "string"},
+					},
+				},
+			},
+		},
+	}
+
+	if p.cfg.Auth.Enabled {
+		doc["components"].(map[string]interface{})["securitySchemes"] = map[string]interface{}{
+			"bearerAuth": map[string]interface{}{"type": "http", "scheme": "bearer"},
+		}
+		doc["security"] = []interface{}{map[string]interface{}{"bearerAuth": []string{}}}
+	}
+
+	return doc
+}
+
+// openAPIOperation describes the REST operation of a tool
+func openAPIOperation(tool *mcp.Tool, namespace string) map[string]interface{} {
+	result := map[string]interface{}{"$ref": "#/components/schemas/CallToolResult"}
+	if tool.OutputSchema != nil {
+		result = map[string]interface{}{
+			"allOf": []interface{}{
+				result,
+				map[string]interface{}{
+					"properties": map[string]interface{}{
+						"structuredContent": tool.OutputSchema,
+					},
+				},
+			},
+		}
+	}
+
+	operation := map[string]interface{}{
+		"operationId": tool.Name,
+		"description": tool.Description,
+		"requestBody": map[string]interface{}{
+			"required": true,
+			"content": map[string]interface{}{
+				"application/json": map[string]interface{}{"schema": tool.InputSchema},
+			},
+		},
+		"responses": map[string]interface{}{
+			"200": openAPIResponse("Tool result", result),
+			"400": openAPIResponse("Invalid request", openAPIErrorRef()),
+			"401": openAPIResponse("Authentication failed", openAPIErrorRef()),
+			"404": openAPIResponse("Tool not found", openAPIErrorRef()),
+			"422": openAPIResponse("Tool returned an error result", map[string]interface{}{"$ref": "#/components/schemas/CallToolResult"}),
+		},
+	}
+
+	if tool.Title != "" {
+		operation["summary"] = tool.Title
+	}
+	if namespace != "" {
+		operation["tags"] = []string{namespace}
+	}
+
+	return operation
+}
+
+// openAPIResponse describes a JSON response
+func openAPIResponse(description string, schema interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"description": description,
+		"content": map[string]interface{}{
+			"application/json": map[string]interface{}{"schema": schema},
+		},
+	}
+}
+
+// openAPIErrorRef references the error response schema
+func openAPIErrorRef() map[string]interface{} {
+	return map[string]interface{}{"$ref": "#/components/schemas/Error"}
+}
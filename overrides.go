@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"context"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// overrideKey returns the tools.overrides key of a registered tool, tenant
+// tools fall back to their declared name. Empty when there is no override.
+func (p *Plugin) overrideKey(name string) string {
+	if _, ok := p.cfg.Tools.Overrides[name]; ok {
+		return name
+	}
+
+	if i := strings.LastIndex(name, tenantSeparator); i > 0 {
+		if _, ok := p.cfg.Tools.Overrides[name[:i]]; ok {
+			return name[:i]
+		}
+	}
+
+	return ""
+}
+
+// toolOverride returns the override of a registered tool, nil when none
+func (p *Plugin) toolOverride(name string) *ToolOverride {
+	if key := p.overrideKey(name); key != "" {
+		return p.cfg.Tools.Overrides[key]
+	}
+	return nil
+}
+
+// toolRateLimits returns the limits of tools with a rate_limit override
+func toolRateLimits(overrides map[string]*ToolOverride) map[string]*RateLimitConfig {
+	limits := make(map[string]*RateLimitConfig)
+	for name, override := range overrides {
+		if override != nil && override.RateLimit != nil {
+			limits[name] = override.RateLimit
+		}
+	}
+	return limits
+}
+
+// apply returns the annotations with the overridden hints replaced
+func (a *AnnotationsOverride) apply(annotations *mcp.ToolAnnotations) *mcp.ToolAnnotations {
+	merged := &mcp.ToolAnnotations{}
+	if annotations != nil {
+		*merged = *annotations
+	}
+
+	if a.Title != "" {
+		merged.Title = a.Title
+	}
+	if a.ReadOnly != nil {
+		merged.ReadOnlyHint = *a.ReadOnly
+	}
+	if a.Destructive != nil {
+		merged.DestructiveHint = a.Destructive
+	}
+	if a.Idempotent != nil {
+		merged.IdempotentHint = *a.Idempotent
+	}
+	if a.OpenWorld != nil {
+		merged.OpenWorldHint = a.OpenWorld
+	}
+
+	return merged
+}
+
+// overrideMiddleware hides disabled tools and rate limits tools per
+// tools.overrides. It runs after tenantMiddleware and sees registered names.
+func (p *Plugin) overrideMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if len(p.cfg.Tools.Overrides) == 0 || (method != "tools/list" && method != "tools/call") {
+			return next(ctx, method, req)
+		}
+
+		if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok {
+			key := p.overrideKey(params.Name)
+			override := p.cfg.Tools.Overrides[key]
+			if override == nil {
+				return next(ctx, method, req)
+			}
+
+			if override.Disabled {
+				return nil, newJSONRPCError(codeInvalidParams, "tool is disabled", nil)
+			}
+
+			// Tenants share the limit of a tool
+			if !p.toolLimiter.allow(key) {
+				p.callLogger(ctx).Debug("tool call rate limited",
+					zap.String("tool", params.Name),
+				)
+				return nil, jsonRPCError(p.countError(errorClassRateLimited, errors.Str("tool rate limit exceeded, retry later")))
+			}
+
+			return next(ctx, method, req)
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		if res, ok := result.(*mcp.ListToolsResult); ok {
+			tools := make([]*mcp.Tool, 0, len(res.Tools))
+			for _, tool := range res.Tools {
+				if override := p.toolOverride(tool.Name); override == nil || !override.Disabled {
+					tools = append(tools, tool)
+				}
+			}
+			res.Tools = tools
+		}
+
+		return result, nil
+	}
+}
@@ -0,0 +1,80 @@
+package mcp
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// defaultPingTimeout bounds a Ping RPC without a timeout
+const defaultPingTimeout = 5 * time.Second
+
+// pingWorker sends EventPing to one worker and returns the round trip and
+// the protocol version the worker speaks
+func (p *Plugin) pingWorker(ctx context.Context, timeout time.Duration) (time.Duration, int, error) {
+	const op = errors.Op("mcp_ping_worker")
+
+	if timeout <= 0 {
+		timeout = defaultPingTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	body, err := p.sendEvent(ctx, "", EventPing, p.newPingPayload())
+	elapsed := time.Since(start)
+	if err != nil {
+		p.pings.add(false, elapsed)
+		return elapsed, 0, errors.E(op, err)
+	}
+
+	resp, err := parsePing(body)
+	if err == nil {
+		var version int
+		if version, err = negotiateProtocol(resp); err == nil {
+			p.pings.add(true, elapsed)
+			return elapsed, version, nil
+		}
+	}
+
+	p.pings.add(false, elapsed)
+	return elapsed, 0, errors.E(op, err)
+}
+
+// pingStats records the outcomes and round trips of Ping RPCs
+type pingStats struct {
+	mu    sync.Mutex
+	stats map[string]shadowStat // outcome -> pings
+	last  time.Duration         // round trip of the last answered ping
+}
+
+func newPingStats() *pingStats {
+	return &pingStats{stats: make(map[string]shadowStat)}
+}
+
+// add records a finished ping
+func (s *pingStats) add(answered bool, elapsed time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	outcome := callOutcome(!answered)
+	stat := s.stats[outcome]
+	stat.count++
+	stat.seconds += elapsed.Seconds()
+	s.stats[outcome] = stat
+
+	if answered {
+		s.last = elapsed
+	}
+}
+
+// snapshot returns a copy of the stats and the last round trip
+func (s *pingStats) snapshot() (map[string]shadowStat, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.stats), s.last
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package mcp
+
+import (
+	"net"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// pipeSupported reports whether named pipe addresses can be listened on
+const pipeSupported = false
+
+// listenPipe fails, named pipes are only available on Windows
+func listenPipe(string) (net.Listener, error) {
+	return nil, errors.Str("named pipes are only available on Windows")
+}
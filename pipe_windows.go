@@ -0,0 +1,212 @@
+//go:build windows
+
+package mcp
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// pipeSupported reports whether named pipe addresses can be listened on
+const pipeSupported = true
+
+// pipeBufferSize is the in and out buffer size of pipe instances
+const pipeBufferSize = 64 * 1024
+
+// pipeAddr is the address of a named pipe
+type pipeAddr string
+
+func (a pipeAddr) Network() string { return "pipe" }
+func (a pipeAddr) String() string  { return string(a) }
+
+// pipeListener accepts clients of a named pipe, one pipe instance waits for
+// the next client at a time. Remote clients are rejected.
+type pipeListener struct {
+	name string
+
+	mu      sync.Mutex
+	next    windows.Handle // Instance created but not waited on yet
+	waiting bool           // Accept waits on an instance
+	closed  bool
+}
+
+// listenPipe creates a named pipe, it fails when the name is already in use
+func listenPipe(name string) (net.Listener, error) {
+	l := &pipeListener{name: name}
+
+	h, err := l.createInstance(true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create named pipe %s: %w", name, err)
+	}
+	l.next = h
+
+	return l, nil
+}
+
+// createInstance creates an instance of the pipe waiting for a client
+func (l *pipeListener) createInstance(first bool) (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(l.name)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX)
+	if first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+	}
+
+	return windows.CreateNamedPipe(
+		name,
+		flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		nil,
+	)
+}
+
+// Accept implements net.Listener
+func (l *pipeListener) Accept() (net.Conn, error) {
+	for {
+		l.mu.Lock()
+		if l.closed {
+			l.mu.Unlock()
+			return nil, net.ErrClosed
+		}
+		h := l.next
+		l.next = windows.InvalidHandle
+		if h == windows.InvalidHandle || h == 0 {
+			var err error
+			if h, err = l.createInstance(false); err != nil {
+				l.mu.Unlock()
+				return nil, fmt.Errorf("failed to create named pipe instance: %w", err)
+			}
+		}
+		l.waiting = true
+		l.mu.Unlock()
+
+		err := windows.ConnectNamedPipe(h, nil)
+
+		l.mu.Lock()
+		l.waiting = false
+		closed := l.closed
+		l.mu.Unlock()
+
+		switch {
+		case closed:
+			_ = windows.CloseHandle(h)
+			return nil, net.ErrClosed
+		case err == nil || err == windows.ERROR_PIPE_CONNECTED:
+			return &pipeConn{h: h, addr: pipeAddr(l.name)}, nil
+		case err == windows.ERROR_NO_DATA:
+			// The client went away before it was accepted
+			_ = windows.CloseHandle(h)
+		default:
+			_ = windows.CloseHandle(h)
+			return nil, fmt.Errorf("failed to accept named pipe client: %w", err)
+		}
+	}
+}
+
+// Close implements net.Listener, a pending Accept is woken by connecting
+// to the waiting instance
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	next, waiting := l.next, l.waiting
+	l.next = windows.InvalidHandle
+	l.mu.Unlock()
+
+	if next != windows.InvalidHandle && next != 0 {
+		_ = windows.CloseHandle(next)
+	}
+
+	if waiting {
+		name, err := windows.UTF16PtrFromString(l.name)
+		if err != nil {
+			return err
+		}
+		h, err := windows.CreateFile(name, windows.GENERIC_READ|windows.GENERIC_WRITE, 0, nil, windows.OPEN_EXISTING, 0, 0)
+		if err == nil {
+			_ = windows.CloseHandle(h)
+		}
+	}
+
+	return nil
+}
+
+// Addr implements net.Listener
+func (l *pipeListener) Addr() net.Addr {
+	return pipeAddr(l.name)
+}
+
+// pipeConn is a client connected to a pipe instance. Pipes are used in
+// blocking mode, so deadlines are not supported and Close disconnects the
+// client to unblock pending reads.
+type pipeConn struct {
+	h      windows.Handle
+	addr   pipeAddr
+	closed atomic.Bool
+	once   sync.Once
+}
+
+// Read implements net.Conn
+func (c *pipeConn) Read(b []byte) (int, error) {
+	var n uint32
+	err := windows.ReadFile(c.h, b, &n, nil)
+	switch {
+	case c.closed.Load():
+		return 0, net.ErrClosed
+	case err == windows.ERROR_BROKEN_PIPE || err == windows.ERROR_PIPE_NOT_CONNECTED:
+		return int(n), io.EOF
+	case err != nil:
+		return int(n), err
+	}
+	return int(n), nil
+}
+
+// Write implements net.Conn
+func (c *pipeConn) Write(b []byte) (int, error) {
+	written := 0
+	for written < len(b) {
+		var n uint32
+		if err := windows.WriteFile(c.h, b[written:], &n, nil); err != nil {
+			if c.closed.Load() {
+				return written, net.ErrClosed
+			}
+			return written, err
+		}
+		written += int(n)
+	}
+	return written, nil
+}
+
+// Close implements net.Conn
+func (c *pipeConn) Close() error {
+	var err error
+	c.once.Do(func() {
+		c.closed.Store(true)
+		_ = windows.DisconnectNamedPipe(c.h)
+		err = windows.CloseHandle(c.h)
+	})
+	return err
+}
+
+func (c *pipeConn) LocalAddr() net.Addr  { return c.addr }
+func (c *pipeConn) RemoteAddr() net.Addr { return c.addr }
+
+func (c *pipeConn) SetDeadline(time.Time) error      { return nil }
+func (c *pipeConn) SetReadDeadline(time.Time) error  { return nil }
+func (c *pipeConn) SetWriteDeadline(time.Time) error { return nil }
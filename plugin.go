@@ -4,10 +4,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"net"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/api/v4/plugins/v1/kv"
 	"github.com/roadrunner-server/endure/v2/dep"
 	"github.com/roadrunner-server/errors"
 	"github.com/roadrunner-server/pool/payload"
@@ -23,25 +29,189 @@ type Plugin struct {
 	mu sync.RWMutex
 
 	// Configuration
-	cfg *Config
-	log *zap.Logger
+	cfg       *Config
+	cfgPlugin Configurer
+	log       *zap.Logger
 
-	// MCP server instance
-	mcpServer *mcp.Server
+	// MCP servers of the connections and the features they share
+	serverImpl  *mcp.Implementation
+	connServers *connServerSet
+
+	// Instructions sent to clients on initialize (config or PHP via RPC)
+	instructions string
 
 	// RoadRunnercomponents
 	server Server
 	pool   Pool
 
-	// Tool registry (name -> definition)
-	tools map[string]*mcp.Tool
+	// Dedicated worker pools of tenants (tenant ID -> pool)
+	tenantPools map[string]Pool
+
+	// Pool running canary.percentage of the calls of canary.tools
+	canaryPool  Pool
+	canaryCalls *canaryCalls
+
+	// Pool running discarded copies of shadow.percentage of the calls of shadow.tools
+	shadowPool  Pool
+	shadowSlots chan struct{}
+	shadowCalls *shadowCalls
+
+	// Outcomes and round trips of Ping RPCs
+	pings *pingStats
+
+	// Serializes pool resets of rr reset and the ResetPool RPC
+	resetMu sync.Mutex
+
+	// Tool calls by the session attributes in clients.metric_attributes
+	attributedCalls *attributedCalls
+
+	// Tool call limits per tenant
+	rateLimiter *rateLimiter
+	toolLimiter *rateLimiter
+
+	// Tool registry (qualified name -> entry), written through setTool and deleteTool
+	tools map[string]*toolEntry
+
+	// Copy of the tool registry for lock-free reads, nil after a change
+	toolView atomic.Pointer[map[string]*toolEntry]
+
+	// Aliases declared by PHP (alias, tenant qualified -> qualified tool name)
+	aliases map[string]string
+
+	// Mounted upstream MCP servers (name -> upstream)
+	upstreams map[string]*upstream
+
+	// Recent completions of upstream prompt arguments
+	completions *completionCache
+
+	// Resources declared by PHP (URI -> definition) and their cached contents
+	resources     map[string]*ResourceDefinition
+	resourceCache *resourceCache
+
+	// Client connections opened by PHP workers (name -> connection)
+	clients map[string]*upstream
+
+	// Plugins exposing Go-native tools
+	providers []ToolProvider
+
+	// Plugins enforcing guardrails on tool calls, in name order
+	policies []ToolPolicy
+
+	// Redaction of arguments in logs and the call history, nil when disabled
+	redactor      *redactor
+	redactionHits *redactionHits
+
+	// Result filters of collected plugins and the configured chain
+	resultFilterPlugins []ResultFilter
+	resultFilters       []ResultFilter
+
+	// Prompt injection scanners of collected plugins and the configured ones
+	injectionScannerPlugins []InjectionScanner
+	injectionScanners       []InjectionScanner
+	injectionHits           *injectionHits
+
+	// Feature flag providers of collected plugins and the configured one
+	flagProviderPlugins []FlagProvider
+	flagProvider        FlagProvider
+
+	// KV drivers (driver name -> constructor) and built-in KV tools
+	kvDrivers map[string]kv.Constructor
+	kvTools   *kvTools
+
+	// Access log of the client listeners, nil when disabled
+	accessLog *accessLog
+
+	// Collected audit sinks and the shippers of audit.sinks, nil without sinks
+	auditSinkPlugins []AuditSink
+	audit            *auditLog
+
+	// Oversized tool results stored as resources, nil without tools.spill.enabled
+	spill *spillStore
+
+	// Jobs plugin backing the built-in jobs tools
+	jobs Jobs
+
+	// Broadcast plugin receiving lifecycle and call events
+	broadcaster Broadcaster
+	bus         chan *busMessage
+
+	// Staged DeclareTools chunks (batch ID -> chunks)
+	batches map[string]*declarationBatch
+
+	// Sends notifications to a session, bypassing the notification filter
+	sendNotification mcp.MethodHandler
+
+	// Pending debounced list_changed notification
+	notifyMu    sync.Mutex
+	notifyTimer *time.Timer
 
 	// Active sessions (sessionID -> info)
-	sessions map[string]*SessionInfo
+	sessions *sessionRegistry
 
 	// HTTP server for SSE transport
 	httpServer *http.Server
 
+	// Listener of the TCP transport
+	tcpListener net.Listener
+
+	// Connections of the TCP transport, bounded by clients.max_connections
+	tcpSlots chan struct{}
+
+	// Address and certificates of the SSE or TCP transport listener
+	listener *transportListener
+
+	// Serializes listener swaps
+	swapMu sync.Mutex
+
+	// HTTP servers of logical servers with their own listener
+	serverListeners []*http.Server
+
+	// HTTP server for admin endpoints
+	adminServer *http.Server
+
+	// HTTP servers answering ACME HTTP-01 challenges
+	acmeServers []*http.Server
+
+	// Recent tool calls
+	calls *callLog
+
+	// Revoked client and session tokens
+	revoked *revokedTokens
+
+	// Sessions closed after their credentials expired
+	expiries *sessionExpiries
+
+	// Calls of deprecated tools by tool and outcome
+	deprecatedCalls *deprecatedCalls
+
+	// Calls made by a tool alias
+	aliasCalls *aliasCalls
+
+	// SSE sessions evicted for not reading their stream
+	slowConsumers *slowConsumers
+
+	// Per-session queues of outgoing notifications
+	notifications *notificationQueues
+	resumes       *resumeBuffers
+
+	// Values PHP keeps per session, dropped on disconnect
+	storage *sessionStorage
+
+	// Failures by class
+	errorCounts *errorCounts
+
+	// Replaces the worker pool when set (see UseEventHandler)
+	eventHandler EventHandler
+
+	// Set once enough workers answered the startup ping
+	ready atomic.Bool
+
+	// Payload protocol version negotiated by the startup ping, zero until then
+	workerProtocol atomic.Int32
+
+	// Wire format of worker payloads negotiated by the startup ping, JSON until then
+	workerCodec atomic.Value
+
 	// Context for lifecycle management
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -50,9 +220,15 @@ type Plugin struct {
 	statsExporter *StatsExporter
 }
 
+// EventHandler answers worker events in place of the PHP worker pool. It gets
+// the event headers and JSON body and returns the JSON response body. Headers
+// and body are reused after it returns and must not be retained.
+type EventHandler func(ctx context.Context, headers map[string][]string, body []byte) ([]byte, error)
+
 // Pool interface for worker pool operations
 type Pool interface {
 	Workers() []*worker.Process
+	QueueSize() uint64
 	Exec(ctx context.Context, p *payload.Payload, stopCh chan struct{}) (chan *static_pool.PExec, error)
 	RemoveWorker(ctx context.Context) error
 	AddWorker() error
@@ -91,18 +267,61 @@ func (p *Plugin) Init(cfg Configurer, log Logger, srv Server) error {
 		return errors.E(op, err)
 	}
 
+	// Typos would otherwise fall back to defaults silently
+	if p.cfg.Strict {
+		var raw map[string]interface{}
+		if err := cfg.UnmarshalKey(PluginName, &raw); err != nil {
+			return errors.E(op, err)
+		}
+		if err := p.cfg.validateStrict(raw); err != nil {
+			return errors.E(op, err)
+		}
+	}
+
 	// Initialize defaults
 	if err := p.cfg.InitDefaults(); err != nil {
 		return errors.E(op, err)
 	}
 
 	// Store dependencies
+	p.cfgPlugin = cfg
 	p.log = log.NamedLogger(PluginName)
 	p.server = srv
 
 	// Initialize internal structures
-	p.tools = make(map[string]*mcp.Tool)
-	p.sessions = make(map[string]*SessionInfo)
+	p.tools = make(map[string]*toolEntry)
+	p.aliases = make(map[string]string)
+	p.batches = make(map[string]*declarationBatch)
+	p.upstreams = make(map[string]*upstream)
+	p.completions = newCompletionCache(p.cfg.Prompts.CompletionTTL)
+	p.resources = make(map[string]*ResourceDefinition)
+	p.resourceCache = newResourceCache(p.cfg.Resources.CacheTTL, p.cfg.Resources.CacheSize)
+	p.clients = make(map[string]*upstream)
+	p.sessions = newSessionRegistry()
+	p.tenantPools = make(map[string]Pool)
+	p.canaryCalls = newCanaryCalls()
+	p.shadowCalls = newShadowCalls()
+	p.pings = newPingStats()
+	p.attributedCalls = newAttributedCalls(p.cfg, p.log)
+	p.rateLimiter = newRateLimiter(p.cfg.RateLimit, tenantRateLimits(p.cfg.Tenants))
+	p.toolLimiter = newRateLimiter(RateLimitConfig{}, toolRateLimits(p.cfg.Tools.Overrides))
+	p.calls = newCallLog(max(p.cfg.Admin.RecentCalls, 0))
+	p.revoked = newRevokedTokens(p.cfg.Auth.RevocationTTL)
+	p.expiries = newSessionExpiries()
+	p.deprecatedCalls = newDeprecatedCalls()
+	p.aliasCalls = newAliasCalls()
+	p.slowConsumers = newSlowConsumers()
+	p.notifications = newNotificationQueues()
+	p.resumes = newResumeBuffers()
+	p.storage = newSessionStorage()
+	p.tcpSlots = make(chan struct{}, p.cfg.Clients.MaxConnections)
+	p.errorCounts = newErrorCounts()
+	p.bus = make(chan *busMessage, busQueueSize)
+	p.redactionHits = newRedactionHits()
+	p.injectionHits = newInjectionHits()
+	if p.cfg.Redaction.Enabled {
+		p.redactor = newRedactor(p.cfg, p.redactionHits)
+	}
 
 	// Create context for lifecycle management
 	p.ctx, p.cancel = context.WithCancel(context.Background())
@@ -115,6 +334,8 @@ func (p *Plugin) Init(cfg Configurer, log Logger, srv Server) error {
 		return errors.E(op, err)
 	}
 
+	p.logSupervisors()
+
 	p.log.Info("MCP plugin initialized",
 		zap.String("transport", p.cfg.Transport),
 		zap.String("address", p.cfg.Address),
@@ -128,21 +349,213 @@ func (p *Plugin) Init(cfg Configurer, log Logger, srv Server) error {
 func (p *Plugin) Serve() chan error {
 	errCh := make(chan error, 1)
 
+	// Open the access log before the listeners
+	accessLog, err := openAccessLog(p.cfg)
+	if err != nil {
+		errCh <- errors.E(errors.Op("mcp_serve"), err)
+		return errCh
+	}
+	p.accessLog = accessLog
+
+	// Ship audit records to the configured sinks
+	audit, err := p.openAuditLog()
+	if err != nil {
+		errCh <- errors.E(errors.Op("mcp_serve"), err)
+		return errCh
+	}
 	p.mu.Lock()
-	defer p.mu.Unlock()
+	p.audit = audit
+	p.mu.Unlock()
 
-	// Create worker pool
-	var err error
-	p.pool, err = p.server.NewPool(
-		p.ctx,
-		p.cfg.Pool,
-		map[string]string{"RR_MODE": "mcp"},
-		p.log,
-	)
+	// Store oversized tool results as resources
+	spill, err := p.openSpillStore()
 	if err != nil {
 		errCh <- errors.E(errors.Op("mcp_serve"), err)
 		return errCh
 	}
+	if spill != nil {
+		p.mu.Lock()
+		p.spill = spill
+		p.mu.Unlock()
+		p.addSpillTemplate()
+		go spill.run(p.ctx)
+	}
+
+	// Open storages for the built-in KV tools
+	if len(p.cfg.Builtin.KV.Storages) > 0 {
+		kvt, err := p.openKVTools()
+		if err != nil {
+			errCh <- errors.E(errors.Op("mcp_serve"), err)
+			return errCh
+		}
+
+		p.mu.Lock()
+		p.kvTools = kvt
+		p.providers = append(p.providers, kvt)
+		p.mu.Unlock()
+	}
+
+	// Publish events to the broadcast plugin
+	if p.cfg.Events.Enabled {
+		if err := p.startBus(); err != nil {
+			errCh <- errors.E(errors.Op("mcp_serve"), err)
+			return errCh
+		}
+	}
+
+	// Expose the jobs plugin
+	if p.cfg.Builtin.Jobs.Enabled {
+		jt, err := p.newJobsTools()
+		if err != nil {
+			errCh <- errors.E(errors.Op("mcp_serve"), err)
+			return errCh
+		}
+
+		p.mu.Lock()
+		p.providers = append(p.providers, jt)
+		p.mu.Unlock()
+	}
+
+	// Register tools of collected plugins
+	p.registerProviders()
+
+	// Mock mode answers tools from fixtures
+	if p.cfg.Mode == ModeMock {
+		if err := p.registerMockTools(); err != nil {
+			errCh <- errors.E(errors.Op("mcp_serve"), err)
+			return errCh
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Chain result filters
+	if err := p.initResultFilters(p.resultFilterPlugins); err != nil {
+		errCh <- errors.E(errors.Op("mcp_serve"), err)
+		return errCh
+	}
+
+	// Scan results for prompt injection
+	if err := p.initInjectionScanners(p.injectionScannerPlugins); err != nil {
+		errCh <- errors.E(errors.Op("mcp_serve"), err)
+		return errCh
+	}
+
+	// Roll tools out by feature flags
+	if err := p.initFlagProvider(p.flagProviderPlugins); err != nil {
+		errCh <- errors.E(errors.Op("mcp_serve"), err)
+		return errCh
+	}
+
+	// Create worker pool, mock mode and event handlers run without PHP
+	if p.cfg.Mode != ModeMock && p.eventHandler == nil {
+		var err error
+		p.pool, err = p.server.NewPool(
+			p.ctx,
+			p.cfg.Pool,
+			p.workerEnv(nil),
+			p.log,
+		)
+		if err != nil {
+			errCh <- errors.E(errors.Op("mcp_serve"), err)
+			return errCh
+		}
+
+		if err := p.createTenantPools(); err != nil {
+			errCh <- errors.E(errors.Op("mcp_serve"), err)
+			return errCh
+		}
+
+		if err := p.createCanaryPool(); err != nil {
+			errCh <- errors.E(errors.Op("mcp_serve"), err)
+			return errCh
+		}
+
+		if err := p.createShadowPool(); err != nil {
+			errCh <- errors.E(errors.Op("mcp_serve"), err)
+			return errCh
+		}
+	}
+
+	// Mount upstream servers without blocking startup
+	if len(p.cfg.Upstreams) > 0 {
+		go p.connectUpstreams()
+	}
+
+	// Admin endpoints on their own listener
+	if p.cfg.Admin.Address != "" {
+		go func() {
+			if err := p.serveAdmin(); err != nil {
+				p.log.Error("admin server error", zap.Error(err))
+				errCh <- err
+			}
+		}()
+	}
+
+	// Workers are pinged before the plugin reports ready, listeners optionally wait for them
+	if p.cfg.Readiness.DelayListeners {
+		go func() {
+			if err := p.awaitReadiness(); err != nil {
+				errCh <- err
+				return
+			}
+			p.startListeners(errCh)
+		}()
+	} else {
+		go func() {
+			if err := p.awaitReadiness(); err != nil {
+				errCh <- err
+			}
+		}()
+		p.startListeners(errCh)
+	}
+
+	return errCh
+}
+
+// startListeners starts the transport, logical servers and scheduler
+func (p *Plugin) startListeners(errCh chan error) {
+	// Logical servers with their own listener
+	for name, server := range p.cfg.Servers {
+		if server.Address == "" {
+			continue
+		}
+		go func() {
+			if err := p.serveServer(name, server); err != nil {
+				p.log.Error("logical server error", zap.String("server", name), zap.Error(err))
+				errCh <- err
+			}
+		}()
+	}
+
+	// Certificates of ACME listeners validated over HTTP
+	for _, acmeCfg := range p.acmeConfigs() {
+		go func() {
+			if err := p.serveACMEChallenges(acmeCfg); err != nil {
+				p.log.Error("ACME challenge listener error", zap.Error(err))
+				errCh <- err
+			}
+		}()
+	}
+
+	// Keep PHP tools in sync with replaced workers
+	if p.cfg.Tools.ResyncOnRestart && p.cfg.Mode != ModeMock {
+		go p.watchWorkers()
+	}
+
+	// A debug pool starts no worker until the first event
+	if p.cfg.debugPool() && p.cfg.Mode != ModeMock {
+		p.log.Info("worker pool in debug mode, every event starts a fresh worker")
+		if !p.cfg.Tools.ResyncOnRestart && p.cfg.Readiness.MinWorkers == 0 {
+			go p.bootDebugWorker()
+		}
+	}
+
+	// Invoke scheduled tools
+	if len(p.cfg.Scheduler) > 0 {
+		p.startScheduler()
+	}
 
 	// Start transport
 	go func() {
@@ -152,6 +565,8 @@ func (p *Plugin) Serve() chan error {
 			err = p.serveSSE()
 		case "stdio":
 			err = p.serveStdio()
+		case transportTCP:
+			err = p.serveTCP()
 		default:
 			err = fmt.Errorf("unsupported transport: %s", p.cfg.Transport)
 		}
@@ -163,12 +578,30 @@ func (p *Plugin) Serve() chan error {
 	}()
 
 	p.log.Info("MCP plugin serving", zap.String("transport", p.cfg.Transport))
-
-	return errCh
 }
 
 // Stop gracefully stops the MCP plugin
 func (p *Plugin) Stop(ctx context.Context) error {
+	// Upstream notification handlers take the lock, close them first
+	p.closeUpstreams()
+
+	// Admin handlers take the lock as well
+	p.mu.RLock()
+	adminServer := p.adminServer
+	serverListeners := p.serverListeners
+	p.mu.RUnlock()
+	if adminServer != nil {
+		if err := adminServer.Shutdown(ctx); err != nil {
+			p.log.Error("failed to shutdown admin server", zap.Error(err))
+		}
+	}
+	for _, srv := range serverListeners {
+		if err := srv.Shutdown(ctx); err != nil {
+			p.log.Error("failed to shutdown logical server", zap.Error(err))
+		}
+	}
+	p.stopACMEChallenges(ctx)
+
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
@@ -179,6 +612,14 @@ func (p *Plugin) Stop(ctx context.Context) error {
 		p.cancel()
 	}
 
+	// Drop pending notifications
+	p.notifyMu.Lock()
+	if p.notifyTimer != nil {
+		p.notifyTimer.Stop()
+		p.notifyTimer = nil
+	}
+	p.notifyMu.Unlock()
+
 	// Close HTTP server for SSE
 	if p.httpServer != nil {
 		if err := p.httpServer.Shutdown(ctx); err != nil {
@@ -186,21 +627,79 @@ func (p *Plugin) Stop(ctx context.Context) error {
 		}
 	}
 
+	// Stop accepting TCP clients, open connections end with the context
+	if p.tcpListener != nil {
+		if err := p.tcpListener.Close(); err != nil {
+			p.log.Error("failed to close TCP listener", zap.Error(err))
+		}
+	}
+
 	// Close all sessions
-	for sessionID, info := range p.sessions {
+	for sessionID, info := range p.sessions.snapshot() {
 		p.log.Debug("closing session", zap.String("session_id", sessionID))
-		delete(p.sessions, sessionID)
-		_ = info
+		p.sessions.delete(sessionID)
+		if info.expiryTimer != nil {
+			info.expiryTimer.Stop()
+		}
 	}
 
+	// Close built-in KV storages
+	if p.kvTools != nil {
+		p.kvTools.stop()
+	}
+
+	// Listeners are shut down, close the access log
+	if err := p.accessLog.close(); err != nil {
+		p.log.Error("failed to close access log", zap.Error(err))
+	}
+
+	// Ship the audit records of the last calls
+	p.audit.stop(ctx)
+
 	// Destroy worker pool
 	if p.pool != nil {
 		p.pool.Destroy(ctx)
 	}
+	for _, tenantPool := range p.tenantPools {
+		tenantPool.Destroy(ctx)
+	}
+	if p.canaryPool != nil {
+		p.canaryPool.Destroy(ctx)
+	}
+	if p.shadowPool != nil {
+		p.shadowPool.Destroy(ctx)
+	}
 
 	return nil
 }
 
+// UseEventHandler makes the plugin send worker events to h instead of
+// starting a worker pool, it must be called before Serve
+func (p *Plugin) UseEventHandler(h EventHandler) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.eventHandler = h
+}
+
+// ConnectInMemory opens an MCP client session over an in-memory transport.
+// The session is authenticated with the given credentials like any client,
+// the returned function closes it.
+func (p *Plugin) ConnectInMemory(ctx context.Context, credentials map[string]string) (*mcp.ClientSession, func(), error) {
+	const op = errors.Op("mcp_connect_in_memory")
+
+	if credentials == nil {
+		credentials = map[string]string{}
+	}
+
+	cs, closeSession, err := p.connectLocal(ctx, transportMemory, credentials)
+	if err != nil {
+		return nil, nil, errors.E(op, err)
+	}
+
+	return cs, closeSession, nil
+}
+
 // Name returns the plugin name
 func (p *Plugin) Name() string {
 	return PluginName
@@ -222,6 +721,62 @@ func (p *Plugin) Collects() []*dep.In {
 		dep.Fits(func(pp any) {
 			p.server = pp.(Server)
 		}, (*Server)(nil)),
+		dep.Fits(func(pp any) {
+			p.mu.Lock()
+			p.providers = append(p.providers, pp.(ToolProvider))
+			p.mu.Unlock()
+		}, (*ToolProvider)(nil)),
+		dep.Fits(func(pp any) {
+			p.mu.Lock()
+			p.policies = append(p.policies, pp.(ToolPolicy))
+			slices.SortFunc(p.policies, func(a, b ToolPolicy) int {
+				return strings.Compare(a.Name(), b.Name())
+			})
+			p.mu.Unlock()
+		}, (*ToolPolicy)(nil)),
+		dep.Fits(func(pp any) {
+			p.mu.Lock()
+			p.resultFilterPlugins = append(p.resultFilterPlugins, pp.(ResultFilter))
+			p.mu.Unlock()
+		}, (*ResultFilter)(nil)),
+		dep.Fits(func(pp any) {
+			p.mu.Lock()
+			p.injectionScannerPlugins = append(p.injectionScannerPlugins, pp.(InjectionScanner))
+			p.mu.Unlock()
+		}, (*InjectionScanner)(nil)),
+		dep.Fits(func(pp any) {
+			p.mu.Lock()
+			p.flagProviderPlugins = append(p.flagProviderPlugins, pp.(FlagProvider))
+			p.mu.Unlock()
+		}, (*FlagProvider)(nil)),
+		dep.Fits(func(pp any) {
+			p.mu.Lock()
+			p.auditSinkPlugins = append(p.auditSinkPlugins, pp.(AuditSink))
+			p.mu.Unlock()
+		}, (*AuditSink)(nil)),
+		dep.Fits(func(pp any) {
+			named, ok := pp.(interface{ Name() string })
+			if !ok {
+				return
+			}
+
+			p.mu.Lock()
+			if p.kvDrivers == nil {
+				p.kvDrivers = make(map[string]kv.Constructor)
+			}
+			p.kvDrivers[named.Name()] = pp.(kv.Constructor)
+			p.mu.Unlock()
+		}, (*kv.Constructor)(nil)),
+		dep.Fits(func(pp any) {
+			p.mu.Lock()
+			p.jobs = pp.(Jobs)
+			p.mu.Unlock()
+		}, (*Jobs)(nil)),
+		dep.Fits(func(pp any) {
+			p.mu.Lock()
+			p.broadcaster = pp.(Broadcaster)
+			p.mu.Unlock()
+		}, (*Broadcaster)(nil)),
 	}
 }
 
@@ -253,23 +808,29 @@ func (p *Plugin) Workers() []*process.State {
 	return states
 }
 
-// createMCPServer creates the MCP server instance
+// createMCPServer prepares the MCP servers, every connection gets its own
+// server created by connServer
 func (p *Plugin) createMCPServer() error {
 	// Create server implementation info
-	impl := &mcp.Implementation{
-		Name:    "roadrunner-mcp",
-		Version: "1.0.0",
+	p.serverImpl = &mcp.Implementation{
+		Name:    p.cfg.Server.Name,
+		Title:   p.cfg.Server.Title,
+		Version: p.cfg.Server.Version,
 	}
 
-	// Configure server options - note: v1.0.0 API doesn't have Capabilities field
-	opts := &mcp.ServerOptions{}
+	p.instructions = p.cfg.Server.Instructions
+	p.connServers = newConnServerSet()
 
-	// Create the server
-	p.mcpServer = mcp.NewServer(impl, opts)
+	// The SDK's sending handler does not depend on the server, it is captured
+	// once to send the plugin's own notifications to any session
+	mcp.NewServer(p.serverImpl, nil).AddSendingMiddleware(func(next mcp.MethodHandler) mcp.MethodHandler {
+		p.sendNotification = next
+		return next
+	})
 
 	p.log.Info("MCP server created",
-		zap.String("name", impl.Name),
-		zap.String("version", impl.Version),
+		zap.String("name", p.serverImpl.Name),
+		zap.String("version", p.serverImpl.Version),
 	)
 
 	return nil
@@ -0,0 +1,179 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"slices"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// ToolPolicy is implemented by RoadRunner plugins enforcing guardrails on
+// tool calls. Policies are collected by the plugin and run before every call,
+// in name order and before the PHP BeforeToolCall event.
+type ToolPolicy interface {
+	// Name returns the plugin name, reported when the policy denies a call
+	Name() string
+	// BeforeToolCall decides on a call, a nil decision allows it unchanged
+	BeforeToolCall(ctx context.Context, call *BeforeToolCallPayload) (*BeforeToolCallResponse, error)
+}
+
+// policyMiddleware runs the tool policies before tools/call. Denied calls
+// return an error result with the reason so the model can react to it.
+func (p *Plugin) policyMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok {
+			return next(ctx, method, req)
+		}
+
+		p.mu.RLock()
+		policies := p.policies
+		p.mu.RUnlock()
+
+		checkPHP := p.cfg.Policy.Enabled && p.policyMatches(params.Name)
+		if len(policies) == 0 && !checkPHP {
+			return next(ctx, method, req)
+		}
+
+		sessionID := sessionIDFromContext(ctx)
+		call := p.beforeToolCallPayload(sessionID, params)
+
+		for _, policy := range policies {
+			decision, err := policy.BeforeToolCall(ctx, call)
+			if err != nil {
+				p.callLogger(ctx).Warn("tool policy failed",
+					zap.String("policy", policy.Name()),
+					zap.String("tool", params.Name),
+					zap.Error(err),
+				)
+				return nil, errors.Str("policy check failed")
+			}
+
+			if denied := applyPolicyDecision(call, decision); denied != nil {
+				p.logDeniedCall(ctx, params.Name, policy.Name(), decision.Message)
+				return denied, nil
+			}
+		}
+
+		if checkPHP {
+			decision, err := p.phpPolicyDecision(ctx, call)
+			if err != nil {
+				p.callLogger(ctx).Warn("tool policy failed",
+					zap.String("policy", "php"),
+					zap.String("tool", params.Name),
+					zap.Error(err),
+				)
+				return nil, errors.Str("policy check failed")
+			}
+
+			if denied := applyPolicyDecision(call, decision); denied != nil {
+				p.logDeniedCall(ctx, params.Name, "php", decision.Message)
+				return denied, nil
+			}
+		}
+
+		params.Arguments = call.Arguments
+		if len(call.Meta) > 0 {
+			params.Meta = call.Meta
+		}
+
+		return next(ctx, method, req)
+	}
+}
+
+// policyMatches reports whether PHP checks calls of a tool
+func (p *Plugin) policyMatches(name string) bool {
+	if len(p.cfg.Policy.Tools) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(p.cfg.Policy.Tools, func(pattern string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
+}
+
+// beforeToolCallPayload describes a call for the policies
+func (p *Plugin) beforeToolCallPayload(sessionID string, params *mcp.CallToolParamsRaw) *BeforeToolCallPayload {
+	call := &BeforeToolCallPayload{
+		SessionID: sessionID,
+		Tool:      params.Name,
+		Arguments: params.Arguments,
+		Meta:      params.Meta,
+	}
+
+	p.mu.RLock()
+	if info, ok := p.sessions.get(sessionID); ok {
+		call.Transport = info.Transport
+		call.ClientInfo = info.ClientInfo
+		call.Scopes = info.Scopes
+	}
+	p.mu.RUnlock()
+
+	return call
+}
+
+// phpPolicyDecision sends the BeforeToolCall event to PHP
+func (p *Plugin) phpPolicyDecision(ctx context.Context, call *BeforeToolCallPayload) (*BeforeToolCallResponse, error) {
+	const op = errors.Op("mcp_php_policy_decision")
+
+	phpResp, err := p.sendEvent(ctx, call.SessionID, EventBeforeToolCall, call)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	var decision BeforeToolCallResponse
+	if err := json.Unmarshal(phpResp, &decision); err != nil {
+		return nil, errors.E(op, fmt.Errorf("invalid worker response: %w", err))
+	}
+
+	return &decision, nil
+}
+
+// applyPolicyDecision applies rewrites and annotations of a decision to the
+// call, or returns the result reporting the denial
+func applyPolicyDecision(call *BeforeToolCallPayload, decision *BeforeToolCallResponse) *mcp.CallToolResult {
+	if decision == nil {
+		return nil
+	}
+
+	if !decision.Allowed {
+		message := "call denied by policy"
+		if decision.Message != "" {
+			message += ": " + decision.Message
+		}
+		return toolErrorResult(errors.Str(message))
+	}
+
+	if len(decision.Arguments) > 0 {
+		call.Arguments = decision.Arguments
+	}
+
+	if len(decision.Meta) > 0 {
+		meta := make(map[string]interface{}, len(call.Meta)+len(decision.Meta))
+		for k, v := range call.Meta {
+			meta[k] = v
+		}
+		for k, v := range decision.Meta {
+			meta[k] = v
+		}
+		call.Meta = meta
+	}
+
+	return nil
+}
+
+// logDeniedCall logs a call denied by a policy
+func (p *Plugin) logDeniedCall(ctx context.Context, tool, policy, message string) {
+	p.callLogger(ctx).Info("tool call denied by policy",
+		zap.String("session_id", sessionIDFromContext(ctx)),
+		zap.String("tool", tool),
+		zap.String("policy", policy),
+		zap.String("reason", message),
+	)
+}
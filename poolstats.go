@@ -0,0 +1,79 @@
+package mcp
+
+import (
+	"github.com/roadrunner-server/pool/state/process"
+)
+
+// PoolStats is the state of a worker pool
+type PoolStats struct {
+	Pool        string           `json:"pool"` // Config path, e.g. pool or tenants.acme.pool
+	Workers     int              `json:"workers"`
+	States      map[string]int   `json:"states"`      // Workers by state, e.g. ready or working
+	QueueDepth  uint64           `json:"queueDepth"`  // Events waiting for a free worker
+	MemoryUsage uint64           `json:"memoryUsage"` // Bytes, all workers
+	Processes   []*process.State `json:"processes"`
+}
+
+// PoolStatsResponse reports every worker pool, none in mock mode
+type PoolStatsResponse struct {
+	Pools []PoolStats `json:"pools"`
+}
+
+// workerPools returns the worker pools by config path, none in mock mode
+func (p *Plugin) workerPools() map[string]Pool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pools := make(map[string]Pool)
+	if p.pool != nil {
+		pools["pool"] = p.pool
+	}
+	for id, tenantPool := range p.tenantPools {
+		pools["tenants."+id+".pool"] = tenantPool
+	}
+	if p.canaryPool != nil {
+		pools["canary.pool"] = p.canaryPool
+	}
+	if p.shadowPool != nil {
+		pools["shadow.pool"] = p.shadowPool
+	}
+
+	return pools
+}
+
+// poolStats returns the state of every worker pool
+func (p *Plugin) poolStats() []PoolStats {
+	pools := p.workerPools()
+
+	stats := make([]PoolStats, 0, len(pools))
+	for _, path := range sortedKeys(pools) {
+		stats = append(stats, collectPoolStats(path, pools[path]))
+	}
+
+	return stats
+}
+
+// collectPoolStats reads the state of every worker of a pool, workers that
+// exited meanwhile are left out
+func collectPoolStats(path string, pool Pool) PoolStats {
+	stats := PoolStats{
+		Pool:       path,
+		States:     make(map[string]int),
+		QueueDepth: pool.QueueSize(),
+		Processes:  []*process.State{},
+	}
+
+	for _, w := range pool.Workers() {
+		state, err := process.WorkerProcessState(w)
+		if err != nil {
+			continue
+		}
+
+		stats.Workers++
+		stats.States[state.StatusStr]++
+		stats.MemoryUsage += state.MemoryUsage
+		stats.Processes = append(stats.Processes, state)
+	}
+
+	return stats
+}
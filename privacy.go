@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"hash"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// Hashes replacing arguments and results with privacy.enabled are prefixed
+// with their algorithm
+const (
+	privacyHashSHA256 = "sha256:"
+	privacyHashHMAC   = "hmac-sha256:"
+)
+
+// privacyHash hashes content kept out of logs and records, keyed with
+// privacy.hash_key so low-entropy values cannot be guessed from the hash
+func (p *Plugin) privacyHash(data []byte) string {
+	var h hash.Hash
+	prefix := privacyHashSHA256
+	if p.cfg.Privacy.HashKey != "" {
+		h = hmac.New(sha256.New, []byte(p.cfg.Privacy.HashKey))
+		prefix = privacyHashHMAC
+	} else {
+		h = sha256.New()
+	}
+	_, _ = h.Write(data)
+
+	return prefix + hex.EncodeToString(h.Sum(nil))
+}
+
+// recordArguments returns tool arguments for the call history and audit
+// records: redacted, or only their hash with privacy.enabled
+func (p *Plugin) recordArguments(arguments json.RawMessage) (json.RawMessage, string) {
+	if p.cfg.Privacy.Enabled {
+		return nil, p.privacyHash(arguments)
+	}
+
+	return p.redactArguments(arguments), ""
+}
+
+// recordResult returns the beginning of the text of a result for the call
+// history and audit records, or only the hash of the result with
+// privacy.enabled
+func (p *Plugin) recordResult(result *mcp.CallToolResult) (string, string) {
+	if !p.cfg.Privacy.Enabled {
+		return resultSummary(result), ""
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return "", ""
+	}
+
+	return "", p.privacyHash(data)
+}
+
+// argumentsField returns the log field of tool arguments, their hash with
+// privacy.enabled
+func (p *Plugin) argumentsField(arguments []byte) zap.Field {
+	if p.cfg.Privacy.Enabled {
+		return zap.String("arguments_hash", p.privacyHash(arguments))
+	}
+
+	return zap.ByteString("arguments", p.redactArguments(arguments))
+}
@@ -0,0 +1,224 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// maxCompletionEntries bounds the completion cache, expired entries are
+// dropped first once it is full
+const maxCompletionEntries = 1024
+
+// promptPageMiddleware pages prompts/list by prompts.page_size. The full list
+// is collected and filtered for the session before it is cut, so pages stay
+// full regardless of the prompts hidden from the session.
+func (p *Plugin) promptPageMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method != "prompts/list" {
+			return next(ctx, method, req)
+		}
+
+		listReq, ok := req.(*mcp.ListPromptsRequest)
+		if !ok {
+			return next(ctx, method, req)
+		}
+		if listReq.Params == nil {
+			listReq.Params = &mcp.ListPromptsParams{}
+		}
+
+		after := ""
+		if listReq.Params.Cursor != "" {
+			decoded, err := base64.RawURLEncoding.DecodeString(listReq.Params.Cursor)
+			if err != nil {
+				return nil, newJSONRPCError(codeInvalidParams, "invalid cursor", nil)
+			}
+			after = string(decoded)
+		}
+
+		var prompts []*mcp.Prompt
+		listReq.Params.Cursor = ""
+		for {
+			result, err := next(ctx, method, req)
+			if err != nil {
+				return nil, err
+			}
+			res, ok := result.(*mcp.ListPromptsResult)
+			if !ok {
+				return result, nil
+			}
+			prompts = append(prompts, res.Prompts...)
+			if res.NextCursor == "" {
+				break
+			}
+			listReq.Params.Cursor = res.NextCursor
+		}
+
+		// Tenant prompts are renamed on the way out, so the order is restored
+		slices.SortStableFunc(prompts, func(a, b *mcp.Prompt) int {
+			return strings.Compare(a.Name, b.Name)
+		})
+
+		start := 0
+		if after != "" {
+			start, _ = slices.BinarySearchFunc(prompts, after, func(pr *mcp.Prompt, name string) int {
+				if pr.Name <= name {
+					return -1
+				}
+				return 1
+			})
+		}
+
+		page := &mcp.ListPromptsResult{Prompts: prompts[start:]}
+		if len(page.Prompts) > p.cfg.Prompts.PageSize {
+			page.Prompts = page.Prompts[:p.cfg.Prompts.PageSize]
+			page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(page.Prompts[len(page.Prompts)-1].Name))
+		}
+
+		return page, nil
+	}
+}
+
+// completePrompt answers completion/complete for prompt arguments from the
+// upstream serving the prompt. Results are shared by all sessions for
+// prompts.completion_ttl, so a client completing on every keystroke does
+// not reach the upstream for values it asked for moments ago.
+func (p *Plugin) completePrompt(ctx context.Context, req *mcp.CompleteRequest) (*mcp.CompleteResult, error) {
+	const op = errors.Op("mcp_complete_prompt")
+
+	params := req.Params
+	if params == nil || params.Ref == nil || params.Ref.Type != "ref/prompt" {
+		return nil, newJSONRPCError(codeInvalidParams, "only prompt arguments can be completed", nil)
+	}
+
+	name, ok := p.resolveTenantPrompt(p.sessionTenant(sessionIDFromContext(ctx)), params.Ref.Name)
+	up, upstreamName := p.promptUpstream(name)
+	if !ok || up == nil {
+		return nil, newJSONRPCError(codeInvalidParams, fmt.Sprintf("unknown prompt %q", params.Ref.Name), nil)
+	}
+	if caps := up.session.InitializeResult().Capabilities; caps == nil || caps.Completions == nil {
+		return &mcp.CompleteResult{Completion: mcp.CompletionResultDetails{Values: []string{}}}, nil
+	}
+
+	key := completionKey(name, params)
+	if cached, ok := p.completions.get(key); ok {
+		return cached, nil
+	}
+
+	upCtx, cancel := context.WithTimeout(ctx, up.cfg.Timeout)
+	defer cancel()
+
+	result, err := up.session.Complete(upCtx, &mcp.CompleteParams{
+		Meta:     params.Meta,
+		Ref:      &mcp.CompleteReference{Type: "ref/prompt", Name: upstreamName},
+		Argument: params.Argument,
+		Context:  params.Context,
+	})
+	if err != nil {
+		p.log.Debug("prompt completion failed",
+			zap.String("upstream", up.name),
+			zap.String("prompt", upstreamName),
+			zap.Error(err),
+		)
+		return nil, errors.E(op, err)
+	}
+
+	p.completions.put(key, result)
+
+	return result, nil
+}
+
+// promptUpstream returns the upstream serving a registered prompt and the
+// prompt's name there
+func (p *Plugin) promptUpstream(name string) (*upstream, string) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, up := range p.upstreams {
+		if upstreamName, ok := up.prompts[name]; ok {
+			return up, upstreamName
+		}
+	}
+	return nil, ""
+}
+
+// completionKey identifies the completions of a prompt argument by the typed
+// value and the arguments resolved before it
+func completionKey(prompt string, params *mcp.CompleteParams) string {
+	var key strings.Builder
+	fmt.Fprintf(&key, "%s\x00%s\x00%s", prompt, params.Argument.Name, params.Argument.Value)
+	if params.Context != nil {
+		for _, name := range sortedKeys(params.Context.Arguments) {
+			fmt.Fprintf(&key, "\x00%s=%s", name, params.Context.Arguments[name])
+		}
+	}
+	return key.String()
+}
+
+// completionCache keeps completion results for a short time
+type completionCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*completionEntry
+}
+
+type completionEntry struct {
+	result  *mcp.CompleteResult
+	expires time.Time
+}
+
+func newCompletionCache(ttl time.Duration) *completionCache {
+	return &completionCache{ttl: ttl, entries: make(map[string]*completionEntry)}
+}
+
+// get returns a cached result that has not expired
+func (c *completionCache) get(key string) (*mcp.CompleteResult, bool) {
+	if c.ttl <= 0 {
+		return nil, false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || time.Now().After(entry.expires) {
+		return nil, false
+	}
+	return entry.result, true
+}
+
+// put caches a result, making room by dropping expired entries and then
+// arbitrary ones
+func (c *completionCache) put(key string, result *mcp.CompleteResult) {
+	if c.ttl <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if len(c.entries) >= maxCompletionEntries {
+		for k, entry := range c.entries {
+			if now.After(entry.expires) {
+				delete(c.entries, k)
+			}
+		}
+	}
+	for k := range c.entries {
+		if len(c.entries) < maxCompletionEntries {
+			break
+		}
+		delete(c.entries, k)
+	}
+
+	c.entries[key] = &completionEntry{result: result, expires: now.Add(c.ttl)}
+}
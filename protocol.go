@@ -0,0 +1,100 @@
+package mcp
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// Payload protocol versions spoken with the PHP SDK. Version 1 predates
+// versioning: payloads carry no protocolVersion and workers send none back.
+const (
+	PayloadProtocolVersion    = 2
+	minPayloadProtocolVersion = 1
+)
+
+// headerProtocolVersion carries the payload protocol version to workers
+const headerProtocolVersion = "X-MCP-Protocol-Version"
+
+// PingPayload is sent to every worker by the readiness check, next to the
+// protocolVersion every payload carries
+type PingPayload struct {
+	Supported []int    `json:"supportedVersions"`
+	Codecs    []string `json:"codecs"` // In order of preference
+}
+
+// PingResponse carries the payload protocol version chosen by the worker,
+// a missing version means a version 1 SDK
+type PingResponse struct {
+	ProtocolVersion int    `json:"protocolVersion,omitempty"`
+	Codec           string `json:"codec,omitempty"` // JSON when empty
+}
+
+// newPingPayload offers every supported protocol version and the configured codec
+func (p *Plugin) newPingPayload() *PingPayload {
+	ping := &PingPayload{Codecs: p.offeredCodecs()}
+	for v := PayloadProtocolVersion; v >= minPayloadProtocolVersion; v-- {
+		ping.Supported = append(ping.Supported, v)
+	}
+	return ping
+}
+
+// parsePing decodes a worker's ping response, an empty body is a version 1 worker
+func parsePing(body []byte) (*PingResponse, error) {
+	resp := &PingResponse{}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, resp); err != nil {
+			return nil, errors.Errorf("invalid ping response: %v", err)
+		}
+	}
+	return resp, nil
+}
+
+// negotiateProtocol returns the protocol version of a worker's ping response
+func negotiateProtocol(resp *PingResponse) (int, error) {
+	version := resp.ProtocolVersion
+	if version == 0 {
+		version = minPayloadProtocolVersion
+	}
+	if version < minPayloadProtocolVersion || version > PayloadProtocolVersion {
+		return 0, errors.Errorf("worker speaks payload protocol version %d, supported are %d to %d", version, minPayloadProtocolVersion, PayloadProtocolVersion)
+	}
+
+	return version, nil
+}
+
+// protocolVersion returns the payload protocol version negotiated with the
+// workers, the current one until a readiness ping says otherwise
+func (p *Plugin) protocolVersion() int {
+	if v := p.workerProtocol.Load(); v != 0 {
+		return int(v)
+	}
+	return PayloadProtocolVersion
+}
+
+// encodePayload applies the compatibility shim of a protocol version to an
+// event body: version 2 adds protocolVersion to the JSON object. The result
+// may alias dst.
+func encodePayload(dst *bytes.Buffer, version int, body []byte, headers map[string][]string) []byte {
+	if version < 2 {
+		return body
+	}
+
+	headers[headerProtocolVersion] = []string{strconv.Itoa(version)}
+
+	if len(body) < 2 || body[0] != '{' {
+		return body
+	}
+
+	dst.Reset()
+	dst.WriteString(`{"protocolVersion":`)
+	dst.WriteString(strconv.Itoa(version))
+	if string(body) != "{}" {
+		dst.WriteByte(',')
+	}
+	dst.Write(body[1:])
+
+	return dst.Bytes()
+}
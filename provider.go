@@ -0,0 +1,116 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// ToolProvider is implemented by RoadRunner plugins exposing their functionality
+// as MCP tools, they are collected by the plugin and registered on Serve
+type ToolProvider interface {
+	// Name returns the plugin name, used as the tools namespace
+	Name() string
+	// MCPTools returns the tools provided by the plugin
+	MCPTools() []*ProviderTool
+}
+
+// ProviderTool is a tool implemented in Go by another plugin
+type ProviderTool struct {
+	// Tool definition, the name is qualified with the provider namespace.
+	// InputSchema must be a JSON schema object
+	Tool *mcp.Tool
+	// Handler is called with the tool arguments
+	Handler mcp.ToolHandler
+}
+
+// registerProviders registers the tools of all collected providers
+func (p *Plugin) registerProviders() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	changed := false
+
+	for _, provider := range p.providers {
+		for _, pt := range provider.MCPTools() {
+			if p.registerProviderTool(provider.Name(), pt) {
+				changed = true
+			}
+		}
+	}
+
+	if changed && p.cfg.Tools.NotifyClientsOnChange {
+		p.notifyToolsChanged()
+	}
+}
+
+// registerProviderTool registers a single provider tool, the caller holds the lock
+func (p *Plugin) registerProviderTool(namespace string, pt *ProviderTool) bool {
+	if pt == nil || pt.Tool == nil || pt.Handler == nil {
+		p.log.Warn("provider returned an incomplete tool", zap.String("provider", namespace))
+		return false
+	}
+
+	name := p.qualifiedToolName(namespace, pt.Tool.Name)
+
+	if entry, exists := p.tools[name]; exists {
+		p.log.Warn("provider tool collides with registered tool",
+			zap.String("provider", namespace),
+			zap.String("tool", name),
+			zap.String("source", entry.Source),
+		)
+		return false
+	}
+
+	schema, _ := pt.Tool.InputSchema.(map[string]interface{})
+	if schema["type"] != "object" {
+		p.log.Warn("provider tool has no object input schema",
+			zap.String("provider", namespace),
+			zap.String("tool", pt.Tool.Name),
+		)
+		return false
+	}
+
+	tool := *pt.Tool
+	tool.Name = name
+	p.addTool(&tool, p.providerToolHandler(pt.Handler))
+
+	now := time.Now()
+	p.setTool(name, &toolEntry{
+		Tool:         &tool,
+		Namespace:    namespace,
+		Schema:       schema,
+		Source:       ToolSourceProvider,
+		RegisteredAt: now,
+		UpdatedAt:    now,
+	})
+
+	p.publish(&BusEvent{
+		Event:     BusToolRegistered,
+		Tool:      name,
+		Source:    ToolSourceProvider,
+		Namespace: namespace,
+	})
+
+	p.log.Debug("provider tool registered",
+		zap.String("provider", namespace),
+		zap.String("tool", name),
+	)
+
+	return true
+}
+
+// providerToolHandler wraps a provider handler with session bookkeeping and
+// panic recovery
+func (p *Plugin) providerToolHandler(handler mcp.ToolHandler) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (result *mcp.CallToolResult, _ error) {
+		defer p.recoverToolCall(ctx, req.Params.Name, &result)
+
+		if sessionID := sessionIDFromContext(ctx); sessionID != "" {
+			p.updateSessionActivity(sessionID)
+		}
+		return handler(ctx, req)
+	}
+}
@@ -0,0 +1,152 @@
+package mcp
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/roadrunner-server/api/v4/plugins/v1/status"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// readinessRetry is the pause between rounds of worker pings
+const readinessRetry = time.Second
+
+// Ready reports whether enough workers answered the startup ping, it is
+// polled by the RoadRunner status plugin
+func (p *Plugin) Ready() (*status.Status, error) {
+	if p.ready.Load() {
+		return &status.Status{Code: http.StatusOK}, nil
+	}
+	return &status.Status{Code: http.StatusServiceUnavailable}, nil
+}
+
+// awaitReadiness pings workers until readiness.min_workers answer, failing
+// after readiness.timeout
+func (p *Plugin) awaitReadiness() error {
+	const op = errors.Op("mcp_await_readiness")
+
+	p.mu.RLock()
+	needed := p.cfg.Readiness.MinWorkers
+	if p.pool == nil && p.eventHandler == nil {
+		// Mock mode has no workers
+		needed = 0
+	}
+	p.mu.RUnlock()
+
+	if needed == 0 {
+		p.ready.Store(true)
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.cfg.Readiness.Timeout)
+	defer cancel()
+
+	answered, err := p.awaitWorkers(ctx, needed)
+	if err != nil {
+		if p.ctx.Err() != nil {
+			return nil
+		}
+		return errors.E(op, errors.Errorf("%d of %d workers answered the ping within %s", answered, needed, p.cfg.Readiness.Timeout))
+	}
+
+	p.ready.Store(true)
+	return nil
+}
+
+// awaitWorkers pings workers until needed answer and keeps the protocol
+// version and codec they agreed on, failing when ctx is done first
+func (p *Plugin) awaitWorkers(ctx context.Context, needed int) (int, error) {
+	for {
+		answered, version, codec := p.pingWorkers(ctx)
+		if answered >= needed {
+			p.workerProtocol.Store(int32(version))
+			p.workerCodec.Store(codec)
+			p.log.Info("workers ready",
+				zap.Int("answered", answered),
+				zap.Int("protocol_version", version),
+				zap.String("codec", codec),
+			)
+			return answered, nil
+		}
+
+		p.log.Debug("waiting for workers",
+			zap.Int("answered", answered),
+			zap.Int("required", needed),
+		)
+
+		select {
+		case <-ctx.Done():
+			return answered, ctx.Err()
+		case <-time.After(readinessRetry):
+		}
+	}
+}
+
+// pingWorkers sends EventPing once per worker of the main pool concurrently,
+// returning how many pings were answered, the lowest protocol version among
+// the answering workers and the codec they agreed on
+func (p *Plugin) pingWorkers(ctx context.Context) (int, int, string) {
+	p.mu.RLock()
+	pings := p.cfg.Readiness.MinWorkers
+	if p.pool != nil {
+		pings = max(pings, len(p.pool.Workers()))
+	}
+	p.mu.RUnlock()
+
+	var (
+		wg       sync.WaitGroup
+		mu       sync.Mutex
+		answered int
+		version  = PayloadProtocolVersion
+		codec    string
+	)
+
+	for range pings {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			body, err := p.sendEvent(ctx, "", EventPing, p.newPingPayload())
+			if err != nil {
+				p.log.Debug("worker ping failed", zap.Error(err))
+				return
+			}
+
+			resp, err := parsePing(body)
+			if err != nil {
+				p.log.Error("incompatible worker", zap.Error(err))
+				return
+			}
+			workerVersion, err := negotiateProtocol(resp)
+			if err != nil {
+				p.log.Error("incompatible worker", zap.Error(err))
+				return
+			}
+			workerCodec, err := p.negotiateCodec(resp)
+			if err != nil {
+				p.log.Error("incompatible worker", zap.Error(err))
+				return
+			}
+
+			mu.Lock()
+			answered++
+			version = min(version, workerVersion)
+			// Workers disagreeing on the codec all understand JSON
+			if codec == "" {
+				codec = workerCodec
+			} else if codec != workerCodec {
+				codec = CodecJSON
+			}
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+	if codec == "" {
+		codec = CodecJSON
+	}
+	return answered, version, codec
+}
@@ -0,0 +1,49 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// recoverToolCall turns a panic in a tool handler into an error result, so a
+// single bad call cannot take down the server. Deferred with the handler's
+// named result.
+func (p *Plugin) recoverToolCall(ctx context.Context, tool string, result **mcp.CallToolResult) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	p.errorCounts.add(errorClassPanic)
+
+	p.callLogger(ctx).Error("tool handler panicked",
+		zap.String("tool", tool),
+		zap.String("session_id", sessionIDFromContext(ctx)),
+		zap.Any("panic", r),
+		zap.Stack("stack"),
+	)
+
+	*result = toolErrorResult(fmt.Errorf("internal error while executing tool %q", tool))
+}
+
+// recoverNotification turns a panic while notifying a session into an error,
+// the remaining sessions are still notified. Deferred with the named error.
+func (p *Plugin) recoverNotification(ss *mcp.ServerSession, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	p.errorCounts.add(errorClassPanic)
+
+	p.log.Error("client notification panicked",
+		zap.String("session_id", ss.ID()),
+		zap.Any("panic", r),
+		zap.Stack("stack"),
+	)
+
+	*err = fmt.Errorf("notification panicked: %v", r)
+}
@@ -0,0 +1,295 @@
+package mcp
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redaction targets reported in metrics
+const (
+	redactionTargetArguments = "arguments"
+	redactionTargetResult    = "result"
+)
+
+// RedactionRule is a custom redaction rule, matching either a regular
+// expression or tokens with a high Shannon entropy
+type RedactionRule struct {
+	Name    string `mapstructure:"name"`
+	Pattern string `mapstructure:"pattern"`
+
+	// Minimum entropy in bits per character of redacted tokens
+	Entropy float64 `mapstructure:"entropy"`
+
+	// Minimum length of tokens checked for entropy, default 20
+	MinLength int `mapstructure:"min_length"`
+}
+
+// builtinRedactionRules are the rules enabled by name in redaction.builtin
+var builtinRedactionRules = map[string][]*redactionRule{
+	"secrets": secretRules("secrets"),
+	"email": {{
+		pattern: regexp.MustCompile(`\b[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}\b`),
+	}},
+	"phone": {{
+		pattern: regexp.MustCompile(`(?:\+\d{1,3}[\s.-]?)?\(?\b\d{3}\)?[\s.-]\d{3}[\s.-]\d{4}\b`),
+	}},
+	"credit_card": {{
+		pattern: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`),
+		check:   luhnValid,
+	}},
+	"iban": {{
+		pattern: regexp.MustCompile(`\b[A-Z]{2}\d{2}(?: ?[A-Z0-9]{4}){2,7}(?: ?[A-Z0-9]{1,3})?\b`),
+	}},
+	"ssn": {{
+		pattern: regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`),
+	}},
+	"ipv4": {{
+		pattern: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1?\d?\d)\.){3}(?:25[0-5]|2[0-4]\d|1?\d?\d)\b`),
+	}},
+}
+
+// Defaults of the redaction settings
+var (
+	defaultRedactionBuiltin = []string{"secrets", "email", "credit_card", "iban"}
+	defaultRedactionKeys    = []string{"password", "passwd", "secret", "token", "api_key", "apikey", "authorization"}
+)
+
+// redactionToken splits text into tokens checked by entropy rules
+var redactionToken = regexp.MustCompile("[^\\s\"'`,;:=(){}\\[\\]<>]+")
+
+// redactionRule is a compiled redaction rule
+type redactionRule struct {
+	name      string
+	pattern   *regexp.Regexp
+	check     func(match string) bool // Confirms a pattern match
+	entropy   float64
+	minLength int
+}
+
+// secretRules returns rules for the built-in secret patterns
+func secretRules(name string) []*redactionRule {
+	rules := make([]*redactionRule, 0, len(secretPatterns))
+	for _, re := range secretPatterns {
+		rules = append(rules, &redactionRule{name: name, pattern: re})
+	}
+	return rules
+}
+
+// redactionHitKey identifies a redaction counter
+type redactionHitKey struct {
+	rule   string
+	target string
+}
+
+// redactionHits counts redactions per rule and target
+type redactionHits struct {
+	mu     sync.Mutex
+	counts map[redactionHitKey]uint64
+}
+
+func newRedactionHits() *redactionHits {
+	return &redactionHits{counts: make(map[redactionHitKey]uint64)}
+}
+
+// add counts redactions of a rule
+func (h *redactionHits) add(rule, target string, n int) {
+	if h == nil || n == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	h.counts[redactionHitKey{rule: rule, target: target}] += uint64(n)
+	h.mu.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (h *redactionHits) snapshot() map[redactionHitKey]uint64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	counts := make(map[redactionHitKey]uint64, len(h.counts))
+	for k, v := range h.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// redactor replaces sensitive data in text and JSON values
+type redactor struct {
+	rules       []*redactionRule
+	keys        map[string]bool
+	replacement string
+	hits        *redactionHits
+}
+
+// newRedactor compiles the redaction configuration
+func newRedactor(cfg *Config, hits *redactionHits) *redactor {
+	r := &redactor{
+		keys:        make(map[string]bool, len(cfg.Redaction.Keys)),
+		replacement: cfg.Redaction.Replacement,
+		hits:        hits,
+	}
+
+	for _, name := range cfg.Redaction.Builtin {
+		for _, rule := range builtinRedactionRules[name] {
+			r.rules = append(r.rules, &redactionRule{
+				name:    name,
+				pattern: rule.pattern,
+				check:   rule.check,
+			})
+		}
+	}
+
+	for _, rule := range cfg.Redaction.Rules {
+		compiled := &redactionRule{
+			name:      rule.Name,
+			entropy:   rule.Entropy,
+			minLength: rule.MinLength,
+		}
+		if rule.Pattern != "" {
+			// Validated with the configuration
+			compiled.pattern = regexp.MustCompile(rule.Pattern)
+		}
+		r.rules = append(r.rules, compiled)
+	}
+
+	for _, key := range cfg.Redaction.Keys {
+		r.keys[strings.ToLower(key)] = true
+	}
+
+	return r
+}
+
+// redact replaces all matches of the rules in text
+func (r *redactor) redact(text, target string) string {
+	for _, rule := range r.rules {
+		hits := 0
+
+		replace := func(match string) string {
+			if rule.check != nil && !rule.check(match) {
+				return match
+			}
+			hits++
+			return r.replacement
+		}
+
+		if rule.pattern != nil {
+			text = rule.pattern.ReplaceAllStringFunc(text, replace)
+		} else {
+			text = redactionToken.ReplaceAllStringFunc(text, func(token string) string {
+				if len(token) < rule.minLength || shannonEntropy(token) < rule.entropy {
+					return token
+				}
+				return replace(token)
+			})
+		}
+
+		r.hits.add(rule.name, target, hits)
+	}
+
+	return text
+}
+
+// redactValue redacts the strings of a decoded JSON value, values of
+// sensitive keys are replaced entirely
+func (r *redactor) redactValue(v interface{}, target string) interface{} {
+	switch value := v.(type) {
+	case string:
+		return r.redact(value, target)
+	case []interface{}:
+		for i := range value {
+			value[i] = r.redactValue(value[i], target)
+		}
+	case map[string]interface{}:
+		for k := range value {
+			if r.keys[strings.ToLower(k)] {
+				value[k] = r.replacement
+				r.hits.add("keys", target, 1)
+				continue
+			}
+			value[k] = r.redactValue(value[k], target)
+		}
+	}
+	return v
+}
+
+// redactJSON redacts a JSON document, invalid documents are redacted as text
+func (r *redactor) redactJSON(data json.RawMessage, target string) json.RawMessage {
+	if len(data) == 0 {
+		return data
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		text, _ := json.Marshal(r.redact(string(data), target))
+		return text
+	}
+
+	redacted, err := json.Marshal(r.redactValue(value, target))
+	if err != nil {
+		return nil
+	}
+
+	return redacted
+}
+
+// redactArguments returns tool arguments safe for logs and the call history
+func (p *Plugin) redactArguments(arguments json.RawMessage) json.RawMessage {
+	if p.redactor == nil {
+		return arguments
+	}
+
+	return p.redactor.redactJSON(arguments, redactionTargetArguments)
+}
+
+// shannonEntropy returns the entropy of s in bits per character
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	total := 0
+	for _, c := range s {
+		counts[c]++
+		total++
+	}
+
+	entropy := 0.0
+	for _, n := range counts {
+		f := float64(n) / float64(total)
+		entropy -= f * math.Log2(f)
+	}
+
+	return entropy
+}
+
+// luhnValid reports whether the digits of s pass the Luhn checksum
+func luhnValid(s string) bool {
+	sum := 0
+	digits := 0
+	double := false
+
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+
+	return digits >= 13 && sum%10 == 0
+}
@@ -0,0 +1,120 @@
+package mcp
+
+import (
+	"hash/fnv"
+	"maps"
+	"sync"
+)
+
+// sessionShards spreads sessions over independently locked maps
+const sessionShards = 32
+
+// sessionRegistry is the sharded map of active sessions. It only guards
+// membership, fields of SessionInfo are still guarded by the plugin lock.
+type sessionRegistry struct {
+	shards [sessionShards]sessionShard
+}
+
+type sessionShard struct {
+	mu       sync.RWMutex
+	sessions map[string]*SessionInfo
+}
+
+func newSessionRegistry() *sessionRegistry {
+	r := &sessionRegistry{}
+	for i := range r.shards {
+		r.shards[i].sessions = make(map[string]*SessionInfo)
+	}
+	return r
+}
+
+func (r *sessionRegistry) shard(sessionID string) *sessionShard {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(sessionID))
+	return &r.shards[h.Sum32()%sessionShards]
+}
+
+// get returns a session
+func (r *sessionRegistry) get(sessionID string) (*SessionInfo, bool) {
+	s := r.shard(sessionID)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	info, ok := s.sessions[sessionID]
+	return info, ok
+}
+
+// set adds or replaces a session
+func (r *sessionRegistry) set(sessionID string, info *SessionInfo) {
+	s := r.shard(sessionID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sessions[sessionID] = info
+}
+
+// delete removes a session
+func (r *sessionRegistry) delete(sessionID string) {
+	s := r.shard(sessionID)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.sessions, sessionID)
+}
+
+// len returns the number of sessions
+func (r *sessionRegistry) len() int {
+	n := 0
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.RLock()
+		n += len(s.sessions)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// snapshot returns a copy of all sessions, safe to iterate while sessions
+// are added or removed
+func (r *sessionRegistry) snapshot() map[string]*SessionInfo {
+	sessions := make(map[string]*SessionInfo)
+	for i := range r.shards {
+		s := &r.shards[i]
+		s.mu.RLock()
+		maps.Copy(sessions, s.sessions)
+		s.mu.RUnlock()
+	}
+	return sessions
+}
+
+// setTool registers a tool entry, must be called under lock
+func (p *Plugin) setTool(name string, entry *toolEntry) {
+	p.tools[name] = entry
+	p.toolView.Store(nil)
+}
+
+// deleteTool removes a tool entry and the tool from the servers of all
+// connections, must be called under lock
+func (p *Plugin) deleteTool(name string) {
+	p.removeAliases(p.tools[name])
+	delete(p.tools, name)
+	p.toolView.Store(nil)
+	p.removeFeature(featureTool + name)
+}
+
+// toolSnapshot returns an immutable copy of the tool registry for reads
+// without the plugin lock, rebuilt on first use after a change. Must not be
+// called under lock.
+func (p *Plugin) toolSnapshot() map[string]*toolEntry {
+	if tools := p.toolView.Load(); tools != nil {
+		return *tools
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	// Writers invalidate under the write lock, so the copy stays current
+	tools := maps.Clone(p.tools)
+	p.toolView.Store(&tools)
+	return tools
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// benchSessions is the number of sessions spread over the shards
+const benchSessions = 1024
+
+// lockedSessions is a session map behind a single lock, as before sharding
+type lockedSessions struct {
+	mu       sync.RWMutex
+	sessions map[string]*SessionInfo
+}
+
+func benchSessionIDs() []string {
+	ids := make([]string, benchSessions)
+	for i := range ids {
+		ids[i] = uuid.New().String()
+	}
+	return ids
+}
+
+// BenchmarkSessionActivity looks up sessions and records their activity
+// from parallel calls, as every tool call does
+func BenchmarkSessionActivity(b *testing.B) {
+	ids := benchSessionIDs()
+
+	b.Run("locked", func(b *testing.B) {
+		r := &lockedSessions{sessions: make(map[string]*SessionInfo)}
+		for _, id := range ids {
+			r.sessions[id] = &SessionInfo{ID: id}
+		}
+
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				r.mu.Lock()
+				if info, ok := r.sessions[ids[i%benchSessions]]; ok {
+					info.touch()
+				}
+				r.mu.Unlock()
+			}
+		})
+	})
+
+	b.Run("sharded", func(b *testing.B) {
+		p := &Plugin{sessions: newSessionRegistry()}
+		for _, id := range ids {
+			p.sessions.set(id, &SessionInfo{ID: id})
+		}
+
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				p.updateSessionActivity(ids[i%benchSessions])
+			}
+		})
+	})
+}
+
+// BenchmarkToolLookup reads tool entries from parallel calls, as tenant
+// resolution and retries do on the call path
+func BenchmarkToolLookup(b *testing.B) {
+	p := &Plugin{tools: make(map[string]*toolEntry)}
+	names := make([]string, 256)
+	for i := range names {
+		names[i] = "tool_" + strconv.Itoa(i)
+		p.setTool(names[i], &toolEntry{Source: ToolSourcePHP})
+	}
+
+	b.Run("locked", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				p.mu.RLock()
+				_ = p.tools[names[i%len(names)]]
+				p.mu.RUnlock()
+			}
+		})
+	})
+
+	b.Run("snapshot", func(b *testing.B) {
+		b.ReportAllocs()
+		b.RunParallel(func(pb *testing.PB) {
+			for i := 0; pb.Next(); i++ {
+				_ = p.toolSnapshot()[names[i%len(names)]]
+			}
+		})
+	})
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// headerRequestID carries the request ID to workers and from HTTP clients
+const headerRequestID = "X-Request-ID"
+
+// metaRequestID is the _meta key clients set to propagate their request ID
+const metaRequestID = "requestId"
+
+// requestIDCtxKey carries the request ID of a tool call
+type requestIDCtxKey struct{}
+
+// withRequestID binds a request ID to a tool call context
+func withRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDCtxKey{}, requestID)
+}
+
+// requestIDFromContext returns the request ID of a tool call, empty outside of calls
+func requestIDFromContext(ctx context.Context) string {
+	if requestID, ok := ctx.Value(requestIDCtxKey{}).(string); ok {
+		return requestID
+	}
+	return ""
+}
+
+// callLogger returns the logger for a tool call, tagged with its request ID
+func (p *Plugin) callLogger(ctx context.Context) *zap.Logger {
+	if requestID := requestIDFromContext(ctx); requestID != "" {
+		return p.log.With(zap.String("request_id", requestID))
+	}
+	return p.log
+}
+
+// requestIDMiddleware assigns every tool call a request ID, propagated from
+// the client's _meta.requestId or X-Request-ID header or generated
+func (p *Plugin) requestIDMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok {
+			return next(ctx, method, req)
+		}
+
+		requestID, _ := params.Meta[metaRequestID].(string)
+		if extra := req.GetExtra(); requestID == "" && extra != nil && extra.Header != nil {
+			requestID = extra.Header.Get(headerRequestID)
+		}
+		if requestID == "" {
+			requestID = uuid.New().String()
+		}
+
+		return next(withRequestID(ctx, requestID), method, req)
+	}
+}
@@ -0,0 +1,95 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// ResetPoolRequest is sent from PHP deploy tooling to restart the workers
+type ResetPoolRequest struct {
+	Timeout time.Duration `json:"timeout,omitempty"` // Default readiness.timeout
+}
+
+// ResetPoolResponse reports the restarted pools
+type ResetPoolResponse struct {
+	Pools           []string      `json:"pools"`    // Config paths, e.g. pool or tenants.acme.pool
+	Answered        int           `json:"answered"` // Workers of the main pool that answered the ping
+	ProtocolVersion int           `json:"protocolVersion"`
+	Resynced        bool          `json:"resynced"` // Tools were re-synced with ListTools
+	Duration        time.Duration `json:"duration"`
+}
+
+// Reset restarts the worker pools on rr reset, nothing to do in mock mode
+func (p *Plugin) Reset() error {
+	const op = errors.Op("mcp_reset")
+
+	if len(p.workerPools()) == 0 {
+		return nil
+	}
+
+	if err := p.resetPools(p.cfg.Readiness.Timeout, &ResetPoolResponse{}); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// resetPools restarts every worker pool and returns once the main pool's new
+// workers answer the readiness ping, at least one or readiness.min_workers,
+// and tools are re-synced with tools.resync_on_restart. Without it the new
+// workers declared their tools before answering.
+func (p *Plugin) resetPools(timeout time.Duration, resp *ResetPoolResponse) error {
+	const op = errors.Op("mcp_reset_pools")
+
+	p.resetMu.Lock()
+	defer p.resetMu.Unlock()
+
+	pools := p.workerPools()
+	if len(pools) == 0 {
+		return errors.E(op, errors.Str("no worker pool to reset"))
+	}
+
+	if timeout <= 0 {
+		timeout = p.cfg.Readiness.Timeout
+	}
+	ctx, cancel := context.WithTimeout(p.ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	resp.Pools = sortedKeys(pools)
+	for _, path := range resp.Pools {
+		if err := pools[path].Reset(ctx); err != nil {
+			return errors.E(op, errors.Errorf("%s: %v", path, err))
+		}
+	}
+
+	if _, ok := pools["pool"]; ok {
+		answered, err := p.awaitWorkers(ctx, max(1, p.cfg.Readiness.MinWorkers))
+		if err != nil {
+			return errors.E(op, errors.Errorf("%d workers answered the ping within %s", answered, timeout))
+		}
+		resp.Answered = answered
+		resp.ProtocolVersion = p.protocolVersion()
+
+		if p.cfg.Tools.ResyncOnRestart {
+			if err := p.resyncTools(); err != nil {
+				return errors.E(op, err)
+			}
+			resp.Resynced = true
+		}
+	}
+
+	resp.Duration = time.Since(start)
+
+	p.log.Info("worker pools reset",
+		zap.Strings("pools", resp.Pools),
+		zap.Int("answered", resp.Answered),
+		zap.Bool("resynced", resp.Resynced),
+		zap.Duration("duration", resp.Duration),
+	)
+
+	return nil
+}
@@ -0,0 +1,215 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"os"
+	"path/filepath"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+)
+
+// rangeMetaKey is the _meta key of the range of a resource read, sent by
+// the client and answered with the offset and total size
+const rangeMetaKey = "range"
+
+// resourceRange returns the range a client asked for, nil for whole reads
+func resourceRange(meta mcp.Meta) (*ResourceRange, error) {
+	value, ok := meta[rangeMetaKey]
+	if !ok {
+		return nil, nil
+	}
+
+	var rng ResourceRange
+	data, err := json.Marshal(value)
+	if err == nil {
+		err = json.Unmarshal(data, &rng)
+	}
+	if err != nil || rng.Offset < 0 || rng.Length < 0 {
+		return nil, newJSONRPCError(codeInvalidParams, `invalid _meta.range, expected {"offset": bytes, "length": bytes}`, nil)
+	}
+
+	return &rng, nil
+}
+
+// limit returns the bytes to read of every content, at most max
+func (r *ResourceRange) limit(max int64) int64 {
+	if r.Length == 0 || r.Length > max {
+		return max
+	}
+	return r.Length
+}
+
+// readResourceRange reads a range of a resource declared by PHP. Fresh
+// cached contents are cut without asking PHP, otherwise PHP is asked for
+// the range and contents it returns whole are cut. Ranges are not cached.
+func (p *Plugin) readResourceRange(ctx context.Context, uri string, rng *ResourceRange) (*mcp.ReadResourceResult, error) {
+	const op = errors.Op("mcp_read_resource_range")
+
+	length := rng.limit(p.cfg.Resources.MaxSize)
+
+	if cached, fresh, _ := p.resourceCache.get(uri); fresh {
+		result := cached.result()
+		var total int64
+		for _, c := range result.Contents {
+			total = max(total, cutContents(c, rng.Offset, length))
+		}
+		return withRange(result, rng.Offset, total), nil
+	}
+
+	payloadData := &ReadResourcePayload{
+		SessionID: sessionIDFromContext(ctx),
+		URI:       uri,
+		Range:     rng,
+	}
+
+	phpResp, err := p.sendEvent(ctx, payloadData.SessionID, EventReadResource, payloadData)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	var readResp ReadResourceResponse
+	if err := json.Unmarshal(phpResp, &readResp); err != nil {
+		return nil, errors.E(op, fmt.Errorf("invalid worker response: %w", err))
+	}
+
+	if readResp.NotModified {
+		return nil, errors.E(op, errors.Str("worker answered notModified to a range read"))
+	}
+
+	result := &mcp.ReadResourceResult{}
+	total := readResp.TotalSize
+	for i, c := range readResp.Contents {
+		if c.URI == "" {
+			c.URI = uri
+		}
+
+		var contents *mcp.ResourceContents
+		var size int64
+		if c.File != "" {
+			contents, size, err = p.resourceFileRange(&c, rng.Offset, length)
+		} else {
+			contents, err = convertResource(MCPContent{Resource: &c})
+			if err == nil {
+				if readResp.TotalSize > 0 {
+					// PHP answered the range, only more than asked for is cut
+					cutContents(contents, 0, length)
+				} else {
+					size = cutContents(contents, rng.Offset, length)
+				}
+			}
+		}
+		if err != nil {
+			return nil, errors.E(op, fmt.Errorf("content %d: %w", i, err))
+		}
+
+		if contents.MIMEType == "" {
+			contents.MIMEType = p.resourceMimeType(uri)
+		}
+		result.Contents = append(result.Contents, contents)
+
+		if readResp.TotalSize == 0 {
+			total = max(total, size)
+		}
+	}
+
+	return withRange(result, rng.Offset, total), nil
+}
+
+// resourceFileRange reads a range of a file PHP returned as contents
+func (p *Plugin) resourceFileRange(c *ResourceContent, offset, length int64) (*mcp.ResourceContents, int64, error) {
+	if c.Text != "" || c.Blob != "" {
+		return nil, 0, errors.Str("file cannot be combined with text or blob")
+	}
+
+	f, err := openResourceFile(p.cfg.Resources.FileRoot, c.File)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer func() { _ = f.Close() }()
+
+	blob, total, err := readFileRange(f, offset, length)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	mimeType := c.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(c.File))
+	}
+
+	return &mcp.ResourceContents{URI: c.URI, MIMEType: mimeType, Blob: blob}, total, nil
+}
+
+// readFileRange reads length bytes of a file from offset and returns them
+// with the file size
+func readFileRange(f *os.File, offset, length int64) ([]byte, int64, error) {
+	stat, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	total := stat.Size()
+	start := min(offset, total)
+	end := min(start+length, total)
+
+	data := make([]byte, end-start)
+	if _, err := f.ReadAt(data, start); err != nil && err != io.EOF {
+		return nil, 0, err
+	}
+
+	return data, total, nil
+}
+
+// cutContents cuts contents to length bytes from offset and returns their
+// size before. Text is cut at a character boundary.
+func cutContents(c *mcp.ResourceContents, offset, length int64) int64 {
+	if c.Blob != nil {
+		total := int64(len(c.Blob))
+		start := min(offset, total)
+		c.Blob = c.Blob[start:min(start+length, total)]
+		return total
+	}
+
+	total := int64(len(c.Text))
+	start := min(offset, total)
+	end := min(start+length, total)
+	c.Text = string(trimPartialRune([]byte(c.Text[start:end]), end < total))
+	return total
+}
+
+// trimPartialRune drops a character cut by the end of a range, unless it is
+// the end of the contents or all the range holds
+func trimPartialRune(data []byte, cut bool) []byte {
+	if !cut {
+		return data
+	}
+
+	for i := len(data) - 1; i > 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+
+	return data
+}
+
+// withRange tells the client which range a result holds
+func withRange(result *mcp.ReadResourceResult, offset, total int64) *mcp.ReadResourceResult {
+	if result.Meta == nil {
+		result.Meta = mcp.Meta{}
+	}
+	result.Meta[rangeMetaKey] = map[string]interface{}{
+		"offset":    offset,
+		"totalSize": total,
+	}
+	return result
+}
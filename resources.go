@@ -0,0 +1,360 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// validResourceURI reports whether uri is an absolute URI clients can read
+func validResourceURI(uri string) error {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return err
+	}
+	if u.Scheme == "" {
+		return errors.Str("uri must be absolute")
+	}
+	return nil
+}
+
+// declareResources registers resources read through the ReadResource event,
+// must be called under lock
+func (p *Plugin) declareResources(resources []ResourceDefinition) ([]string, error) {
+	if err := p.validateResources(resources); err != nil {
+		return nil, err
+	}
+
+	registered := make([]string, 0, len(resources))
+	for _, def := range resources {
+		p.resources[def.URI] = &def
+		p.addResource(&mcp.Resource{
+			URI:         def.URI,
+			Name:        def.Name,
+			Title:       def.Title,
+			Description: def.Description,
+			MIMEType:    def.MimeType,
+			Size:        def.Size,
+		}, p.readResource)
+
+		// Declared again, e.g. after a deploy, the contents may have changed
+		p.resourceCache.invalidate(def.URI)
+
+		registered = append(registered, def.URI)
+	}
+
+	return registered, nil
+}
+
+// validateResources checks resource declarations, must be called under lock
+func (p *Plugin) validateResources(resources []ResourceDefinition) error {
+	for _, def := range resources {
+		if err := validResourceURI(def.URI); err != nil {
+			return fmt.Errorf("resource %q: %w", def.URI, err)
+		}
+		if def.Name == "" {
+			return fmt.Errorf("resource %q: name is required", def.URI)
+		}
+		for _, up := range p.upstreams {
+			if _, ok := up.resources[def.URI]; ok {
+				return fmt.Errorf("resource %q is served by upstream %q", def.URI, up.name)
+			}
+		}
+	}
+
+	return nil
+}
+
+// removeResources removes resources declared by PHP, must be called under lock
+func (p *Plugin) removeResources(uris []string) {
+	for _, uri := range uris {
+		if _, ok := p.resources[uri]; !ok {
+			continue
+		}
+		delete(p.resources, uri)
+		p.removeFeature(featureResource + uri)
+		p.resourceCache.invalidate(uri)
+
+		p.log.Info("resource removed", zap.String("uri", uri))
+	}
+}
+
+// readResource reads a resource declared by PHP. Contents PHP versioned with
+// an etag are served from the cache until the resource is updated; once
+// resources.cache_ttl passed PHP is asked again with the etag and may answer
+// that they did not change. Clients read ranges with _meta.range.
+func (p *Plugin) readResource(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	const op = errors.Op("mcp_read_resource")
+
+	uri := req.Params.URI
+	rng, err := resourceRange(req.Params.Meta)
+	if err != nil {
+		return nil, err
+	}
+	if rng != nil {
+		return p.readResourceRange(ctx, uri, rng)
+	}
+
+	cached, fresh, version := p.resourceCache.get(uri)
+	if fresh {
+		return cached.result(), nil
+	}
+
+	payloadData := &ReadResourcePayload{
+		SessionID: sessionIDFromContext(ctx),
+		URI:       uri,
+	}
+	if cached != nil {
+		payloadData.ETag = cached.etag
+	}
+
+	phpResp, err := p.sendEvent(ctx, payloadData.SessionID, EventReadResource, payloadData)
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	var readResp ReadResourceResponse
+	if err := json.Unmarshal(phpResp, &readResp); err != nil {
+		return nil, errors.E(op, fmt.Errorf("invalid worker response: %w", err))
+	}
+
+	if readResp.NotModified {
+		if cached == nil {
+			return nil, errors.E(op, errors.Str("worker answered notModified without a cached version"))
+		}
+		p.resourceCache.revalidate(uri, cached, version)
+		return cached.result(), nil
+	}
+
+	entry := &cachedResource{etag: readResp.ETag}
+	for i, c := range readResp.Contents {
+		if c.URI == "" {
+			c.URI = uri
+		}
+		contents, err := p.resourceContents(&c, p.cfg.Resources.MaxSize-entry.size)
+		if err != nil {
+			if err == errPayloadTooLarge {
+				p.errorCounts.add(errorClassOversized)
+				return nil, errors.E(op, errors.Errorf("resource %s exceeds resources.max_size (%d bytes)", uri, p.cfg.Resources.MaxSize))
+			}
+			return nil, errors.E(op, fmt.Errorf("content %d: %w", i, err))
+		}
+		if contents.MIMEType == "" {
+			contents.MIMEType = p.resourceMimeType(uri)
+		}
+		entry.contents = append(entry.contents, contents)
+		entry.size += int64(len(contents.Text) + len(contents.Blob))
+	}
+
+	if readResp.ETag != "" {
+		p.resourceCache.put(uri, entry, version)
+	}
+
+	return entry.result(), nil
+}
+
+// resourceContents converts contents returned by PHP, reading the blob of a
+// file under resources.file_root. Contents larger than limit fail with
+// errPayloadTooLarge.
+func (p *Plugin) resourceContents(c *ResourceContent, limit int64) (*mcp.ResourceContents, error) {
+	if c.File == "" {
+		contents, err := convertResource(MCPContent{Resource: c})
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(contents.Text)+len(contents.Blob)) > limit {
+			return nil, errPayloadTooLarge
+		}
+		return contents, nil
+	}
+
+	if c.Text != "" || c.Blob != "" {
+		return nil, errors.Str("file cannot be combined with text or blob")
+	}
+
+	blob, err := readResourceFile(p.cfg.Resources.FileRoot, c.File, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	mimeType := c.MimeType
+	if mimeType == "" {
+		mimeType = mime.TypeByExtension(filepath.Ext(c.File))
+	}
+
+	return &mcp.ResourceContents{URI: c.URI, MIMEType: mimeType, Blob: blob}, nil
+}
+
+// readResourceFile reads a file below root, relative to it or absolute
+func readResourceFile(root, path string, limit int64) ([]byte, error) {
+	f, err := openResourceFile(root, path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	if stat, err := f.Stat(); err == nil && stat.Size() > limit {
+		return nil, errPayloadTooLarge
+	}
+
+	blob, err := io.ReadAll(io.LimitReader(f, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(blob)) > limit {
+		return nil, errPayloadTooLarge
+	}
+
+	return blob, nil
+}
+
+// openResourceFile opens a file below root, relative to it or absolute
+func openResourceFile(root, path string) (*os.File, error) {
+	if root == "" {
+		return nil, errors.Str("file contents require resources.file_root")
+	}
+
+	if filepath.IsAbs(path) {
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return nil, fmt.Errorf("file %q is outside resources.file_root", path)
+		}
+		path = rel
+	}
+
+	// Opened through the root, paths escaping it fail
+	dir, err := os.OpenRoot(root)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = dir.Close() }()
+
+	return dir.Open(path)
+}
+
+// resourceMimeType returns the declared MIME type of a resource
+func (p *Plugin) resourceMimeType(uri string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if def, ok := p.resources[uri]; ok {
+		return def.MimeType
+	}
+	return ""
+}
+
+// cachedResource is the contents of a resource at one etag
+type cachedResource struct {
+	etag      string
+	contents  []*mcp.ResourceContents
+	size      int64
+	validated time.Time
+	used      time.Time
+}
+
+// result returns the contents as a read result, copied as the SDK and the
+// result middleware change them in place
+func (r *cachedResource) result() *mcp.ReadResourceResult {
+	contents := make([]*mcp.ResourceContents, len(r.contents))
+	for i, c := range r.contents {
+		cp := *c
+		contents[i] = &cp
+	}
+	return &mcp.ReadResourceResult{Contents: contents}
+}
+
+// resourceCache keeps resource contents by URI within a total size. Reads
+// started before an invalidation do not cache what they read.
+type resourceCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	limit   int64
+	size    int64
+	version uint64 // incremented by every invalidation
+	entries map[string]*cachedResource
+}
+
+func newResourceCache(ttl time.Duration, limit int64) *resourceCache {
+	return &resourceCache{ttl: ttl, limit: limit, entries: make(map[string]*cachedResource)}
+}
+
+// get returns the cached contents of a resource, whether they can be served
+// without asking PHP, and the cache version to store the next read with
+func (c *resourceCache) get(uri string) (*cachedResource, bool, uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[uri]
+	if !ok {
+		return nil, false, c.version
+	}
+
+	now := time.Now()
+	entry.used = now
+	return entry, c.ttl == 0 || now.Sub(entry.validated) < c.ttl, c.version
+}
+
+// put caches the contents read at version, the least recently used entries
+// make room for them
+func (c *resourceCache) put(uri string, entry *cachedResource, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.limit <= 0 || entry.size > c.limit || version != c.version {
+		return
+	}
+
+	c.remove(uri)
+	for c.size+entry.size > c.limit {
+		var oldest string
+		for key, e := range c.entries {
+			if oldest == "" || e.used.Before(c.entries[oldest].used) {
+				oldest = key
+			}
+		}
+		c.remove(oldest)
+	}
+
+	now := time.Now()
+	entry.validated, entry.used = now, now
+	c.entries[uri] = entry
+	c.size += entry.size
+}
+
+// revalidate marks cached contents as current again
+func (c *resourceCache) revalidate(uri string, entry *cachedResource, version uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if version == c.version && c.entries[uri] == entry {
+		entry.validated = time.Now()
+	}
+}
+
+// invalidate drops the cached contents of a resource
+func (c *resourceCache) invalidate(uri string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.version++
+	c.remove(uri)
+}
+
+// remove drops an entry, must be called under lock
+func (c *resourceCache) remove(uri string) {
+	if entry, ok := c.entries[uri]; ok {
+		c.size -= entry.size
+		delete(c.entries, uri)
+	}
+}
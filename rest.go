@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// Transports of sessions running over in-memory connections
+const (
+	transportREST   = "rest"
+	transportMemory = "memory"
+)
+
+// serveREST invokes a registered tool with the JSON request body as arguments.
+// Each request runs through a local session, so it is authenticated and
+// handled exactly like a tool call from an MCP client.
+func (p *Plugin) serveREST(w http.ResponseWriter, r *http.Request) {
+	if !p.authorizeRequest(w, r, "", transportREST) {
+		return
+	}
+
+	name := r.PathValue("name")
+
+	// Authenticated before anything about the tool is revealed
+	cs, closeSession, err := p.connectLocal(r.Context(), transportREST, p.requestCredentials(r))
+	if err != nil {
+		p.log.Warn("REST request rejected",
+			zap.String("tool", name),
+			zap.Error(err),
+		)
+		writeJSONError(w, http.StatusUnauthorized, "authentication failed")
+		return
+	}
+	defer closeSession()
+
+	// The body is limited by limits.max_request_size
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			p.errorCounts.add(errorClassOversized)
+			writeJSONError(w, http.StatusRequestEntityTooLarge, fmt.Sprintf("request body exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+		writeJSONError(w, http.StatusBadRequest, "failed to read request body")
+		return
+	}
+
+	var arguments json.RawMessage
+	if len(body) > 0 {
+		var object map[string]json.RawMessage
+		if err := json.Unmarshal(body, &object); err != nil {
+			writeJSONError(w, http.StatusBadRequest, "request body must be a JSON object")
+			return
+		}
+		arguments = body
+	}
+
+	params := &mcp.CallToolParams{Name: name}
+	if requestID := r.Header.Get(headerRequestID); requestID != "" {
+		params.Meta = mcp.Meta{metaRequestID: requestID}
+	}
+	for name, value := range traceContext(r.Header.Get) {
+		if params.Meta == nil {
+			params.Meta = mcp.Meta{}
+		}
+		params.Meta[name] = value
+	}
+	if arguments != nil {
+		params.Arguments = arguments
+	}
+
+	// Tenant tools and aliases are resolved like for MCP clients
+	result, err := cs.CallTool(r.Context(), params)
+	if err != nil {
+		if unknownTool(err) {
+			writeJSONError(w, http.StatusNotFound, "tool not found")
+			return
+		}
+		writeCallError(w, err)
+		return
+	}
+
+	status := http.StatusOK
+	if result.IsError {
+		status = http.StatusUnprocessableEntity
+	}
+
+	writeJSON(w, status, result)
+}
+
+// connectLocal opens an in-memory MCP session against the plugin's own server,
+// it is tracked, authenticated and handled like any client session
+func (p *Plugin) connectLocal(ctx context.Context, transport string, credentials map[string]string) (*mcp.ClientSession, func(), error) {
+	return p.connectLocalSession(ctx, uuid.New().String(), transport, credentials)
+}
+
+// connectLocalSession is connectLocal with the ID of the session given
+func (p *Plugin) connectLocalSession(ctx context.Context, sessionID, transport string, credentials map[string]string) (*mcp.ClientSession, func(), error) {
+	p.trackSession(sessionID, transport, credentials, nil)
+
+	serverTransport, clientTransport := mcp.NewInMemoryTransports()
+
+	ss, err := p.connServer(sessionID).Connect(withSessionID(ctx, sessionID), serverTransport, nil)
+	if err != nil {
+		p.removeSession(sessionID)
+		return nil, nil, fmt.Errorf("failed to connect %s session: %w", transport, err)
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{Name: transport, Version: p.cfg.Server.Version}, nil)
+
+	cs, err := client.Connect(ctx, clientTransport, nil)
+	if err != nil {
+		_ = ss.Close()
+		p.removeSession(sessionID)
+		return nil, nil, err
+	}
+
+	return cs, func() {
+		_ = cs.Close()
+		_ = ss.Close()
+		p.removeSession(sessionID)
+	}, nil
+}
+
+// unknownTool reports whether a failed tools/call named a tool the session
+// cannot call, the SDK's wire error type is internal and read from JSON
+func unknownTool(err error) bool {
+	for ; err != nil; err = errors.Unwrap(err) {
+		raw, marshalErr := json.Marshal(err)
+		if marshalErr != nil {
+			continue
+		}
+
+		var wire ToolError
+		if json.Unmarshal(raw, &wire) == nil && wire.Code == codeInvalidParams && strings.HasPrefix(wire.Message, "unknown tool ") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+// writeJSONError writes a JSON error response
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, map[string]string{"error": message})
+}
+
+// writeCallError writes a failed tool call with the status and
+// classification of its error code
+func writeCallError(w http.ResponseWriter, err error) {
+	info := errorInfo(err)
+	if info == nil {
+		writeJSONError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	status := http.StatusBadRequest
+	if code := errorCodeByType(info.Type); code != nil {
+		status = code.Status
+	}
+
+	writeJSON(w, status, map[string]interface{}{
+		"error":     err.Error(),
+		"type":      info.Type,
+		"retryable": info.Retryable,
+	})
+}
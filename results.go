@@ -0,0 +1,197 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// Built-in result filters
+const (
+	ResultFilterRedactSecrets = "redact_secrets"
+	ResultFilterRedactPII     = "redact_pii"
+	ResultFilterTruncate      = "truncate"
+	ResultFilterSanitize      = "sanitize"
+)
+
+// ResultFilter is implemented by RoadRunner plugins transforming tool results
+// before they reach the client. Collected filters are applied when listed by
+// name in results.filters.
+type ResultFilter interface {
+	// Name returns the filter name referenced in results.filters
+	Name() string
+	// FilterResult returns the transformed result of a call to tool
+	FilterResult(ctx context.Context, tool string, result *mcp.CallToolResult) (*mcp.CallToolResult, error)
+}
+
+// secretPatterns match common credentials leaking into tool output
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`),
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`),
+	regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),
+	regexp.MustCompile(`\beyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`),
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]+=*`),
+	regexp.MustCompile(`(?i)\b(?:password|passwd|secret|api[_-]?key|access[_-]?token)\b["']?\s*[:=]\s*["']?[^\s"',;]+`),
+}
+
+// resultFilterMiddleware passes tool results through the configured filters
+func (p *Plugin) resultFilterMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok || len(p.resultFilters) == 0 {
+			return next(ctx, method, req)
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		res, ok := result.(*mcp.CallToolResult)
+		if !ok {
+			return result, nil
+		}
+
+		for _, filter := range p.resultFilters {
+			res, err = filter.FilterResult(ctx, params.Name, res)
+			if err != nil {
+				p.callLogger(ctx).Warn("result filter failed",
+					zap.String("filter", filter.Name()),
+					zap.String("tool", params.Name),
+					zap.Error(err),
+				)
+				return nil, errors.Str("result filter failed")
+			}
+		}
+
+		return res, nil
+	}
+}
+
+// initResultFilters builds the result filter chain from the configuration
+func (p *Plugin) initResultFilters(collected []ResultFilter) error {
+	const op = errors.Op("mcp_init_result_filters")
+
+	available := make(map[string]ResultFilter, len(collected))
+	for _, filter := range collected {
+		available[filter.Name()] = filter
+	}
+
+	p.resultFilters = nil
+
+	for _, name := range p.cfg.Results.Filters {
+		switch name {
+		case ResultFilterRedactSecrets:
+			filter, err := p.newSecretRedactor()
+			if err != nil {
+				return errors.E(op, err)
+			}
+			p.resultFilters = append(p.resultFilters, filter)
+		case ResultFilterRedactPII:
+			p.resultFilters = append(p.resultFilters, &redactionFilter{name: ResultFilterRedactPII, redactor: p.redactor})
+		case ResultFilterTruncate:
+			p.resultFilters = append(p.resultFilters, &truncator{max: p.cfg.Results.MaxLength})
+		case ResultFilterSanitize:
+			p.resultFilters = append(p.resultFilters, p.newSanitizer())
+		default:
+			filter, ok := available[name]
+			if !ok {
+				return errors.E(op, errors.Errorf("unknown result filter %q", name))
+			}
+			p.resultFilters = append(p.resultFilters, filter)
+		}
+	}
+
+	if len(p.resultFilters) > 0 {
+		p.log.Debug("result filters enabled", zap.Strings("filters", p.cfg.Results.Filters))
+	}
+
+	return nil
+}
+
+// redactionFilter applies a redactor to text, embedded resources and
+// structured content of results
+type redactionFilter struct {
+	name     string
+	redactor *redactor
+}
+
+// newSecretRedactor creates the redact_secrets filter for the built-in and extra patterns
+func (p *Plugin) newSecretRedactor() (*redactionFilter, error) {
+	r := &redactor{
+		rules:       secretRules(ResultFilterRedactSecrets),
+		replacement: p.cfg.Results.RedactReplacement,
+		hits:        p.redactionHits,
+	}
+
+	for _, expr := range p.cfg.Results.RedactPatterns {
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", expr, err)
+		}
+		r.rules = append(r.rules, &redactionRule{name: ResultFilterRedactSecrets, pattern: re})
+	}
+
+	return &redactionFilter{name: ResultFilterRedactSecrets, redactor: r}, nil
+}
+
+func (f *redactionFilter) Name() string {
+	return f.name
+}
+
+func (f *redactionFilter) FilterResult(_ context.Context, _ string, result *mcp.CallToolResult) (*mcp.CallToolResult, error) {
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case *mcp.TextContent:
+			c.Text = f.redactor.redact(c.Text, redactionTargetResult)
+		case *mcp.EmbeddedResource:
+			if c.Resource != nil {
+				c.Resource.Text = f.redactor.redact(c.Resource.Text, redactionTargetResult)
+			}
+		}
+	}
+
+	if result.StructuredContent != nil {
+		data, err := json.Marshal(result.StructuredContent)
+		if err != nil {
+			return nil, err
+		}
+
+		var structured interface{}
+		if err := json.Unmarshal(data, &structured); err != nil {
+			return nil, err
+		}
+
+		result.StructuredContent = f.redactor.redactValue(structured, redactionTargetResult)
+	}
+
+	return result, nil
+}
+
+// truncator shortens text content exceeding max bytes
+type truncator struct {
+	max int
+}
+
+func (t *truncator) Name() string {
+	return ResultFilterTruncate
+}
+
+func (t *truncator) FilterResult(_ context.Context, _ string, result *mcp.CallToolResult) (*mcp.CallToolResult, error) {
+	for _, content := range result.Content {
+		if c, ok := content.(*mcp.TextContent); ok && len(c.Text) > t.max {
+			omitted := len(c.Text) - t.max
+			c.Text = strings.ToValidUTF8(c.Text[:t.max], "") + fmt.Sprintf("\n... [truncated %d bytes]", omitted)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,130 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// truncatedMetaKey is the _meta key telling clients a result was cut to
+// tools.max_result_bytes
+const truncatedMetaKey = "truncated"
+
+// resultSizeMiddleware spills or cuts tool results larger than
+// tools.max_result_bytes, after the result filters ran and before the call
+// is recorded
+func (p *Plugin) resultSizeMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok || p.cfg.Tools.MaxResultBytes <= 0 {
+			return next(ctx, method, req)
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		res, ok := result.(*mcp.CallToolResult)
+		if !ok {
+			return result, nil
+		}
+
+		size := resultSize(res)
+		if size <= p.cfg.Tools.MaxResultBytes {
+			return res, nil
+		}
+
+		if p.spill != nil && p.spillResult(ctx, params.Name, res, size) {
+			return res, nil
+		}
+
+		truncateResult(res, p.cfg.Tools.MaxResultBytes)
+
+		if res.Meta == nil {
+			res.Meta = mcp.Meta{}
+		}
+		res.Meta[truncatedMetaKey] = map[string]interface{}{
+			"originalBytes": size,
+			"maxBytes":      p.cfg.Tools.MaxResultBytes,
+		}
+
+		p.callLogger(ctx).Warn("tool result truncated",
+			zap.String("tool", params.Name),
+			zap.String("session_id", sessionIDFromContext(ctx)),
+			zap.Int("bytes", size),
+			zap.Int("max_bytes", p.cfg.Tools.MaxResultBytes),
+		)
+
+		return res, nil
+	}
+}
+
+// resultSize returns the size of the content of a result as sent to the
+// client, binary data counted base64 encoded. Structured content must match
+// the output schema and is not limited.
+func resultSize(res *mcp.CallToolResult) int {
+	size := 0
+	for _, content := range res.Content {
+		size += contentSize(content)
+	}
+	return size
+}
+
+func contentSize(content mcp.Content) int {
+	switch c := content.(type) {
+	case *mcp.TextContent:
+		return len(c.Text)
+	case *mcp.ImageContent:
+		return base64.StdEncoding.EncodedLen(len(c.Data))
+	case *mcp.AudioContent:
+		return base64.StdEncoding.EncodedLen(len(c.Data))
+	case *mcp.EmbeddedResource:
+		if c.Resource != nil {
+			return len(c.Resource.Text) + base64.StdEncoding.EncodedLen(len(c.Resource.Blob))
+		}
+	case *mcp.ResourceLink:
+		return len(c.URI)
+	}
+	return 0
+}
+
+// truncateResult keeps the content fitting into max bytes in order: the
+// text block crossing the limit is cut with a marker, the blocks after it
+// and binary blocks crossing it are replaced by a marker
+func truncateResult(res *mcp.CallToolResult, max int) {
+	kept := make([]mcp.Content, 0, len(res.Content))
+	remaining := max
+	omitted := 0
+
+	for i, content := range res.Content {
+		size := contentSize(content)
+		if size <= remaining {
+			kept = append(kept, content)
+			remaining -= size
+			continue
+		}
+
+		if c, ok := content.(*mcp.TextContent); ok && remaining > 0 {
+			c.Text = strings.ToValidUTF8(c.Text[:remaining], "") + fmt.Sprintf("\n... [truncated %d bytes]", size-remaining)
+			kept = append(kept, c)
+		} else {
+			omitted += size
+		}
+
+		for _, rest := range res.Content[i+1:] {
+			omitted += contentSize(rest)
+		}
+		break
+	}
+
+	if omitted > 0 {
+		kept = append(kept, &mcp.TextContent{Text: fmt.Sprintf("... [omitted %d bytes of content]", omitted)})
+	}
+
+	res.Content = kept
+}
@@ -0,0 +1,180 @@
+package mcp
+
+import (
+	"context"
+	"crypto/subtle"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// headerResumeKey is sent by clients on the SSE GET to resume an earlier
+// session after a reconnect
+const headerResumeKey = "X-MCP-Resume-Key"
+
+// parkedNotification is a notification buffered for a disconnected session
+type parkedNotification struct {
+	method string
+	send   func(ctx context.Context, ss *mcp.ServerSession) error
+}
+
+// parkedSession keeps the notifications of a disconnected session until the
+// client reattaches with the same resume key or the grace period ends
+type parkedSession struct {
+	sessionID string
+	token     string
+	tenant    string
+	server    string
+	pending   []*parkedNotification
+	timer     *time.Timer
+}
+
+// resumeBuffers holds the sessions in their reconnect grace period
+type resumeBuffers struct {
+	mu     sync.Mutex
+	parked map[string]*parkedSession // resume key -> parked session
+}
+
+func newResumeBuffers() *resumeBuffers {
+	return &resumeBuffers{parked: make(map[string]*parkedSession)}
+}
+
+// parkSession starts the grace period of a disconnected session, must be
+// called under lock
+func (p *Plugin) parkSession(info *SessionInfo) {
+	if p.cfg.Clients.ResumeGrace <= 0 || info.ResumeKey == "" || info.Session == nil {
+		return
+	}
+
+	r := p.resumes
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if old, ok := r.parked[info.ResumeKey]; ok {
+		old.timer.Stop()
+	}
+
+	key := info.ResumeKey
+	parked := &parkedSession{
+		sessionID: info.ID,
+		token:     info.Credentials["token"],
+		tenant:    info.Tenant,
+		server:    info.Server,
+	}
+	parked.timer = time.AfterFunc(p.cfg.Clients.ResumeGrace, func() {
+		p.expireParked(key, parked)
+	})
+	r.parked[key] = parked
+
+	p.log.Debug("session parked for resume",
+		zap.String("session_id", info.ID),
+		zap.Duration("grace", p.cfg.Clients.ResumeGrace),
+	)
+}
+
+// expireParked drops a parked session whose grace period ended
+func (p *Plugin) expireParked(key string, parked *parkedSession) {
+	r := p.resumes
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.parked[key] != parked {
+		return
+	}
+	delete(r.parked, key)
+
+	p.log.Debug("resume grace period ended",
+		zap.String("session_id", parked.sessionID),
+		zap.Int("discarded", len(parked.pending)),
+	)
+}
+
+// bufferNotification buffers a notification for the parked sessions
+// matching the selection, all of them when sessionIDs is empty, and returns
+// the number of sessions it was buffered for. A tool list change pending
+// for a session is not buffered twice.
+func (p *Plugin) bufferNotification(sessionIDs []string, method string, send func(ctx context.Context, ss *mcp.ServerSession) error) int {
+	r := p.resumes
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	buffered := 0
+	for _, parked := range r.parked {
+		if len(sessionIDs) > 0 && !slices.Contains(sessionIDs, parked.sessionID) {
+			continue
+		}
+		buffered++
+
+		if method == notificationToolListChanged && parked.hasPending(method) {
+			continue
+		}
+
+		if len(parked.pending) >= p.cfg.Clients.ResumeBuffer {
+			oldest := parked.pending[0]
+			parked.pending[0] = nil
+			parked.pending = parked.pending[1:]
+			p.notifications.countDropped(oldest.method, OverflowDropOldest)
+		}
+		parked.pending = append(parked.pending, &parkedNotification{method: method, send: send})
+	}
+
+	return buffered
+}
+
+// hasPending reports whether a notification of the method is buffered
+func (s *parkedSession) hasPending(method string) bool {
+	for _, n := range s.pending {
+		if n.method == method {
+			return true
+		}
+	}
+	return false
+}
+
+// resumeSession delivers the notifications buffered under the resume key of
+// a session that completed the handshake. The key only resumes a session of
+// the same token, tenant and server.
+func (p *Plugin) resumeSession(info *SessionInfo) {
+	p.mu.RLock()
+	key, ss := info.ResumeKey, info.Session
+	token, tenant, server := info.Credentials["token"], info.Tenant, info.Server
+	p.mu.RUnlock()
+
+	if key == "" || ss == nil {
+		return
+	}
+
+	r := p.resumes
+	r.mu.Lock()
+	parked, ok := r.parked[key]
+	if !ok {
+		r.mu.Unlock()
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(parked.token), []byte(token)) != 1 || parked.tenant != tenant || parked.server != server {
+		r.mu.Unlock()
+		p.log.Warn("resume key rejected",
+			zap.String("session_id", info.ID),
+			zap.String("resumed_session_id", parked.sessionID),
+		)
+		return
+	}
+	parked.timer.Stop()
+	delete(r.parked, key)
+	r.mu.Unlock()
+
+	for _, n := range parked.pending {
+		p.enqueueNotification(ss, n.method, func(ctx context.Context) error {
+			return n.send(ctx, ss)
+		})
+	}
+
+	p.log.Info("session resumed",
+		zap.String("session_id", info.ID),
+		zap.String("resumed_session_id", parked.sessionID),
+		zap.Int("notifications", len(parked.pending)),
+	)
+}
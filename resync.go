@@ -0,0 +1,165 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// resyncTimeout bounds a ListTools round trip
+const resyncTimeout = 30 * time.Second
+
+// watchWorkers discovers tools once, then again whenever a worker of the main
+// pool is replaced (max_jobs, TTL, supervisor kills, resets). A debug pool
+// replaces its workers on every event and is re-synced on every tick.
+func (p *Plugin) watchWorkers() {
+	if err := p.resyncTools(); err != nil {
+		p.log.Warn("tool discovery failed", zap.Error(err))
+	}
+
+	known := p.workerPids()
+
+	ticker := time.NewTicker(p.cfg.Tools.ResyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		pids := p.workerPids()
+		replaced := p.cfg.debugPool()
+		for pid := range pids {
+			if !known[pid] {
+				replaced = true
+				break
+			}
+		}
+		known = pids
+
+		if !replaced {
+			continue
+		}
+
+		p.log.Debug("workers replaced, re-syncing tools")
+		if err := p.resyncTools(); err != nil {
+			p.log.Warn("tool re-sync failed", zap.Error(err))
+		}
+	}
+}
+
+// workerPids returns the PIDs of the main pool's workers
+func (p *Plugin) workerPids() map[int64]bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	pids := make(map[int64]bool)
+	if p.pool == nil {
+		return pids
+	}
+	for _, w := range p.pool.Workers() {
+		pids[w.Pid()] = true
+	}
+	return pids
+}
+
+// resyncTools asks a worker for its tools and applies the difference: changed
+// tools are re-registered and tools missing from a listed namespace removed
+func (p *Plugin) resyncTools() error {
+	const op = errors.Op("mcp_resync_tools")
+
+	ctx, cancel := context.WithTimeout(p.ctx, resyncTimeout)
+	defer cancel()
+
+	body, err := p.sendEvent(ctx, "", EventListTools, struct{}{})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	var listed ListToolsResponse
+	if err := json.Unmarshal(body, &listed); err != nil {
+		return errors.E(op, errors.Errorf("invalid worker response: %v", err))
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// Unchanged tools keep their registration
+	declared := make(map[string]bool)
+	changed := make([]*DeclareToolsRequest, 0, len(listed.Declarations))
+	for _, req := range listed.Declarations {
+		if req == nil {
+			continue
+		}
+
+		tools := make([]ToolDefinition, 0, len(req.Tools))
+		for _, toolDef := range req.Tools {
+			name := tenantName(req.Tenant, p.qualifiedToolName(req.Namespace, toolDef.Name))
+			declared[name] = true
+			if !p.toolUnchanged(name, req, toolDef) {
+				tools = append(tools, toolDef)
+			}
+		}
+
+		changed = append(changed, &DeclareToolsRequest{
+			Namespace: req.Namespace,
+			Tenant:    req.Tenant,
+			Tools:     tools,
+			Force:     req.Force,
+		})
+	}
+
+	resp := &DeclareToolsResponse{Registered: []string{}, Updated: []string{}}
+	if err := p.applyDeclarations(changed, resp); err != nil {
+		return errors.E(op, err)
+	}
+
+	// Tools dropped from a listed namespace are gone from the new code
+	var removed []string
+	for name, entry := range p.tools {
+		if entry.Source != ToolSourcePHP || declared[name] || !listsNamespace(listed.Declarations, entry.Namespace, entry.Tenant) {
+			continue
+		}
+		p.deleteTool(name)
+		removed = append(removed, name)
+	}
+
+	p.log.Info("tools re-synced",
+		zap.Strings("registered", resp.Registered),
+		zap.Strings("updated", resp.Updated),
+		zap.Strings("removed", removed),
+		zap.Int("conflicts", len(resp.Conflicts)),
+	)
+
+	if p.cfg.Tools.NotifyClientsOnChange && len(resp.Registered)+len(resp.Updated)+len(removed) > 0 {
+		p.notifyToolsChanged()
+	}
+
+	return nil
+}
+
+// toolUnchanged reports whether a listed tool matches its registration, must be called under lock
+func (p *Plugin) toolUnchanged(name string, req *DeclareToolsRequest, toolDef ToolDefinition) bool {
+	entry, ok := p.tools[name]
+	if !ok || entry.Source != ToolSourcePHP {
+		return false
+	}
+
+	hash := declarationHash(req.Namespace, toolDef)
+	return hash != "" && entry.Hash == hash
+}
+
+// listsNamespace reports whether declarations cover a namespace of a tenant
+func listsNamespace(declarations []*DeclareToolsRequest, namespace, tenant string) bool {
+	for _, req := range declarations {
+		if req != nil && req.Namespace == namespace && req.Tenant == tenant {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,69 @@
+package mcp
+
+import (
+	"context"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// rootsListTimeout bounds how long a client may take to list its roots
+const rootsListTimeout = 10 * time.Second
+
+// rootsChanged lists the roots of a client that reported a change and sends
+// them to PHP with the RootsChanged event. Refreshes of a session run one at
+// a time and list the roots once they run, so PHP receives the current list
+// last.
+func (p *Plugin) rootsChanged(ctx context.Context, req *mcp.RootsListChangedRequest) {
+	if p.cfg.Mode == ModeMock {
+		return
+	}
+
+	ss := req.Session
+	sessionID := p.callSessionID(ctx, ss)
+
+	p.mu.RLock()
+	info, ok := p.sessions.get(sessionID)
+	p.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	// The answer to roots/list arrives on the connection this notification
+	// came from, so the client is asked outside of its handler
+	go func() {
+		info.rootsMu.Lock()
+		defer info.rootsMu.Unlock()
+
+		listCtx, cancel := context.WithTimeout(withSessionID(p.ctx, sessionID), rootsListTimeout)
+		res, err := ss.ListRoots(listCtx, nil)
+		cancel()
+		if err != nil {
+			p.log.Warn("failed to list client roots",
+				zap.String("session_id", sessionID),
+				zap.Error(err),
+			)
+			return
+		}
+
+		payloadData := &RootsChangedPayload{SessionID: sessionID, Roots: res.Roots}
+		if payloadData.Roots == nil {
+			payloadData.Roots = []*mcp.Root{}
+		}
+
+		if _, err := p.sendEvent(withSessionID(p.ctx, sessionID), sessionID, EventRootsChanged, payloadData); err != nil {
+			p.log.Warn("failed to notify worker",
+				zap.String("event", EventRootsChanged),
+				zap.String("session_id", sessionID),
+				zap.Error(err),
+			)
+			return
+		}
+
+		p.log.Debug("client roots changed",
+			zap.String("session_id", sessionID),
+			zap.Int("roots", len(res.Roots)),
+		)
+	}()
+}
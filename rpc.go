@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/modelcontextprotocol/go-sdk/mcp"
@@ -11,6 +12,12 @@ import (
 	"go.uber.org/zap"
 )
 
+// declarationBatchTTL bounds how long staged DeclareTools chunks wait for the final one
+const declarationBatchTTL = 5 * time.Minute
+
+// notificationToolListChanged is sent when the tool registry changes
+const notificationToolListChanged = "notifications/tools/list_changed"
+
 // rpcService exposes RPC methods to PHP workers
 type rpcService struct {
 	plugin *Plugin
@@ -26,45 +33,425 @@ func (s *rpcService) DeclareTools(req *DeclareToolsRequest, resp *DeclareToolsRe
 	resp.Registered = []string{}
 	resp.Updated = []string{}
 
+	s.plugin.expireDeclarationBatches()
+
+	// Intermediate chunks are staged until the final one arrives
+	if req.Batch != "" && !req.Final {
+		batch, ok := s.plugin.batches[req.Batch]
+		if !ok {
+			batch = &declarationBatch{StartedAt: time.Now()}
+			s.plugin.batches[req.Batch] = batch
+		}
+		batch.Requests = append(batch.Requests, req)
+
+		for _, toolDef := range req.Tools {
+			resp.Staged = append(resp.Staged, tenantName(req.Tenant, s.plugin.qualifiedToolName(req.Namespace, toolDef.Name)))
+		}
+
+		s.plugin.log.Debug("tool declaration chunk staged",
+			zap.String("batch", req.Batch),
+			zap.Int("tools", len(req.Tools)),
+		)
+
+		return nil
+	}
+
+	requests := []*DeclareToolsRequest{req}
+	if batch, ok := s.plugin.batches[req.Batch]; ok {
+		requests = append(batch.Requests, req)
+		delete(s.plugin.batches, req.Batch)
+	}
+
+	if err := s.plugin.applyDeclarations(requests, resp); err != nil {
+		return errors.E(op, err)
+	}
+
+	// Notify clients if configured
+	if s.plugin.cfg.Tools.NotifyClientsOnChange && len(resp.Registered)+len(resp.Updated) > 0 {
+		s.plugin.notifyToolsChanged()
+	}
+
+	return nil
+}
+
+// ValidateTools checks a declaration like DeclareTools does without
+// registering anything, chunks staged for its batch are checked with it
+func (s *rpcService) ValidateTools(req *DeclareToolsRequest, resp *ValidateToolsResponse) error {
+	s.plugin.mu.RLock()
+	defer s.plugin.mu.RUnlock()
+
+	requests := []*DeclareToolsRequest{req}
+	if batch, ok := s.plugin.batches[req.Batch]; ok && req.Batch != "" {
+		requests = append(append([]*DeclareToolsRequest{}, batch.Requests...), req)
+	}
+
+	*resp = *s.plugin.dryRunDeclarations(requests)
+	return nil
+}
+
+// applyDeclarations validates and registers declarations as a whole, must be called under lock
+func (p *Plugin) applyDeclarations(requests []*DeclareToolsRequest, resp *DeclareToolsResponse) error {
+	if err := p.validateDeclarations(requests, false); err != nil {
+		return err
+	}
+
+	for _, r := range requests {
+		p.declareTools(r, resp)
+	}
+
+	return nil
+}
+
+// validateDeclarations checks declarations against each other and the
+// registry, replacing ignores the tools declared by PHP as they are about to
+// be replaced. Must be called under lock.
+func (p *Plugin) validateDeclarations(requests []*DeclareToolsRequest, replacing bool) error {
+	for _, d := range p.diagnoseDeclarations(requests, replacing) {
+		if d.Severity == SeverityError {
+			return errors.Str(d.Message)
+		}
+	}
+
+	return nil
+}
+
+// diagnoseDeclarations lists the problems of declarations, a single error
+// rejects the whole declaration. Must be called under lock.
+func (p *Plugin) diagnoseDeclarations(requests []*DeclareToolsRequest, replacing bool) []ToolDiagnostic {
+	var diagnostics []ToolDiagnostic
+	report := func(tool, severity, code, field, format string, args ...interface{}) {
+		diagnostics = append(diagnostics, ToolDiagnostic{
+			Tool:     tool,
+			Severity: severity,
+			Code:     code,
+			Field:    field,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Names owned by another namespace reject the whole declaration
+	owners := make(map[string]string)
+	aliases := make(map[string]string)
+	for _, r := range requests {
+		for _, toolDef := range r.Tools {
+			name := tenantName(r.Tenant, p.qualifiedToolName(r.Namespace, toolDef.Name))
+			if toolDef.Name == "" {
+				report(name, SeverityError, "invalid_name", "name", "tool name is required (namespace %q)", r.Namespace)
+				continue
+			}
+
+			if entry, exists := p.tools[name]; exists && entry.Source != ToolSourcePHP {
+				report(name, SeverityError, "name_taken", "name", "tool %q is already registered by %s %q", name, entry.Source, entry.Namespace)
+			} else if exists && !replacing && entry.Namespace != r.Namespace {
+				report(name, SeverityError, "name_taken", "name", "tool %q is already registered by namespace %q", name, entry.Namespace)
+			}
+			if owner, seen := owners[name]; seen && owner != r.Namespace {
+				report(name, SeverityError, "duplicate_tool", "name", "tool %q is declared by namespaces %q and %q", name, owner, r.Namespace)
+			} else if seen {
+				report(name, SeverityWarning, "duplicate_tool", "name", "tool %q is declared more than once, the last declaration wins", name)
+			}
+			owners[name] = r.Namespace
+
+			// The SDK only serves object schemas
+			if toolDef.InputSchema != nil && rootSchema(toolDef.InputSchema)["type"] != "object" {
+				report(name, SeverityError, "invalid_input_schema", "inputSchema", "tool %q: inputSchema must have type \"object\"", name)
+			}
+			if toolDef.OutputSchema != nil && rootSchema(toolDef.OutputSchema)["type"] != "object" {
+				report(name, SeverityError, "invalid_output_schema", "outputSchema", "tool %q: outputSchema must have type \"object\"", name)
+			}
+
+			if toolDef.Deprecated != nil {
+				if _, err := toolDef.Deprecated.disabledAt(); err != nil {
+					report(name, SeverityError, "invalid_deprecation", "deprecated.disableAfter", "tool %q: deprecated: %v", name, err)
+				}
+			}
+
+			target := p.qualifiedToolName(r.Namespace, toolDef.Name)
+			for _, alias := range toolDef.Aliases {
+				if alias == "" || alias == target {
+					report(name, SeverityError, "invalid_alias", "aliases", "tool %q: invalid alias %q", name, alias)
+					continue
+				}
+				key := tenantName(r.Tenant, alias)
+				current, ok := aliases[key]
+				if !ok && !replacing {
+					current, ok = p.aliases[key]
+				}
+				if ok && current != target {
+					report(name, SeverityError, "alias_conflict", "aliases", "tool %q: alias %q already routes to tool %q", name, alias, current)
+				}
+				aliases[key] = target
+			}
+		}
+	}
+
+	return diagnostics
+}
+
+// dryRunDeclarations reports what applying declarations would do, without
+// registering anything. Must be called under lock.
+func (p *Plugin) dryRunDeclarations(requests []*DeclareToolsRequest) *ValidateToolsResponse {
+	resp := &ValidateToolsResponse{
+		Registered:  []string{},
+		Updated:     []string{},
+		Diagnostics: p.diagnoseDeclarations(requests, false),
+	}
+
+	rejected := false
+	for _, d := range resp.Diagnostics {
+		if d.Severity == SeverityError {
+			rejected = true
+		}
+	}
+	resp.Valid = !rejected
+
+	declared := make(map[string]bool)
+	for _, r := range requests {
+		for _, toolDef := range r.Tools {
+			name := tenantName(r.Tenant, p.qualifiedToolName(r.Namespace, toolDef.Name))
+
+			// Models pick tools by their description
+			if toolDef.Name != "" && strings.TrimSpace(toolDef.Description) == "" {
+				resp.Diagnostics = append(resp.Diagnostics, ToolDiagnostic{
+					Tool:     name,
+					Severity: SeverityWarning,
+					Code:     "missing_description",
+					Field:    "description",
+					Message:  fmt.Sprintf("tool %q has no description", name),
+				})
+			}
+
+			hash := declarationHash(r.Namespace, toolDef)
+			diagnostics := p.checkToolSchemas(name, &toolDef)
+			resp.Diagnostics = append(resp.Diagnostics, diagnostics...)
+			if hasErrors(diagnostics) {
+				resp.Valid = false
+				continue
+			}
+
+			// A rejected declaration registers nothing
+			if rejected {
+				continue
+			}
+
+			current, exists := p.tools[name]
+			if exists && current.Source == ToolSourcePHP && hash != "" && current.Hash == hash {
+				resp.Unchanged = append(resp.Unchanged, name)
+				continue
+			}
+			if exists && !r.Force {
+				if changes := incompatibleChanges(current.Schema, toolDef.InputSchema); len(changes) > 0 {
+					resp.Conflicts = append(resp.Conflicts, ToolConflict{
+						Name:            name,
+						CurrentVersion:  current.Version,
+						DeclaredVersion: toolDef.Version,
+						Changes:         changes,
+					})
+					resp.Diagnostics = append(resp.Diagnostics, ToolDiagnostic{
+						Tool:     name,
+						Severity: SeverityError,
+						Code:     "incompatible_change",
+						Field:    "inputSchema",
+						Message:  fmt.Sprintf("tool %q would keep its registered schema, breaking changes need force: %s", name, strings.Join(changes, ", ")),
+					})
+					resp.Valid = false
+					continue
+				}
+			}
+
+			if exists || declared[name] {
+				resp.Updated = append(resp.Updated, name)
+			} else {
+				resp.Registered = append(resp.Registered, name)
+			}
+			declared[name] = true
+		}
+	}
+
+	return resp
+}
+
+// declareTools registers the tools of a single declaration, must be called under lock
+func (p *Plugin) declareTools(req *DeclareToolsRequest, resp *DeclareToolsResponse) {
 	for _, toolDef := range req.Tools {
+		name := tenantName(req.Tenant, p.qualifiedToolName(req.Namespace, toolDef.Name))
+
+		// Workers re-declare their tools on every boot, an identical
+		// declaration changes nothing and notifies nobody
+		hash := declarationHash(req.Namespace, toolDef)
+		if current, exists := p.tools[name]; exists && current.Source == ToolSourcePHP && hash != "" && current.Hash == hash {
+			resp.Unchanged = append(resp.Unchanged, name)
+			p.log.Debug("tool declaration unchanged", zap.String("tool", name))
+			continue
+		}
+
+		// A schema the SDK cannot serve only rejects its tool
+		diagnostics := p.checkToolSchemas(name, &toolDef)
+		resp.Diagnostics = append(resp.Diagnostics, diagnostics...)
+		if hasErrors(diagnostics) {
+			p.log.Warn("tool declaration rejected for its schema",
+				zap.String("tool", name),
+				zap.Int("diagnostics", len(diagnostics)),
+			)
+			continue
+		}
+
 		// Check if tool already exists
-		_, exists := s.plugin.tools[toolDef.Name]
+		current, exists := p.tools[name]
 
-		// Create MCP Tool structure
-		tool := &mcp.Tool{
-			Name:        toolDef.Name,
-			Description: toolDef.Description,
-			InputSchema: toolDef.InputSchema,
+		// Breaking schema changes need an explicit force
+		if exists && !req.Force {
+			if changes := incompatibleChanges(current.Schema, toolDef.InputSchema); len(changes) > 0 {
+				resp.Conflicts = append(resp.Conflicts, ToolConflict{
+					Name:            name,
+					CurrentVersion:  current.Version,
+					DeclaredVersion: toolDef.Version,
+					Changes:         changes,
+				})
+
+				p.log.Warn("tool declaration conflicts with registered schema",
+					zap.String("tool", name),
+					zap.Strings("changes", changes),
+				)
+				continue
+			}
 		}
 
+		// Create MCP Tool structure
+		tool := p.newTool(name, toolDef)
+
 		// Create handler that delegates to PHP
-		handler := s.plugin.createToolHandler(toolDef.Name)
+		handler := p.createToolHandler(toolDef.Name, req.Namespace, req.Tenant)
 
-		// Add tool to MCP server using AddTool function
-		mcp.AddTool(s.plugin.mcpServer, tool, handler)
+		// Add tool to the servers of all connections using the AddTool function
+		p.addFeature(featureTool+name,
+			func(s *mcp.Server) { mcp.AddTool(s, tool, handler) },
+			func(s *mcp.Server) { s.RemoveTools(name) },
+		)
 
 		// Update registry
-		s.plugin.tools[toolDef.Name] = tool
+		now := time.Now()
+		registeredAt := now
+		if exists {
+			registeredAt = current.RegisteredAt
+		}
+
+		entry := &toolEntry{
+			Tool:         tool,
+			Namespace:    req.Namespace,
+			Tenant:       req.Tenant,
+			Version:      toolDef.Version,
+			Schema:       toolDef.InputSchema,
+			Source:       ToolSourcePHP,
+			Deprecation:  toolDef.Deprecated,
+			Definition:   &toolDef,
+			Hash:         hash,
+			RegisteredAt: registeredAt,
+			UpdatedAt:    now,
+		}
+		if exists {
+			p.removeAliases(current)
+		}
+		p.setAliases(entry, req.Tenant, p.qualifiedToolName(req.Namespace, toolDef.Name), toolDef.Aliases)
+		p.setTool(name, entry)
 
 		// Track response
 		if exists {
-			resp.Updated = append(resp.Updated, toolDef.Name)
+			resp.Updated = append(resp.Updated, name)
 		} else {
-			resp.Registered = append(resp.Registered, toolDef.Name)
+			resp.Registered = append(resp.Registered, name)
 		}
 
-		s.plugin.log.Info("tool registered",
-			zap.String("tool", toolDef.Name),
+		p.publish(&BusEvent{
+			Event:     BusToolRegistered,
+			Tool:      name,
+			Source:    ToolSourcePHP,
+			Namespace: req.Namespace,
+			Tenant:    req.Tenant,
+			Updated:   exists,
+		})
+
+		p.log.Info("tool registered",
+			zap.String("tool", name),
+			zap.String("namespace", req.Namespace),
+			zap.String("tenant", req.Tenant),
+			zap.String("version", toolDef.Version),
 			zap.Bool("updated", exists),
 		)
 	}
+}
 
-	// Notify clients if configured
-	if s.plugin.cfg.Tools.NotifyClientsOnChange && len(resp.Registered)+len(resp.Updated) > 0 {
-		s.plugin.notifyToolsChanged()
+// expireDeclarationBatches drops batches whose final chunk never arrived, must be called under lock
+func (p *Plugin) expireDeclarationBatches() {
+	for id, batch := range p.batches {
+		if time.Since(batch.StartedAt) > declarationBatchTTL {
+			delete(p.batches, id)
+			p.log.Warn("tool declaration batch expired",
+				zap.String("batch", id),
+				zap.Int("chunks", len(batch.Requests)),
+			)
+		}
 	}
+}
 
-	return nil
+// qualifiedToolName joins the configured prefix, namespace and tool name
+func (p *Plugin) qualifiedToolName(namespace, name string) string {
+	parts := make([]string, 0, 3)
+	for _, part := range []string{p.cfg.Tools.Prefix, namespace, name} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+
+	return strings.Join(parts, "_")
+}
+
+// newTool builds the MCP tool for a PHP definition, applying config overrides
+func (p *Plugin) newTool(name string, def ToolDefinition) *mcp.Tool {
+	tool := &mcp.Tool{
+		Name:        name,
+		Title:       def.Title,
+		Description: def.Description,
+		InputSchema: def.InputSchema,
+		Annotations: def.Annotations,
+	}
+
+	if def.OutputSchema != nil {
+		tool.OutputSchema = def.OutputSchema
+	}
+
+	icons := def.Icons
+	deprecation := def.Deprecated
+	if override := p.toolOverride(name); override != nil {
+		if override.Title != "" {
+			tool.Title = override.Title
+		}
+		if len(override.Icons) > 0 {
+			icons = override.Icons
+		}
+		if override.Annotations != nil {
+			tool.Annotations = override.Annotations.apply(tool.Annotations)
+		}
+		if override.Deprecation != nil {
+			deprecation = override.Deprecation
+		}
+	}
+
+	// SDK tool has no icons field yet, expose them through _meta
+	if len(icons) > 0 {
+		tool.Meta = mcp.Meta{"icons": icons}
+	}
+
+	// Models pick tools by their description
+	if deprecation != nil {
+		tool.Description = deprecation.notice(name) + "\n\n" + tool.Description
+		if tool.Meta == nil {
+			tool.Meta = mcp.Meta{}
+		}
+		tool.Meta[deprecatedMetaKey] = deprecation
+	}
+
+	return tool
 }
 
 // RemoveTools removes tools from the registry
@@ -75,7 +462,7 @@ func (s *rpcService) RemoveTools(names []string, _ *struct{}) error {
 	defer s.plugin.mu.Unlock()
 
 	for _, name := range names {
-		delete(s.plugin.tools, name)
+		s.plugin.deleteTool(name)
 		s.plugin.log.Info("tool removed", zap.String("tool", name))
 	}
 
@@ -87,53 +474,612 @@ func (s *rpcService) RemoveTools(names []string, _ *struct{}) error {
 	return nil
 }
 
-// createToolHandler creates a tool handler that delegates execution to PHP workers
-func (p *Plugin) createToolHandler(toolName string) func(context.Context, *mcp.CallToolRequest, map[string]interface{}) (*mcp.CallToolResult, interface{}, error) {
-	return func(ctx context.Context, request *mcp.CallToolRequest, args map[string]interface{}) (*mcp.CallToolResult, interface{}, error) {
-		// Session ID from params (if available)
-		sessionID := "unknown"
-		if request.Params != nil && request.Params.Meta != nil {
-			if sid, ok := request.Params.Meta["sessionId"]; ok {
-				sessionID = fmt.Sprintf("%v", sid)
-			}
+// GetTools returns the current tool registry
+func (s *rpcService) GetTools(req *GetToolsRequest, resp *GetToolsResponse) error {
+	resp.Tools = s.plugin.toolInfos(req.Namespace, req.Tenant)
+	return nil
+}
+
+// toolInfos describes the registered tools, optionally filtered by namespace and tenant
+func (p *Plugin) toolInfos(namespace, tenant string) []ToolInfo {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	tools := make([]ToolInfo, 0, len(p.tools))
+
+	for _, name := range sortedKeys(p.tools) {
+		entry := p.tools[name]
+		if namespace != "" && entry.Namespace != namespace {
+			continue
+		}
+		if tenant != "" && entry.Tenant != tenant {
+			continue
 		}
 
-		p.log.Debug("tool execution requested",
-			zap.String("tool", toolName),
-			zap.String("session_id", sessionID),
-		)
+		tools = append(tools, ToolInfo{
+			Name:         name,
+			Title:        entry.Tool.Title,
+			Description:  entry.Tool.Description,
+			InputSchema:  entry.Schema,
+			Annotations:  entry.Tool.Annotations,
+			Namespace:    entry.Namespace,
+			Tenant:       entry.Tenant,
+			Version:      entry.Version,
+			Source:       entry.Source,
+			Hash:         entry.Hash,
+			RegisteredAt: entry.RegisteredAt,
+			UpdatedAt:    entry.UpdatedAt,
+		})
+	}
+
+	return tools
+}
+
+// DeclareResources registers resources read through the ReadResource event,
+// resources declared again replace the previous declaration
+func (s *rpcService) DeclareResources(req *DeclareResourcesRequest, resp *DeclareResourcesResponse) error {
+	const op = errors.Op("mcp_rpc_declare_resources")
+
+	s.plugin.mu.Lock()
+	defer s.plugin.mu.Unlock()
+
+	registered, err := s.plugin.declareResources(req.Resources)
+	if err != nil {
+		return errors.E(op, err)
+	}
+	resp.Registered = registered
+
+	s.plugin.log.Info("resources declared", zap.Int("count", len(registered)))
+
+	return nil
+}
+
+// RemoveResources removes resources declared by PHP
+func (s *rpcService) RemoveResources(uris []string, _ *struct{}) error {
+	s.plugin.mu.Lock()
+	defer s.plugin.mu.Unlock()
+
+	s.plugin.removeResources(uris)
+
+	return nil
+}
+
+// NotifyResourceUpdated drops the cached contents of a resource and tells
+// the sessions to read it again
+func (s *rpcService) NotifyResourceUpdated(req *NotifyResourceUpdatedRequest, resp *NotifyResourceUpdatedResponse) error {
+	const op = errors.Op("mcp_rpc_notify_resource_updated")
+
+	if req.URI == "" {
+		return errors.E(op, errors.Str("uri is required"))
+	}
+
+	resp.Delivered = s.plugin.broadcastNotification(&WebhookNotification{
+		Type:     webhookResourceUpdated,
+		Sessions: req.Sessions,
+		URI:      req.URI,
+	})
+
+	return nil
+}
+
+// SetInstructions replaces the instructions sent to clients on initialize
+func (s *rpcService) SetInstructions(req *SetInstructionsRequest, _ *struct{}) error {
+	s.plugin.mu.Lock()
+	defer s.plugin.mu.Unlock()
+
+	s.plugin.instructions = req.Instructions
+
+	s.plugin.log.Info("server instructions updated",
+		zap.Int("length", len(req.Instructions)),
+	)
+
+	return nil
+}
+
+// ExportDefinition returns the tools, resources and instructions PHP
+// declared as a single document
+func (s *rpcService) ExportDefinition(_ *struct{}, resp *ServerDefinition) error {
+	*resp = *s.plugin.exportDefinition()
+	return nil
+}
+
+// ImportDefinition atomically replaces the tools, resources and
+// instructions PHP declared with the ones of an exported document
+func (s *rpcService) ImportDefinition(req *ServerDefinition, resp *ImportDefinitionResponse) error {
+	const op = errors.Op("mcp_rpc_import_definition")
+
+	if err := s.plugin.importDefinition(req, resp); err != nil {
+		return errors.E(op, err)
+	}
+	return nil
+}
+
+// GetRecentCalls returns the recorded tool calls
+func (s *rpcService) GetRecentCalls(req *GetRecentCallsRequest, resp *GetRecentCallsResponse) error {
+	resp.Calls = s.plugin.recentCalls(req.Tool, req.Limit)
+	return nil
+}
+
+// ReplayCall re-executes a recorded tool call
+func (s *rpcService) ReplayCall(req *ReplayCallRequest, resp *ReplayCallResponse) error {
+	const op = errors.Op("mcp_rpc_replay_call")
+
+	result, err := s.plugin.replayCall(s.plugin.ctx, req.ID)
+	if err != nil {
+		// A classified call failure is reported with the response so PHP may retry
+		if info := errorInfo(err); info != nil {
+			resp.Error = info
+			return nil
+		}
+		return errors.E(op, err)
+	}
+
+	resp.Result = result
+
+	return nil
+}
+
+// ResetToolFilter drops cached FilterTools decisions and notifies the clients
+func (s *rpcService) ResetToolFilter(req *ResetToolFilterRequest, resp *ResetToolFilterResponse) error {
+	const op = errors.Op("mcp_rpc_reset_tool_filter")
+
+	if !s.plugin.cfg.Tools.Filter {
+		return errors.E(op, errors.Str("tools.filter is not enabled"))
+	}
+
+	resp.Sessions = s.plugin.resetToolFilter(req.SessionID)
+
+	return nil
+}
+
+// RevokeToken invalidates a client or session token and closes the sessions using it
+func (s *rpcService) RevokeToken(req *RevokeTokenRequest, resp *RevokeTokenResponse) error {
+	const op = errors.Op("mcp_rpc_revoke_token")
+
+	if req.Token == "" {
+		return errors.E(op, errors.Str("token is required"))
+	}
+
+	resp.Sessions = s.plugin.revokeToken(req.Token)
+	if resp.Sessions == nil {
+		resp.Sessions = []string{}
+	}
+
+	return nil
+}
+
+// RotateSessionToken replaces the token of a session and revokes the previous one
+func (s *rpcService) RotateSessionToken(req *RotateSessionTokenRequest, resp *RotateSessionTokenResponse) error {
+	const op = errors.Op("mcp_rpc_rotate_session_token")
+
+	token, err := s.plugin.rotateSessionToken(req.SessionID, req.Token)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp.Token = token
+
+	return nil
+}
+
+// SetSessionAttributes attaches application metadata to a session, sent
+// with its tool calls and logged with them
+func (s *rpcService) SetSessionAttributes(req *SetSessionAttributesRequest, resp *SetSessionAttributesResponse) error {
+	const op = errors.Op("mcp_rpc_set_session_attributes")
+
+	attributes, err := s.plugin.setSessionAttributes(req.SessionID, req.Attributes, req.Replace)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp.Attributes = attributes
+
+	return nil
+}
+
+// SessionStorageGet returns a value stored for a session
+func (s *rpcService) SessionStorageGet(req *SessionStorageRequest, resp *SessionStorageGetResponse) error {
+	const op = errors.Op("mcp_rpc_session_storage_get")
+
+	value, found, err := s.plugin.storageGet(req.SessionID, req.Key)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp.Value = value
+	resp.Found = found
+
+	return nil
+}
+
+// SessionStorageSet stores a value for a session until it disconnects
+func (s *rpcService) SessionStorageSet(req *SessionStorageSetRequest, resp *SessionStorageSetResponse) error {
+	const op = errors.Op("mcp_rpc_session_storage_set")
+
+	keys, bytes, err := s.plugin.storageSet(req.SessionID, req.Key, req.Value)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp.Keys = keys
+	resp.Bytes = bytes
+
+	return nil
+}
+
+// SessionStorageDelete removes a value stored for a session
+func (s *rpcService) SessionStorageDelete(req *SessionStorageRequest, resp *SessionStorageDeleteResponse) error {
+	const op = errors.Op("mcp_rpc_session_storage_delete")
+
+	deleted, err := s.plugin.storageDelete(req.SessionID, req.Key)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp.Deleted = deleted
+
+	return nil
+}
+
+// LoadTest runs a synthetic load test against a tool and reports latencies
+func (s *rpcService) LoadTest(req *LoadTestRequest, resp *LoadTestResponse) error {
+	const op = errors.Op("mcp_rpc_load_test")
+
+	result, err := s.plugin.loadTest(s.plugin.ctx, req)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	*resp = *result
+
+	return nil
+}
+
+// Ping sends EventPing to a worker and reports the round trip, it fails
+// unless a worker answered with a supported protocol version
+func (s *rpcService) Ping(req *WorkerPingRequest, resp *WorkerPingResponse) error {
+	const op = errors.Op("mcp_rpc_ping")
+
+	roundTrip, version, err := s.plugin.pingWorker(s.plugin.ctx, req.Timeout)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp.RoundTrip = roundTrip
+	resp.ProtocolVersion = version
+
+	return nil
+}
+
+// PoolStats reports the workers, their states, queue depth and memory usage
+// of every worker pool
+func (s *rpcService) PoolStats(_ *struct{}, resp *PoolStatsResponse) error {
+	resp.Pools = s.plugin.poolStats()
+
+	return nil
+}
+
+// ResetPool restarts the worker pools like rr reset, returning once the new
+// workers answer the readiness ping and tools are re-synced
+func (s *rpcService) ResetPool(req *ResetPoolRequest, resp *ResetPoolResponse) error {
+	const op = errors.Op("mcp_rpc_reset_pool")
+
+	if err := s.plugin.resetPools(req.Timeout, resp); err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// SessionStats counts the active sessions by transport with their average
+// age and how many are idle, e.g. for autoscaling decisions
+func (s *rpcService) SessionStats(req *SessionStatsRequest, resp *SessionStatsResponse) error {
+	*resp = *s.plugin.sessionStats(req.IdleAfter)
+
+	return nil
+}
+
+// SwapListener moves the transport listener to a new address or certificates
+func (s *rpcService) SwapListener(req *SwapListenerRequest, resp *SwapListenerResponse) error {
+	const op = errors.Op("mcp_rpc_swap_listener")
+
+	notified, err := s.plugin.swapListener(req.Address, req.TLS, req.Drain)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	s.plugin.mu.RLock()
+	resp.Address = s.plugin.listener.address
+	s.plugin.mu.RUnlock()
+	resp.Notified = notified
+
+	return nil
+}
+
+// ClientConnect opens a named connection to an external MCP server
+func (s *rpcService) ClientConnect(req *ClientConnectRequest, resp *ClientConnectResponse) error {
+	const op = errors.Op("mcp_rpc_client_connect")
+
+	if req.Name == "" {
+		return errors.E(op, errors.Str("name is required"))
+	}
+
+	// Workers share connections, connecting twice reuses the first one
+	if up, err := s.plugin.lookupClient(req.Name); err == nil {
+		resp.ServerInfo = up.session.InitializeResult().ServerInfo
+		resp.Reused = true
+		return nil
+	}
+
+	cfg := &UpstreamConfig{
+		Command:   req.Command,
+		Env:       req.Env,
+		URL:       req.URL,
+		Transport: req.Transport,
+		Headers:   req.Headers,
+	}
+
+	if req.Timeout != "" {
+		timeout, err := time.ParseDuration(req.Timeout)
+		if err != nil {
+			return errors.E(op, fmt.Errorf("invalid timeout: %w", err))
+		}
+		cfg.Timeout = timeout
+	}
+
+	cfg.InitDefaults(req.Name)
+	if err := cfg.Validate(); err != nil {
+		return errors.E(op, err)
+	}
+
+	up, err := s.plugin.connectClient(req.Name, cfg)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	s.plugin.mu.Lock()
+	if existing, ok := s.plugin.clients[req.Name]; ok {
+		// Lost a race with another worker
+		s.plugin.mu.Unlock()
+		_ = up.session.Close()
+		resp.ServerInfo = existing.session.InitializeResult().ServerInfo
+		resp.Reused = true
+		return nil
+	}
+	s.plugin.clients[req.Name] = up
+	s.plugin.mu.Unlock()
+
+	resp.ServerInfo = up.session.InitializeResult().ServerInfo
+
+	return nil
+}
+
+// ClientDisconnect closes a connection opened with ClientConnect
+func (s *rpcService) ClientDisconnect(req *ClientRequest, _ *struct{}) error {
+	const op = errors.Op("mcp_rpc_client_disconnect")
+
+	s.plugin.mu.Lock()
+	up, ok := s.plugin.clients[req.Name]
+	delete(s.plugin.clients, req.Name)
+	s.plugin.mu.Unlock()
+
+	if !ok {
+		return errors.E(op, errors.Errorf("no client connection named %q", req.Name))
+	}
+
+	if err := up.session.Close(); err != nil {
+		return errors.E(op, err)
+	}
+
+	s.plugin.log.Info("client connection closed", zap.String("name", req.Name))
+
+	return nil
+}
+
+// ClientListTools lists the tools of a connected server
+func (s *rpcService) ClientListTools(req *ClientRequest, resp *ClientListToolsResponse) error {
+	const op = errors.Op("mcp_rpc_client_list_tools")
+
+	up, err := s.plugin.lookupClient(req.Name)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	ctx, cancel := context.WithTimeout(s.plugin.ctx, up.cfg.Timeout)
+	defer cancel()
+
+	resp.Tools = []*mcp.Tool{}
+	for tool, err := range up.session.Tools(ctx, nil) {
+		if err != nil {
+			return errors.E(op, err)
+		}
+		resp.Tools = append(resp.Tools, tool)
+	}
+
+	return nil
+}
+
+// ClientCallTool calls a tool on a connected server
+func (s *rpcService) ClientCallTool(req *ClientCallToolRequest, resp *ClientCallToolResponse) error {
+	const op = errors.Op("mcp_rpc_client_call_tool")
+
+	up, err := s.plugin.lookupClient(req.Name)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	ctx, cancel := context.WithTimeout(s.plugin.ctx, up.cfg.Timeout)
+	defer cancel()
+
+	params := &mcp.CallToolParams{
+		Meta: req.Meta,
+		Name: req.Tool,
+	}
+	if len(req.Arguments) > 0 {
+		params.Arguments = req.Arguments
+	}
+
+	resp.Result, err = up.session.CallTool(ctx, params)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// ClientReadResource reads a resource from a connected server
+func (s *rpcService) ClientReadResource(req *ClientReadResourceRequest, resp *ClientReadResourceResponse) error {
+	const op = errors.Op("mcp_rpc_client_read_resource")
+
+	up, err := s.plugin.lookupClient(req.Name)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	ctx, cancel := context.WithTimeout(s.plugin.ctx, up.cfg.Timeout)
+	defer cancel()
+
+	result, err := up.session.ReadResource(ctx, &mcp.ReadResourceParams{URI: req.URI})
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	resp.Contents = result.Contents
+
+	return nil
+}
+
+// createToolHandler creates a tool handler that delegates execution to PHP workers
+func (p *Plugin) createToolHandler(toolName, namespace, tenant string) func(context.Context, *mcp.CallToolRequest, map[string]interface{}) (*mcp.CallToolResult, interface{}, error) {
+	return func(ctx context.Context, request *mcp.CallToolRequest, args map[string]interface{}) (callResult *mcp.CallToolResult, _ interface{}, callErr error) {
+		defer p.recoverToolCall(ctx, toolName, &callResult)
+
+		sessionID := p.callSessionID(ctx, request.Session)
 
 		// Update session activity
 		p.updateSessionActivity(sessionID)
 
-		// Marshal arguments to JSON
-		argsJSON, err := json.Marshal(args)
+		log := p.callLogger(ctx)
+
+		// Attributes PHP attached to the session travel with the call
+		attributes := p.sessionAttributes(sessionID)
+		if len(attributes) > 0 {
+			log = log.With(zap.Any("session_attributes", attributes))
+		}
+		if p.attributedCalls.enabled() {
+			defer func() {
+				failed := callErr != nil || callResult == nil || callResult.IsError
+				p.attributedCalls.add(toolName, callOutcome(failed), p.sessionTenant(sessionID), attributes)
+			}()
+		}
+
+		// Marshal arguments to JSON, the buffer is reused once the worker answered
+		argsBuf := getBuffer()
+		defer putBuffer(argsBuf)
+
+		argsJSON, err := marshalTo(argsBuf, args)
 		if err != nil {
 			return nil, nil, fmt.Errorf("failed to marshal arguments: %w", err)
 		}
 
+		if ce := log.Check(zap.DebugLevel, "tool execution requested"); ce != nil {
+			ce.Write(
+				zap.String("tool", toolName),
+				zap.String("session_id", sessionID),
+				p.argumentsField(argsJSON),
+			)
+		}
+
 		// Create payload for PHP
 		payload := &CallToolPayload{
-			SessionID: sessionID,
-			ToolName:  toolName,
-			Arguments: json.RawMessage(argsJSON),
+			SessionID:  sessionID,
+			ToolName:   toolName,
+			Namespace:  namespace,
+			Tenant:     tenant,
+			Arguments:  json.RawMessage(argsJSON),
+			Attributes: attributes,
 		}
 
-		// Send event to PHP worker
-		phpResp, err := p.sendEvent(ctx, sessionID, EventCallTool, payload)
-		if err != nil {
-			p.log.Error("tool execution failed",
+		// Forward request _meta for correlation
+		if request.Params != nil && len(request.Params.Meta) > 0 {
+			payload.Meta = request.Params.Meta
+		}
+
+		// Let PHP adapt to the calling client
+		if request.Session != nil {
+			if params := request.Session.InitializeParams(); params != nil {
+				payload.ClientInfo = params.ClientInfo
+				payload.Capabilities = params.Capabilities
+			}
+		}
+
+		// Bound the worker execution by the client's or configured timeout,
+		// overrides are keyed by the registered name
+		registered := toolName
+		if request.Params != nil {
+			registered = request.Params.Name
+		}
+		callCtx, cancel, timeout := p.withCallDeadline(ctx, registered, payload.Meta)
+		defer cancel()
+
+		// A share of the calls of selected tools is mirrored to the shadow pool
+		eventCtx := withTenant(callCtx, tenant)
+		if p.mirrorSelected(registered) {
+			p.mirrorCall(eventCtx, registered, payload, timeout)
+			started := time.Now()
+			defer func() {
+				failed := callErr != nil || callResult == nil || callResult.IsError
+				p.shadowCalls.add(registered, shadowPoolPrimary, callOutcome(failed), time.Since(started))
+			}()
+		}
+
+		// A share of the calls of selected tools runs on the canary pool
+		if p.canaryEligible(registered, tenant) {
+			pool := p.routeCanary()
+			if pool == canaryPoolCanary {
+				eventCtx = withCanary(eventCtx)
+			}
+			defer func() {
+				p.canaryCalls.add(registered, pool, callErr != nil || callResult == nil || callResult.IsError)
+			}()
+		}
+
+		// Send event to PHP worker, once more when it died during a call that is safe to repeat
+		phpResp, err := p.sendEvent(eventCtx, sessionID, EventCallTool, payload)
+		if err != nil && ErrorCodeOf(err) == ErrWorkerCrash && callCtx.Err() == nil && p.crashRetryable(registered) {
+			log.Warn("retrying tool call after its worker terminated",
 				zap.String("tool", toolName),
 				zap.String("session_id", sessionID),
-				zap.Error(err),
 			)
-			return nil, nil, fmt.Errorf("tool execution failed: %w", err)
+			phpResp, err = p.sendEvent(eventCtx, sessionID, EventCallTool, payload)
+		}
+		if err != nil && timeout > 0 && callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			log.Warn("tool execution timed out",
+				zap.String("tool", toolName),
+				zap.String("session_id", sessionID),
+				zap.Duration("timeout", timeout),
+			)
+			return nil, nil, jsonRPCError(withCode(ErrTimeout, fmt.Errorf("Request timed out after %s", timeout)))
+		}
+		if err != nil && ErrorCodeOf(err) == ErrWorkerCrash {
+			log.Error("tool execution failed", append([]zap.Field{
+				zap.String("tool", toolName),
+				zap.String("session_id", sessionID),
+			}, errorFields(err)...)...)
+			return workerCrashResult(), nil, nil
+		}
+		if err != nil {
+			log.Error("tool execution failed", append([]zap.Field{
+				zap.String("tool", toolName),
+				zap.String("session_id", sessionID),
+			}, errorFields(err)...)...)
+			return nil, nil, jsonRPCError(fmt.Errorf("tool execution failed: %w", err))
 		}
 
 		// Parse PHP response
 		var result CallToolResponse
 		if err := json.Unmarshal(phpResp, &result); err != nil {
-			p.log.Error("invalid PHP response",
+			log.Error("invalid PHP response",
 				zap.String("tool", toolName),
 				zap.String("session_id", sessionID),
 				zap.Error(err),
@@ -141,50 +1087,114 @@ func (p *Plugin) createToolHandler(toolName string) func(context.Context, *mcp.C
 			return nil, nil, fmt.Errorf("invalid worker response: %w", err)
 		}
 
+		// Protocol errors bypass the tool result entirely
+		if result.Error != nil {
+			log.Debug("tool execution returned protocol error",
+				zap.String("tool", toolName),
+				zap.String("session_id", sessionID),
+				zap.Int64("code", result.Error.Code),
+			)
+			return nil, nil, newJSONRPCError(result.Error.Code, result.Error.Message, result.Error.Data)
+		}
+
 		// Convert to MCP result
-		mcpContent := make([]mcp.Content, len(result.Content))
-		for i, c := range result.Content {
-			switch c.Type {
-			case "text":
-				mcpContent[i] = &mcp.TextContent{Text: c.Text}
-			case "image":
-				// Image data should be base64 encoded string
-				mcpContent[i] = &mcp.ImageContent{Data: []byte(c.Data), MIMEType: c.MimeType}
-			case "resource":
-				// For resource content, use text content as fallback
-				mcpContent[i] = &mcp.TextContent{Text: c.Text}
-			default:
-				mcpContent[i] = &mcp.TextContent{Text: c.Text}
-			}
+		mcpContent, err := convertContent(result.Content)
+		if err != nil {
+			log.Error("invalid tool content",
+				zap.String("tool", toolName),
+				zap.String("session_id", sessionID),
+				zap.Error(err),
+			)
+			return nil, nil, fmt.Errorf("invalid worker response: %w", err)
 		}
 
 		mcpResult := &mcp.CallToolResult{
+			Meta:    result.Meta,
 			Content: mcpContent,
 			IsError: result.IsError,
 		}
 
-		p.log.Debug("tool execution completed",
+		log.Debug("tool execution completed",
 			zap.String("tool", toolName),
 			zap.String("session_id", sessionID),
 			zap.Bool("is_error", result.IsError),
 		)
 
+		// The SDK validates structured content and sets it on the result
+		if len(result.StructuredContent) > 0 {
+			return mcpResult, result.StructuredContent, nil
+		}
+
 		return mcpResult, nil, nil
 	}
 }
 
-// notifyToolsChanged sends notifications to all connected clients
+// notifyToolsChanged schedules a notification to all connected clients,
+// changes within the debounce window are coalesced into one notification
 func (p *Plugin) notifyToolsChanged() {
+	p.notifyMu.Lock()
+	defer p.notifyMu.Unlock()
+
+	if p.notifyTimer != nil {
+		p.log.Debug("tool change notification already scheduled")
+		return
+	}
+
+	p.notifyTimer = time.AfterFunc(p.cfg.Tools.NotifyDebounce, func() {
+		p.notifyMu.Lock()
+		p.notifyTimer = nil
+		p.notifyMu.Unlock()
+
+		p.sendToolsChanged()
+	})
+}
+
+// sendToolsChanged sends notifications/tools/list_changed to all connected clients
+func (p *Plugin) sendToolsChanged() {
 	p.log.Info("notifying clients about tool changes")
-	// TODO: Implement notification logic via MCP SDK
+
+	if p.sendNotification == nil {
+		return
+	}
+
+	for _, ss := range p.serverSessions() {
+		p.queueToolListChanged(ss)
+	}
+	p.bufferNotification(nil, notificationToolListChanged, p.sendToolListChanged)
+}
+
+// queueToolListChanged queues notifications/tools/list_changed for a session
+func (p *Plugin) queueToolListChanged(ss *mcp.ServerSession) {
+	p.enqueueNotification(ss, notificationToolListChanged, func(ctx context.Context) error {
+		return p.sendToolListChanged(ctx, ss)
+	})
+}
+
+// sendToolListChanged sends notifications/tools/list_changed to a single session
+func (p *Plugin) sendToolListChanged(ctx context.Context, ss *mcp.ServerSession) (err error) {
+	defer p.recoverNotification(ss, &err)
+
+	_, err = p.sendNotification(ctx, notificationToolListChanged, &mcp.ServerRequest[*mcp.ToolListChangedParams]{
+		Session: ss,
+		Params:  &mcp.ToolListChangedParams{},
+	})
+	return err
+}
+
+// notificationMiddleware drops the SDK's per-tool list_changed notifications,
+// the plugin sends a single one per declaration through notifyToolsChanged
+func (p *Plugin) notificationMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if method == notificationToolListChanged {
+			return nil, nil
+		}
+		return next(ctx, method, req)
+	}
 }
 
 // updateSessionActivity updates the last activity time for a session
 func (p *Plugin) updateSessionActivity(sessionID string) {
-	p.mu.Lock()
-	defer p.mu.Unlock()
-
-	if info, ok := p.sessions[sessionID]; ok {
-		info.LastActivity = time.Now()
+	if info, ok := p.sessions.get(sessionID); ok {
+		info.touch()
 	}
 }
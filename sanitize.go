@@ -0,0 +1,161 @@
+package mcp
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// Actions of the sanitize result filter per class of malformed text
+const (
+	SanitizeAllow   = "allow"
+	SanitizeStrip   = "strip"
+	SanitizeReplace = "replace" // U+FFFD
+	SanitizeReject  = "reject"  // The call fails with a tool error
+)
+
+// ansiEscape matches CSI and OSC sequences and two-byte escapes
+var ansiEscape = regexp.MustCompile(`\x1b\[[0-?]*[ -/]*[@-~]|\x1b\][^\x07\x1b]*(?:\x07|\x1b\\)|\x1b[@-Z\\-_]`)
+
+// sanitizer applies results.sanitize to the text of tool results
+type sanitizer struct {
+	invalidUTF8 string
+	control     string
+	ansi        string
+}
+
+func (p *Plugin) newSanitizer() *sanitizer {
+	return &sanitizer{
+		invalidUTF8: p.cfg.Results.Sanitize.InvalidUTF8,
+		control:     p.cfg.Results.Sanitize.Control,
+		ansi:        p.cfg.Results.Sanitize.ANSI,
+	}
+}
+
+func (s *sanitizer) Name() string {
+	return ResultFilterSanitize
+}
+
+func (s *sanitizer) FilterResult(_ context.Context, _ string, result *mcp.CallToolResult) (*mcp.CallToolResult, error) {
+	rejected := ""
+	clean := func(text string) string {
+		text, reason := s.sanitize(text)
+		if reason != "" && rejected == "" {
+			rejected = reason
+		}
+		return text
+	}
+
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case *mcp.TextContent:
+			c.Text = clean(c.Text)
+		case *mcp.EmbeddedResource:
+			if c.Resource != nil {
+				c.Resource.Text = clean(c.Resource.Text)
+			}
+		}
+	}
+	if result.StructuredContent != nil {
+		result.StructuredContent = sanitizeValue(result.StructuredContent, clean)
+	}
+
+	if rejected != "" {
+		return &mcp.CallToolResult{
+			IsError: true,
+			Content: []mcp.Content{&mcp.TextContent{Text: "tool result rejected: it contains " + rejected}},
+		}, nil
+	}
+
+	return result, nil
+}
+
+// sanitize cleans text, returning why it is rejected instead when a class
+// found in it is rejected
+func (s *sanitizer) sanitize(text string) (string, string) {
+	if !utf8.ValidString(text) {
+		switch s.invalidUTF8 {
+		case SanitizeReject:
+			return text, "invalid UTF-8"
+		case SanitizeStrip:
+			text = strings.ToValidUTF8(text, "")
+		case SanitizeReplace:
+			text = strings.ToValidUTF8(text, string(utf8.RuneError))
+		}
+	}
+
+	if s.ansi != SanitizeAllow && strings.IndexByte(text, 0x1b) >= 0 && ansiEscape.MatchString(text) {
+		switch s.ansi {
+		case SanitizeReject:
+			return text, "ANSI escape sequences"
+		case SanitizeStrip:
+			text = ansiEscape.ReplaceAllString(text, "")
+		case SanitizeReplace:
+			text = ansiEscape.ReplaceAllString(text, string(utf8.RuneError))
+		}
+	}
+
+	if s.control != SanitizeAllow && strings.IndexFunc(text, s.isControl) >= 0 {
+		switch s.control {
+		case SanitizeReject:
+			return text, "control characters"
+		case SanitizeStrip:
+			text = strings.Map(func(r rune) rune {
+				if s.isControl(r) {
+					return -1
+				}
+				return r
+			}, text)
+		case SanitizeReplace:
+			text = strings.Map(func(r rune) rune {
+				if s.isControl(r) {
+					return utf8.RuneError
+				}
+				return r
+			}, text)
+		}
+	}
+
+	return text, ""
+}
+
+// isControl reports C0 and C1 control characters other than tabs and line
+// breaks, escapes are left to the ansi class when it allows them
+func (s *sanitizer) isControl(r rune) bool {
+	switch {
+	case r == '\t' || r == '\n' || r == '\r':
+		return false
+	case r == 0x1b && s.ansi == SanitizeAllow:
+		return false
+	}
+	return r < 0x20 || (r >= 0x7f && r <= 0x9f)
+}
+
+// sanitizeValue cleans the strings of structured content
+func sanitizeValue(v interface{}, clean func(string) string) interface{} {
+	switch value := v.(type) {
+	case string:
+		return clean(value)
+	case map[string]interface{}:
+		for k, item := range value {
+			value[k] = sanitizeValue(item, clean)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = sanitizeValue(item, clean)
+		}
+	}
+	return v
+}
+
+// validSanitizeAction reports whether action is an action of results.sanitize
+func validSanitizeAction(action string) bool {
+	switch action {
+	case SanitizeAllow, SanitizeStrip, SanitizeReplace, SanitizeReject:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// transportScheduler is the transport of scheduled call sessions
+const transportScheduler = "scheduler"
+
+// startScheduler runs every scheduled call until the plugin stops
+func (p *Plugin) startScheduler() {
+	for _, call := range p.cfg.Scheduler {
+		// Validated with the configuration
+		sched, _ := parseSchedule(call.Schedule)
+		go p.runSchedule(call, sched)
+	}
+
+	p.log.Info("scheduler started", zap.Int("calls", len(p.cfg.Scheduler)))
+}
+
+// runSchedule invokes a tool at every activation of its schedule
+func (p *Plugin) runSchedule(call *ScheduledCall, sched schedule) {
+	for {
+		next := sched.next(time.Now())
+		if next.IsZero() {
+			p.log.Warn("schedule never activates",
+				zap.String("tool", call.Tool),
+				zap.String("schedule", call.Schedule),
+			)
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-p.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			p.runScheduledCall(call)
+		}
+	}
+}
+
+// runScheduledCall invokes the tool and pushes the result if configured
+func (p *Plugin) runScheduledCall(call *ScheduledCall) {
+	ctx, cancel := context.WithTimeout(p.ctx, call.Timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	cs, closeSession, err := p.connectLocal(ctx, transportScheduler, map[string]string{})
+	if err != nil {
+		p.log.Error("failed to open scheduler session", zap.String("tool", call.Tool), zap.Error(err))
+		return
+	}
+	defer closeSession()
+
+	params := &mcp.CallToolParams{Name: call.Tool}
+	if len(call.Arguments) > 0 {
+		params.Arguments = call.Arguments
+	}
+
+	result, err := cs.CallTool(ctx, params)
+	if err != nil {
+		p.log.Error("scheduled tool call failed", zap.String("tool", call.Tool), zap.Error(err))
+		return
+	}
+
+	p.log.Debug("scheduled tool call completed",
+		zap.String("tool", call.Tool),
+		zap.Bool("is_error", result.IsError),
+		zap.Duration("duration", time.Since(start)),
+	)
+
+	if call.Notify == "" || result.IsError {
+		return
+	}
+
+	event := &WebhookNotification{
+		Type:   call.Notify,
+		Level:  "info",
+		Logger: call.Tool,
+		URI:    call.URI,
+	}
+
+	if call.Notify == webhookMessage {
+		data, err := json.Marshal(scheduledResultData(result))
+		if err != nil {
+			p.log.Error("failed to encode scheduled result", zap.String("tool", call.Tool), zap.Error(err))
+			return
+		}
+		event.Data = data
+	}
+
+	p.broadcastNotification(event)
+}
+
+// scheduledResultData picks the payload of a result pushed as a message,
+// structured content when present, the text content otherwise
+func scheduledResultData(result *mcp.CallToolResult) interface{} {
+	if result.StructuredContent != nil {
+		return result.StructuredContent
+	}
+
+	texts := make([]string, 0, len(result.Content))
+	for _, content := range result.Content {
+		if text, ok := content.(*mcp.TextContent); ok {
+			texts = append(texts, text.Text)
+		}
+	}
+
+	if len(texts) == 1 {
+		return texts[0]
+	}
+
+	return texts
+}
@@ -0,0 +1,109 @@
+package mcp
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+)
+
+// incompatibleChanges lists changes in newSchema that break callers built
+// against oldSchema: removed properties, changed types and new required fields
+func incompatibleChanges(oldSchema, newSchema map[string]interface{}) []string {
+	var changes []string
+
+	if !reflect.DeepEqual(oldSchema["type"], newSchema["type"]) {
+		changes = append(changes, fmt.Sprintf("schema type changed from %v to %v", oldSchema["type"], newSchema["type"]))
+	}
+
+	oldProps := schemaProperties(oldSchema)
+	newProps := schemaProperties(newSchema)
+
+	for _, name := range sortedKeys(oldProps) {
+		newProp, ok := newProps[name]
+		if !ok {
+			changes = append(changes, fmt.Sprintf("property %q removed", name))
+			continue
+		}
+
+		oldType := propertyType(oldProps[name])
+		newType := propertyType(newProp)
+		if !reflect.DeepEqual(oldType, newType) {
+			changes = append(changes, fmt.Sprintf("property %q type changed from %v to %v", name, oldType, newType))
+		}
+	}
+
+	oldRequired := schemaRequired(oldSchema)
+	for _, name := range sortedKeys(schemaRequired(newSchema)) {
+		if !oldRequired[name] {
+			changes = append(changes, fmt.Sprintf("property %q is now required", name))
+		}
+	}
+
+	if newSchema["additionalProperties"] == false && oldSchema["additionalProperties"] != false {
+		changes = append(changes, "additional properties are no longer allowed")
+	}
+
+	return changes
+}
+
+// schemaProperties returns the "properties" object of a schema
+func schemaProperties(schema map[string]interface{}) map[string]interface{} {
+	props, _ := schema["properties"].(map[string]interface{})
+	return props
+}
+
+// schemaRequired returns the set of required property names of a schema
+func schemaRequired(schema map[string]interface{}) map[string]bool {
+	required := make(map[string]bool)
+
+	switch list := schema["required"].(type) {
+	case []interface{}:
+		for _, v := range list {
+			if name, ok := v.(string); ok {
+				required[name] = true
+			}
+		}
+	case []string:
+		for _, name := range list {
+			required[name] = true
+		}
+	}
+
+	return required
+}
+
+// propertyType returns the declared "type" of a property schema
+func propertyType(prop interface{}) interface{} {
+	if m, ok := prop.(map[string]interface{}); ok {
+		return m["type"]
+	}
+	return nil
+}
+
+// sortedKeys returns map keys in a stable order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// declarationHash identifies a tool declaration by its content, as PHP sent
+// it. Maps marshal with sorted keys, so equal declarations hash equally.
+func declarationHash(namespace string, def ToolDefinition) string {
+	data, err := json.Marshal(struct {
+		Namespace  string         `json:"namespace"`
+		Definition ToolDefinition `json:"definition"`
+	}{namespace, def})
+	if err != nil {
+		return ""
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
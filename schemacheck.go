@@ -0,0 +1,318 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/google/jsonschema-go/jsonschema"
+)
+
+// schemaDialect is the JSON Schema dialect of MCP tool schemas
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema checks of tools.schema_check
+const (
+	SchemaCheckReject = "reject"
+	SchemaCheckWarn   = "warn"
+	SchemaCheckOff    = "off"
+)
+
+// legacyDialects are read as 2020-12, their keywords mostly carried over
+var legacyDialects = []string{
+	"http://json-schema.org/draft-07/schema",
+	"http://json-schema.org/draft-06/schema",
+	"http://json-schema.org/draft-04/schema",
+	"https://json-schema.org/draft/2019-09/schema",
+}
+
+// clientUnsupportedKeywords break the schema handling of common clients
+var clientUnsupportedKeywords = map[string]string{
+	"$dynamicRef":      "dynamic references",
+	"$dynamicAnchor":   "dynamic anchors",
+	"$recursiveRef":    "recursive references",
+	"$recursiveAnchor": "recursive anchors",
+}
+
+// rootCombinators are rejected by clients at the top of an input schema
+var rootCombinators = []string{"oneOf", "anyOf", "allOf"}
+
+// Keywords holding a map of subschemas by name
+var schemaMapKeywords = []string{"properties", "patternProperties", "dependentSchemas"}
+
+// Keywords holding values, not subschemas
+var schemaValueKeywords = []string{"enum", "const", "default", "examples", "required"}
+
+// schemaChecker checks a single schema of a tool
+type schemaChecker struct {
+	tool        string
+	field       string
+	compat      string // Severity of client compatibility problems, none when empty
+	root        map[string]interface{}
+	recursive   bool
+	diagnostics []ToolDiagnostic
+}
+
+// checkToolSchemas checks the schemas of a declared tool against the MCP
+// dialect and inlines their internal $refs, so clients that do not resolve
+// references see the full schema. Schemas with errors must not be registered,
+// the SDK cannot serve them.
+func (p *Plugin) checkToolSchemas(name string, def *ToolDefinition) []ToolDiagnostic {
+	compat := SeverityError
+	switch p.cfg.Tools.SchemaCheck {
+	case SchemaCheckWarn:
+		compat = SeverityWarning
+	case SchemaCheckOff:
+		compat = ""
+	}
+
+	var diagnostics []ToolDiagnostic
+	if def.InputSchema != nil {
+		c := &schemaChecker{tool: name, field: "inputSchema", compat: compat}
+		def.InputSchema = c.check(def.InputSchema)
+		diagnostics = append(diagnostics, c.diagnostics...)
+	}
+	if def.OutputSchema != nil {
+		c := &schemaChecker{tool: name, field: "outputSchema", compat: compat}
+		def.OutputSchema = c.check(def.OutputSchema)
+		diagnostics = append(diagnostics, c.diagnostics...)
+	}
+
+	return diagnostics
+}
+
+// hasErrors reports whether any diagnostic is an error
+func hasErrors(diagnostics []ToolDiagnostic) bool {
+	return slices.ContainsFunc(diagnostics, func(d ToolDiagnostic) bool { return d.Severity == SeverityError })
+}
+
+// check returns the schema with its internal references inlined
+func (c *schemaChecker) check(schema map[string]interface{}) map[string]interface{} {
+	c.root = schema
+
+	legacy := false
+	if dialect, ok := schema["$schema"].(string); ok && dialect != schemaDialect {
+		if slices.Contains(legacyDialects, strings.TrimSuffix(dialect, "#")) {
+			legacy = true
+			c.compatible("/$schema", "legacy_dialect", "%s is read as JSON Schema 2020-12, the dialect of MCP", dialect)
+		} else {
+			c.report(SeverityError, "/$schema", "unsupported_dialect", "unsupported dialect %q, MCP uses JSON Schema 2020-12", dialect)
+		}
+	}
+
+	if c.field == "inputSchema" {
+		for _, keyword := range rootCombinators {
+			if _, ok := schema[keyword]; ok {
+				c.compatible("/"+keyword, "unsupported_keyword", "%s at the top of an input schema is rejected by clients, describe the arguments with properties", keyword)
+			}
+		}
+	}
+
+	inlined, ok := c.inline(schema, "", nil).(map[string]interface{})
+	if !ok {
+		c.report(SeverityError, "", "invalid_schema", "the schema must be an object schema")
+		return schema
+	}
+
+	// Definitions are only needed by the references that could not be inlined
+	if !c.recursive {
+		delete(inlined, "$defs")
+		delete(inlined, "definitions")
+	}
+
+	// The SDK only resolves 2020-12 schemas
+	if legacy {
+		inlined["$schema"] = schemaDialect
+	}
+
+	if hasErrors(c.diagnostics) {
+		return inlined
+	}
+
+	// Same resolution as the SDK's, which panics on failure
+	var resolvable jsonschema.Schema
+	data, err := json.Marshal(inlined)
+	if err == nil {
+		err = json.Unmarshal(data, &resolvable)
+	}
+	if err == nil {
+		_, err = resolvable.Resolve(&jsonschema.ResolveOptions{ValidateDefaults: true})
+	}
+	if err != nil {
+		c.report(SeverityError, "", "invalid_schema", "%v", err)
+	}
+
+	return inlined
+}
+
+// inline copies a subschema replacing internal references by their target,
+// refs holds the references being inlined to detect recursion
+func (c *schemaChecker) inline(node interface{}, path string, refs []string) interface{} {
+	switch v := node.(type) {
+	case map[string]interface{}:
+		if ref, ok := v["$ref"].(string); ok {
+			return c.inlineRef(v, ref, path, refs)
+		}
+
+		out := make(map[string]interface{}, len(v))
+		for key, child := range v {
+			out[key] = c.inlineKeyword(key, child, path, refs)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, child := range v {
+			out[i] = c.inline(child, path+"/"+strconv.Itoa(i), refs)
+		}
+		return out
+	}
+
+	return node
+}
+
+// inlineKeyword inlines the value of a keyword of the subschema at path
+func (c *schemaChecker) inlineKeyword(key string, value interface{}, path string, refs []string) interface{} {
+	if reason, ok := clientUnsupportedKeywords[key]; ok {
+		c.compatible(path+"/"+key, "unsupported_keyword", "%s are not supported by common clients", reason)
+	}
+
+	switch {
+	case key == "$defs" || key == "definitions" || slices.Contains(schemaValueKeywords, key):
+		return value
+	case slices.Contains(schemaMapKeywords, key):
+		schemas, ok := value.(map[string]interface{})
+		if !ok {
+			return value
+		}
+		inlined := make(map[string]interface{}, len(schemas))
+		for name, schema := range schemas {
+			inlined[name] = c.inline(schema, path+"/"+key+"/"+escapePointer(name), refs)
+		}
+		return inlined
+	}
+
+	return c.inline(value, path+"/"+key, refs)
+}
+
+// inlineRef replaces a subschema with a $ref by its target, keywords next
+// to the reference take precedence over the target's
+func (c *schemaChecker) inlineRef(v map[string]interface{}, ref, path string, refs []string) interface{} {
+	if !strings.HasPrefix(ref, "#") {
+		c.report(SeverityError, path+"/$ref", "remote_ref", "$ref %q points outside the schema, only internal references are supported", ref)
+		return v
+	}
+
+	target, ok := c.lookup(ref)
+	if !ok {
+		c.report(SeverityError, path+"/$ref", "unresolved_ref", "$ref %q does not resolve to a subschema", ref)
+		return v
+	}
+
+	// Recursive schemas keep the reference and their definitions
+	if slices.Contains(refs, ref) {
+		if !c.recursive {
+			c.compatible(path+"/$ref", "recursive_ref", "$ref %q is recursive and kept, some clients do not resolve references", ref)
+		}
+		c.recursive = true
+		return v
+	}
+
+	resolved := c.inline(target, path, append(refs, ref))
+	schema, ok := resolved.(map[string]interface{})
+	if !ok {
+		// Boolean schema
+		if len(v) == 1 {
+			return resolved
+		}
+		return v
+	}
+
+	out := make(map[string]interface{}, len(schema)+len(v))
+	for key, value := range schema {
+		out[key] = value
+	}
+	for key, value := range v {
+		if key != "$ref" {
+			out[key] = c.inlineKeyword(key, value, path, refs)
+		}
+	}
+	return out
+}
+
+// rootSchema returns the subschema an internal $ref at the top of a schema
+// points to, the schema itself without one
+func rootSchema(schema map[string]interface{}) map[string]interface{} {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema
+	}
+
+	c := &schemaChecker{root: schema}
+	if target, ok := c.lookup(ref); ok {
+		if target, ok := target.(map[string]interface{}); ok {
+			return target
+		}
+	}
+	return schema
+}
+
+// lookup resolves an internal reference, a JSON pointer into the root schema
+func (c *schemaChecker) lookup(ref string) (interface{}, bool) {
+	fragment, err := url.PathUnescape(strings.TrimPrefix(ref, "#"))
+	if err != nil || (fragment != "" && !strings.HasPrefix(fragment, "/")) {
+		return nil, false
+	}
+
+	var node interface{} = c.root
+	if fragment == "" {
+		return node, true
+	}
+
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := node.(type) {
+		case map[string]interface{}:
+			child, ok := v[token]
+			if !ok {
+				return nil, false
+			}
+			node = child
+		case []interface{}:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			node = v[i]
+		default:
+			return nil, false
+		}
+	}
+
+	switch node.(type) {
+	case map[string]interface{}, bool:
+		return node, true
+	}
+	return nil, false
+}
+
+// compatible reports a problem clients have with an otherwise valid schema,
+// with the severity of tools.schema_check
+func (c *schemaChecker) compatible(path, code, format string, args ...interface{}) {
+	if c.compat != "" {
+		c.report(c.compat, path, code, format, args...)
+	}
+}
+
+// report adds a diagnostic for the subschema at path
+func (c *schemaChecker) report(severity, path, code, format string, args ...interface{}) {
+	c.diagnostics = append(c.diagnostics, ToolDiagnostic{
+		Tool:     c.tool,
+		Severity: severity,
+		Code:     code,
+		Field:    c.field + path,
+		Message:  fmt.Sprintf("tool %q: %s: %s", c.tool, c.field+path, fmt.Sprintf(format, args...)),
+	})
+}
@@ -0,0 +1,231 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// codeMethodNotFound is the JSON-RPC code for methods of disabled capabilities
+const codeMethodNotFound = -32601
+
+// Capabilities a logical server can offer
+const (
+	CapabilityTools     = "tools"
+	CapabilityPrompts   = "prompts"
+	CapabilityResources = "resources"
+)
+
+// ServerConfig describes a logical MCP server offering a subset of the
+// plugin's features on its own endpoint, served by the same workers
+type ServerConfig struct {
+	// Listener address, the server is mounted on the main SSE listener when empty
+	Address string `mapstructure:"address"`
+
+	// Path of the SSE endpoint, defaults to "/" on its own listener
+	Path string `mapstructure:"path"`
+
+	// Offered capabilities: "tools", "prompts", "resources", all when empty
+	Capabilities []string `mapstructure:"capabilities"`
+
+	// Glob patterns of exposed tool names, all tools when empty
+	Tools []string `mapstructure:"tools"`
+
+	// HTTPS for the server's own listener
+	TLS *TLSConfig `mapstructure:"tls"`
+
+	// Overrides the authentication of the main listener
+	Auth *ListenerAuthConfig `mapstructure:"auth"`
+}
+
+// InitDefaults fills in defaults for a logical server
+func (s *ServerConfig) InitDefaults() {
+	if s.Path == "" && s.Address != "" {
+		s.Path = "/"
+	}
+	if s.Path != "" {
+		s.Path = "/" + strings.Trim(s.Path, "/")
+	}
+	if len(s.Capabilities) == 0 {
+		s.Capabilities = []string{CapabilityTools, CapabilityPrompts, CapabilityResources}
+	}
+}
+
+// Validate checks a logical server configuration
+func (s *ServerConfig) Validate() error {
+	if s.Address == "" && (s.Path == "" || s.Path == "/") {
+		return errors.Str("address or a path other than \"/\" is required")
+	}
+	for _, capability := range s.Capabilities {
+		if capability != CapabilityTools && capability != CapabilityPrompts && capability != CapabilityResources {
+			return errors.Errorf("unknown capability %q", capability)
+		}
+	}
+	for _, pattern := range s.Tools {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return errors.Errorf("invalid tools pattern %q", pattern)
+		}
+	}
+	if s.TLS != nil {
+		if s.Address == "" {
+			return errors.Str("tls requires an own address, the main listener uses mcp.tls")
+		}
+		if err := s.TLS.Validate(); err != nil {
+			return errors.Errorf("tls: %v", err)
+		}
+	}
+	if s.Auth != nil {
+		if err := s.Auth.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// offers reports whether the server offers a capability
+func (s *ServerConfig) offers(capability string) bool {
+	return slices.Contains(s.Capabilities, capability)
+}
+
+// exposesTool reports whether the server exposes a tool
+func (s *ServerConfig) exposesTool(name string) bool {
+	if len(s.Tools) == 0 {
+		return true
+	}
+
+	return slices.ContainsFunc(s.Tools, func(pattern string) bool {
+		matched, _ := path.Match(pattern, name)
+		return matched
+	})
+}
+
+// methodCapability returns the capability a method belongs to
+func methodCapability(method string) string {
+	switch {
+	case strings.HasPrefix(method, "tools/"):
+		return CapabilityTools
+	case strings.HasPrefix(method, "prompts/"), method == "completion/complete":
+		return CapabilityPrompts
+	case strings.HasPrefix(method, "resources/"):
+		return CapabilityResources
+	}
+	return ""
+}
+
+// sessionServer returns the logical server configuration of a session, nil
+// for sessions of the main endpoint
+func (p *Plugin) sessionServer(sessionID string) *ServerConfig {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if info, ok := p.sessions.get(sessionID); ok && info.Server != "" {
+		return p.cfg.Servers[info.Server]
+	}
+
+	return nil
+}
+
+// serverMiddleware restricts sessions of logical servers to the configured
+// capabilities and tools
+func (p *Plugin) serverMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		if len(p.cfg.Servers) == 0 {
+			return next(ctx, method, req)
+		}
+
+		server := p.sessionServer(sessionIDFromContext(ctx))
+		if server == nil {
+			return next(ctx, method, req)
+		}
+
+		if capability := methodCapability(method); capability != "" && !server.offers(capability) {
+			return nil, newJSONRPCError(codeMethodNotFound, fmt.Sprintf("method %q not found", method), nil)
+		}
+
+		if params, ok := req.GetParams().(*mcp.CallToolParamsRaw); ok && method == "tools/call" && !server.exposesTool(params.Name) {
+			return nil, newJSONRPCError(codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name), nil)
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		switch res := result.(type) {
+		case *mcp.InitializeResult:
+			if res.Capabilities != nil {
+				caps := *res.Capabilities
+				if !server.offers(CapabilityTools) {
+					caps.Tools = nil
+				}
+				if !server.offers(CapabilityPrompts) {
+					caps.Prompts = nil
+					caps.Completions = nil
+				}
+				if !server.offers(CapabilityResources) {
+					caps.Resources = nil
+				}
+				res.Capabilities = &caps
+			}
+		case *mcp.ListToolsResult:
+			tools := make([]*mcp.Tool, 0, len(res.Tools))
+			for _, tool := range res.Tools {
+				if server.exposesTool(tool.Name) {
+					tools = append(tools, tool)
+				}
+			}
+			res.Tools = tools
+		}
+
+		return result, nil
+	}
+}
+
+// mountServers adds the logical servers without their own listener to the main mux
+func (p *Plugin) mountServers(mux *http.ServeMux) {
+	for _, name := range sortedKeys(p.cfg.Servers) {
+		server := p.cfg.Servers[name]
+		if server.Address != "" {
+			continue
+		}
+
+		mux.Handle(server.Path, p.sseSessionHandler(name))
+		p.log.Info("MCP server mounted",
+			zap.String("server", name),
+			zap.String("path", server.Path),
+		)
+	}
+}
+
+// serveServer starts the listener of a logical server
+func (p *Plugin) serveServer(name string, server *ServerConfig) error {
+	const op = errors.Op("mcp_serve_server")
+
+	mux := http.NewServeMux()
+	mux.Handle(server.Path, p.sseSessionHandler(name))
+
+	srv := p.newClientServer(server.Address, p.logAccess(p.limitRequests(p.compressResponses(mux))))
+
+	p.mu.Lock()
+	p.serverListeners = append(p.serverListeners, srv)
+	p.mu.Unlock()
+
+	p.log.Info("MCP server listening",
+		zap.String("server", name),
+		zap.String("address", server.Address),
+		zap.String("path", server.Path),
+	)
+
+	if err := p.listenAndServe(srv, server.TLS); err != nil && err != http.ErrServerClosed {
+		return errors.E(op, fmt.Errorf("server %s: %w", name, err))
+	}
+
+	return nil
+}
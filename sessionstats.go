@@ -0,0 +1,68 @@
+package mcp
+
+import (
+	"time"
+)
+
+// defaultSessionIdleAfter marks sessions idle when SessionStats is not given a threshold
+const defaultSessionIdleAfter = time.Minute
+
+// SessionStatsRequest is sent from PHP to count the active sessions
+type SessionStatsRequest struct {
+	IdleAfter time.Duration `json:"idleAfter,omitempty"` // Default 1m
+}
+
+// SessionStats counts active sessions
+type SessionStats struct {
+	Sessions   int           `json:"sessions"`
+	Idle       int           `json:"idle"`       // No activity for longer than idleAfter
+	AverageAge time.Duration `json:"averageAge"` // Since connect
+}
+
+// SessionStatsResponse counts the active sessions, in total and by transport
+type SessionStatsResponse struct {
+	Total      SessionStats             `json:"total"`
+	Transports map[string]*SessionStats `json:"transports"`
+}
+
+// sessionStats counts the active sessions, sessions without activity for
+// longer than idleAfter are idle
+func (p *Plugin) sessionStats(idleAfter time.Duration) *SessionStatsResponse {
+	if idleAfter <= 0 {
+		idleAfter = defaultSessionIdleAfter
+	}
+
+	now := time.Now()
+	resp := &SessionStatsResponse{Transports: make(map[string]*SessionStats)}
+	ages := make(map[string]time.Duration)
+	var totalAge time.Duration
+
+	for _, info := range p.sessions.snapshot() {
+		stats, ok := resp.Transports[info.Transport]
+		if !ok {
+			stats = &SessionStats{}
+			resp.Transports[info.Transport] = stats
+		}
+
+		idle := now.Sub(info.lastActivity()) > idleAfter
+		age := now.Sub(info.ConnectedAt)
+
+		stats.Sessions++
+		resp.Total.Sessions++
+		if idle {
+			stats.Idle++
+			resp.Total.Idle++
+		}
+		ages[info.Transport] += age
+		totalAge += age
+	}
+
+	for transport, stats := range resp.Transports {
+		stats.AverageAge = ages[transport] / time.Duration(stats.Sessions)
+	}
+	if resp.Total.Sessions > 0 {
+		resp.Total.AverageAge = totalAge / time.Duration(resp.Total.Sessions)
+	}
+
+	return resp
+}
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"sync"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// sessionBucket holds the values one session stored
+type sessionBucket struct {
+	values map[string]string
+	bytes  int // Sum of key and value lengths
+}
+
+// sessionStorage keeps the values PHP stores per session between tool calls.
+// Locking order is the plugin lock first, so a value cannot be stored for a
+// session removed concurrently.
+type sessionStorage struct {
+	mu      sync.Mutex
+	buckets map[string]*sessionBucket // session ID -> values
+}
+
+func newSessionStorage() *sessionStorage {
+	return &sessionStorage{buckets: make(map[string]*sessionBucket)}
+}
+
+// drop removes the values of a session, must be called under the plugin lock
+func (s *sessionStorage) drop(sessionID string) {
+	s.mu.Lock()
+	delete(s.buckets, sessionID)
+	s.mu.Unlock()
+}
+
+// storageGet returns a value stored for a session
+func (p *Plugin) storageGet(sessionID, key string) (string, bool, error) {
+	const op = errors.Op("mcp_session_storage_get")
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, ok := p.sessions.get(sessionID); !ok {
+		return "", false, errors.E(op, errors.Errorf("unknown session: %s", sessionID))
+	}
+
+	s := p.storage
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[sessionID]
+	if !ok {
+		return "", false, nil
+	}
+	value, ok := bucket.values[key]
+
+	return value, ok, nil
+}
+
+// storageSet stores a value for a session within clients.storage quotas and
+// returns the number of keys and bytes the session uses
+func (p *Plugin) storageSet(sessionID, key, value string) (int, int, error) {
+	const op = errors.Op("mcp_session_storage_set")
+
+	if key == "" {
+		return 0, 0, errors.E(op, errors.Str("key is required"))
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, ok := p.sessions.get(sessionID); !ok {
+		return 0, 0, errors.E(op, errors.Errorf("unknown session: %s", sessionID))
+	}
+
+	s := p.storage
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[sessionID]
+	if !ok {
+		bucket = &sessionBucket{values: make(map[string]string)}
+		s.buckets[sessionID] = bucket
+	}
+
+	size := bucket.bytes + len(key) + len(value)
+	keys := len(bucket.values)
+	if previous, exists := bucket.values[key]; exists {
+		size -= len(key) + len(previous)
+	} else {
+		keys++
+	}
+
+	limits := p.cfg.Clients.Storage
+	if keys > limits.MaxKeys {
+		return 0, 0, errors.E(op, errors.Errorf("session storage quota exceeded: at most %d keys", limits.MaxKeys))
+	}
+	if size > limits.MaxBytes {
+		return 0, 0, errors.E(op, errors.Errorf("session storage quota exceeded: at most %d bytes", limits.MaxBytes))
+	}
+
+	bucket.values[key] = value
+	bucket.bytes = size
+
+	return keys, size, nil
+}
+
+// storageDelete removes a value stored for a session and reports whether it existed
+func (p *Plugin) storageDelete(sessionID, key string) (bool, error) {
+	const op = errors.Op("mcp_session_storage_delete")
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if _, ok := p.sessions.get(sessionID); !ok {
+		return false, errors.E(op, errors.Errorf("unknown session: %s", sessionID))
+	}
+
+	s := p.storage
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	bucket, ok := s.buckets[sessionID]
+	if !ok {
+		return false, nil
+	}
+	value, ok := bucket.values[key]
+	if !ok {
+		return false, nil
+	}
+
+	delete(bucket.values, key)
+	bucket.bytes -= len(key) + len(value)
+	if len(bucket.values) == 0 {
+		delete(s.buckets, sessionID)
+	}
+
+	return true, nil
+}
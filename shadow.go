@@ -0,0 +1,188 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"math/rand/v2"
+	"path"
+	"slices"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// headerShadow marks the worker request of a mirrored tool call
+const headerShadow = "X-MCP-Shadow"
+
+// Pools of mirrored tool calls, values of the pool label
+const (
+	shadowPoolPrimary = "primary"
+	shadowPoolShadow  = "shadow"
+)
+
+// Outcomes of mirrored tool calls, besides the canary ones
+const (
+	shadowOutcomeDropped = "dropped"
+)
+
+// shadowCtxKey marks a tool call mirrored to the shadow pool
+type shadowCtxKey struct{}
+
+// withShadow routes the worker execution of a tool call to the shadow pool
+func withShadow(ctx context.Context) context.Context {
+	return context.WithValue(ctx, shadowCtxKey{}, true)
+}
+
+// isShadow reports whether a tool call is a mirror running on the shadow pool
+func isShadow(ctx context.Context) bool {
+	shadow, _ := ctx.Value(shadowCtxKey{}).(bool)
+	return shadow
+}
+
+// shadowKey partitions mirrored tool calls
+type shadowKey struct {
+	tool    string
+	pool    string
+	outcome string
+}
+
+// shadowStat is the number and total duration of calls
+type shadowStat struct {
+	count   uint64
+	seconds float64
+}
+
+// shadowCalls records the outcomes and latencies of mirrored calls on both
+// pools, so the shadow can be compared with the primary
+type shadowCalls struct {
+	mu    sync.Mutex
+	stats map[shadowKey]shadowStat
+}
+
+func newShadowCalls() *shadowCalls {
+	return &shadowCalls{stats: make(map[shadowKey]shadowStat)}
+}
+
+// add records a finished call
+func (c *shadowCalls) add(tool, pool, outcome string, elapsed time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := shadowKey{tool: tool, pool: pool, outcome: outcome}
+	stat := c.stats[key]
+	stat.count++
+	stat.seconds += elapsed.Seconds()
+	c.stats[key] = stat
+}
+
+// snapshot returns a copy of the stats
+func (c *shadowCalls) snapshot() map[shadowKey]shadowStat {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return maps.Clone(c.stats)
+}
+
+// callOutcome names the outcome of a finished call
+func callOutcome(failed bool) string {
+	if failed {
+		return canaryOutcomeError
+	}
+	return canaryOutcomeSuccess
+}
+
+// mirrorSelected reports whether a call of a tool is mirrored to the shadow,
+// shadow.percentage of the calls of shadow.tools are
+func (p *Plugin) mirrorSelected(tool string) bool {
+	if p.shadowPool == nil {
+		return false
+	}
+
+	matched := slices.ContainsFunc(p.cfg.Shadow.Tools, func(pattern string) bool {
+		matched, _ := path.Match(pattern, tool)
+		return matched
+	})
+
+	return matched && rand.Float64()*100 < p.cfg.Shadow.Percentage
+}
+
+// mirrorCall runs a copy of a tool call on the shadow pool in the background
+// and discards its result. The mirror keeps the request and trace IDs of the
+// call but not its cancellation, it ends with its own timeout or the plugin.
+// Calls beyond shadow.max_in_flight are dropped instead of queued.
+func (p *Plugin) mirrorCall(ctx context.Context, tool string, payload *CallToolPayload, timeout time.Duration) {
+	select {
+	case p.shadowSlots <- struct{}{}:
+	default:
+		p.shadowCalls.add(tool, shadowPoolShadow, shadowOutcomeDropped, 0)
+		return
+	}
+
+	// Arguments live in a pooled buffer released when the primary call returns
+	mirrored := *payload
+	mirrored.Arguments = slices.Clone(payload.Arguments)
+	mirrored.Meta = maps.Clone(payload.Meta)
+	delete(mirrored.Meta, "progressToken") // the client only hears from the primary
+
+	base := withShadow(context.WithoutCancel(ctx))
+	var (
+		shadowCtx context.Context
+		cancel    context.CancelFunc
+	)
+	if timeout > 0 {
+		shadowCtx, cancel = context.WithTimeout(base, timeout)
+	} else {
+		shadowCtx, cancel = context.WithCancel(base)
+	}
+	stop := context.AfterFunc(p.ctx, cancel)
+
+	go func() {
+		defer func() {
+			stop()
+			cancel()
+			<-p.shadowSlots
+		}()
+
+		started := time.Now()
+		resp, err := p.sendEvent(shadowCtx, mirrored.SessionID, EventCallTool, &mirrored)
+		elapsed := time.Since(started)
+
+		failed := err != nil
+		if err == nil {
+			var result CallToolResponse
+			failed = json.Unmarshal(resp, &result) != nil || result.Error != nil || result.IsError
+		} else {
+			p.log.Debug("shadow execution failed",
+				zap.String("tool", tool),
+				zap.String("session_id", mirrored.SessionID),
+				zap.Error(err),
+			)
+		}
+
+		p.shadowCalls.add(tool, shadowPoolShadow, callOutcome(failed), elapsed)
+	}()
+}
+
+// createShadowPool starts the shadow pool, must be called under lock
+func (p *Plugin) createShadowPool() error {
+	if p.cfg.Shadow.Pool == nil {
+		return nil
+	}
+
+	pool, err := p.server.NewPool(
+		p.ctx,
+		p.cfg.Shadow.Pool,
+		p.workerEnv(map[string]string{"RR_MCP_SHADOW": "true"}),
+		p.log.Named("shadow"),
+	)
+	if err != nil {
+		return err
+	}
+
+	p.shadowPool = pool
+	p.shadowSlots = make(chan struct{}, p.cfg.Shadow.MaxInFlight)
+
+	return nil
+}
@@ -0,0 +1,127 @@
+package mcp
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// slowConsumers counts SSE sessions evicted for not reading their stream
+type slowConsumers struct {
+	mu     sync.Mutex
+	counts map[string]uint64 // server -> evicted sessions
+}
+
+func newSlowConsumers() *slowConsumers {
+	return &slowConsumers{counts: make(map[string]uint64)}
+}
+
+// add counts an evicted session
+func (s *slowConsumers) add(server string) {
+	s.mu.Lock()
+	s.counts[server]++
+	s.mu.Unlock()
+}
+
+// snapshot returns a copy of the counters
+func (s *slowConsumers) snapshot() map[string]uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	counts := make(map[string]uint64, len(s.counts))
+	for k, v := range s.counts {
+		counts[k] = v
+	}
+	return counts
+}
+
+// sseWriter bounds every write of an SSE stream by clients.write_timeout and
+// reports the stream as stalled when a write does not complete in time. The
+// deadline of the listener would otherwise end long-lived streams after
+// write_timeout, or a blocked write would hold the session forever.
+type sseWriter struct {
+	http.ResponseWriter
+	rc      *http.ResponseController
+	timeout time.Duration
+	stalled func()
+	once    sync.Once
+
+	// Called with the SDK session ID once the endpoint event is written
+	endpoint func(string)
+}
+
+func newSSEWriter(w http.ResponseWriter, timeout time.Duration, stalled func()) *sseWriter {
+	return &sseWriter{
+		ResponseWriter: w,
+		rc:             http.NewResponseController(w),
+		timeout:        timeout,
+		stalled:        stalled,
+	}
+}
+
+// Write writes an event within the write timeout
+func (w *sseWriter) Write(b []byte) (int, error) {
+	_ = w.rc.SetWriteDeadline(time.Now().Add(w.timeout))
+
+	if w.endpoint != nil {
+		if id := sseEndpointSession(b); id != "" {
+			w.endpoint(id)
+			w.endpoint = nil
+		}
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.check(err)
+	return n, err
+}
+
+// sseEndpointSession returns the SDK session ID of an endpoint event
+func sseEndpointSession(b []byte) string {
+	if !bytes.HasPrefix(b, []byte("event: endpoint")) {
+		return ""
+	}
+	_, query, ok := bytes.Cut(b, []byte("sessionid="))
+	if !ok {
+		return ""
+	}
+	if end := bytes.IndexAny(query, "&\r\n"); end >= 0 {
+		query = query[:end]
+	}
+	return string(query)
+}
+
+// Flush sends buffered events to the client within the write timeout
+func (w *sseWriter) Flush() {
+	_ = w.rc.SetWriteDeadline(time.Now().Add(w.timeout))
+
+	w.check(w.rc.Flush())
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *sseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// check reports a stalled stream once
+func (w *sseWriter) check(err error) {
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		w.once.Do(w.stalled)
+	}
+}
+
+// evictSlowConsumer records a session whose stream stayed blocked beyond the
+// write timeout, the caller ends the stream
+func (p *Plugin) evictSlowConsumer(sessionID, server string) {
+	p.slowConsumers.add(server)
+
+	p.log.Warn("slow SSE consumer evicted",
+		zap.String("session_id", sessionID),
+		zap.String("server", server),
+		zap.Duration("write_timeout", p.cfg.Clients.WriteTimeout),
+	)
+}
@@ -0,0 +1,289 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// Spilled results are read as resources below spillURIPrefix
+const (
+	spillURIPrefix   = "spill://results/"
+	spillURITemplate = spillURIPrefix + "{id}"
+	spillFileSuffix  = ".spill"
+)
+
+// spilledMetaKey is the _meta key telling clients a result was stored as a
+// resource
+const spilledMetaKey = "spilled"
+
+// spilledResult is a tool result stored in a spill file
+type spilledResult struct {
+	sessionID string
+	path      string
+	mimeType  string
+	binary    bool // the file holds a blob rather than text
+	size      int64
+	expiresAt time.Time
+}
+
+// spillStore keeps oversized tool results in files of tools.spill.dir until
+// tools.spill.ttl passed. A spilled result is readable by its session only.
+type spillStore struct {
+	dir string
+	ttl time.Duration
+	log *zap.Logger
+
+	mu      sync.Mutex
+	results map[string]*spilledResult // id -> result
+}
+
+// openSpillStore creates the spill directory, removing files left by an
+// earlier run, nil without tools.spill.enabled
+func (p *Plugin) openSpillStore() (*spillStore, error) {
+	const op = errors.Op("mcp_open_spill_store")
+
+	cfg := p.cfg.Tools.Spill
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	if err := os.MkdirAll(cfg.Dir, 0o700); err != nil {
+		return nil, errors.E(op, err)
+	}
+
+	stale, err := filepath.Glob(filepath.Join(cfg.Dir, "*"+spillFileSuffix))
+	if err != nil {
+		return nil, errors.E(op, err)
+	}
+	for _, path := range stale {
+		_ = os.Remove(path)
+	}
+
+	return &spillStore{
+		dir:     cfg.Dir,
+		ttl:     cfg.TTL,
+		log:     p.log,
+		results: make(map[string]*spilledResult),
+	}, nil
+}
+
+// put stores the content of a result and returns the URI it is read from
+func (s *spillStore) put(sessionID string, res *mcp.CallToolResult) (string, *spilledResult, error) {
+	data, mimeType, binary, err := spillContent(res)
+	if err != nil {
+		return "", nil, err
+	}
+
+	id := uuid.New().String()
+	path := filepath.Join(s.dir, id+spillFileSuffix)
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", nil, err
+	}
+
+	spilled := &spilledResult{
+		sessionID: sessionID,
+		path:      path,
+		mimeType:  mimeType,
+		binary:    binary,
+		size:      int64(len(data)),
+		expiresAt: time.Now().Add(s.ttl),
+	}
+
+	s.mu.Lock()
+	s.results[id] = spilled
+	s.mu.Unlock()
+
+	return spillURIPrefix + id, spilled, nil
+}
+
+// spillContent returns what is stored of a result: the text or data of a
+// single block as is, several blocks as their JSON array
+func spillContent(res *mcp.CallToolResult) ([]byte, string, bool, error) {
+	if len(res.Content) == 1 {
+		switch c := res.Content[0].(type) {
+		case *mcp.TextContent:
+			return []byte(c.Text), "text/plain", false, nil
+		case *mcp.ImageContent:
+			return c.Data, c.MIMEType, true, nil
+		case *mcp.AudioContent:
+			return c.Data, c.MIMEType, true, nil
+		}
+	}
+
+	data, err := json.Marshal(res.Content)
+	if err != nil {
+		return nil, "", false, err
+	}
+	return data, "application/json", false, nil
+}
+
+// get returns a spilled result of a session
+func (s *spillStore) get(sessionID, id string) (*spilledResult, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	spilled, ok := s.results[id]
+	if !ok || spilled.sessionID != sessionID || !time.Now().Before(spilled.expiresAt) {
+		return nil, false
+	}
+	return spilled, true
+}
+
+// sweep removes the files of expired results
+func (s *spillStore) sweep() {
+	now := time.Now()
+
+	s.mu.Lock()
+	var expired []*spilledResult
+	for id, spilled := range s.results {
+		if !now.Before(spilled.expiresAt) {
+			expired = append(expired, spilled)
+			delete(s.results, id)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, spilled := range expired {
+		if err := os.Remove(spilled.path); err != nil && !os.IsNotExist(err) {
+			s.log.Warn("failed to remove spill file", zap.String("path", spilled.path), zap.Error(err))
+		}
+	}
+}
+
+// run sweeps expired results until ctx ends, then removes all spill files
+func (s *spillStore) run(ctx context.Context) {
+	ticker := time.NewTicker(min(s.ttl, time.Minute))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.mu.Lock()
+			for id, spilled := range s.results {
+				_ = os.Remove(spilled.path)
+				delete(s.results, id)
+			}
+			s.mu.Unlock()
+			return
+		case <-ticker.C:
+			s.sweep()
+		}
+	}
+}
+
+// spillResult replaces the content of an oversized result with a link to
+// the spilled content, false when it could not be stored
+func (p *Plugin) spillResult(ctx context.Context, tool string, res *mcp.CallToolResult, size int) bool {
+	uri, spilled, err := p.spill.put(sessionIDFromContext(ctx), res)
+	if err != nil {
+		p.callLogger(ctx).Warn("failed to spill tool result, truncating it",
+			zap.String("tool", tool),
+			zap.Error(err),
+		)
+		return false
+	}
+
+	res.Content = []mcp.Content{
+		&mcp.TextContent{Text: fmt.Sprintf(
+			"The result is too large to return (%d bytes) and was stored as the resource %s until %s, read it to get the content.",
+			size, uri, spilled.expiresAt.UTC().Format(time.RFC3339),
+		)},
+		&mcp.ResourceLink{
+			URI:      uri,
+			Name:     "result of " + tool,
+			MIMEType: spilled.mimeType,
+			Size:     &spilled.size,
+		},
+	}
+
+	if res.Meta == nil {
+		res.Meta = mcp.Meta{}
+	}
+	res.Meta[spilledMetaKey] = map[string]interface{}{
+		"uri":           uri,
+		"originalBytes": size,
+		"expiresAt":     spilled.expiresAt.UTC().Format(time.RFC3339),
+	}
+
+	p.callLogger(ctx).Info("tool result spilled",
+		zap.String("tool", tool),
+		zap.String("session_id", sessionIDFromContext(ctx)),
+		zap.String("uri", uri),
+		zap.Int("bytes", size),
+	)
+
+	return true
+}
+
+// readSpilledResult reads a spilled result or a range of it with
+// _meta.range, results of other sessions and expired ones are not found
+func (p *Plugin) readSpilledResult(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+	uri := req.Params.URI
+	id := strings.TrimPrefix(uri, spillURIPrefix)
+
+	spilled, ok := p.spill.get(sessionIDFromContext(ctx), id)
+	if !ok {
+		return nil, mcp.ResourceNotFoundError(uri)
+	}
+
+	rng, err := resourceRange(req.Params.Meta)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(spilled.path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, mcp.ResourceNotFoundError(uri)
+		}
+		return nil, err
+	}
+	defer func() { _ = f.Close() }()
+
+	// Ranges are read from the file, whole reads are not limited
+	offset, length := int64(0), spilled.size
+	if rng != nil {
+		offset, length = rng.Offset, rng.limit(p.cfg.Resources.MaxSize)
+	}
+	data, total, err := readFileRange(f, offset, length)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := &mcp.ResourceContents{URI: uri, MIMEType: spilled.mimeType}
+	if spilled.binary {
+		contents.Blob = data
+	} else {
+		contents.Text = string(trimPartialRune(data, offset+int64(len(data)) < total))
+	}
+
+	result := &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{contents}}
+	if rng != nil {
+		return withRange(result, offset, total), nil
+	}
+	return result, nil
+}
+
+// addSpillTemplate offers the spilled results of a session as resources
+func (p *Plugin) addSpillTemplate() {
+	template := &mcp.ResourceTemplate{
+		URITemplate: spillURITemplate,
+		Name:        "spilled results",
+		Description: "Tool results too large to return, linked from the result",
+	}
+	p.addFeature(featureResource+spillURITemplate,
+		func(s *mcp.Server) { s.AddResourceTemplate(template, p.readSpilledResult) },
+		func(s *mcp.Server) { s.RemoveResourceTemplates(spillURITemplate) },
+	)
+}
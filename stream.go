@@ -0,0 +1,307 @@
+package mcp
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+)
+
+// Message framing of stream transports
+const (
+	FramingNDJSON = "ndjson" // One JSON-RPC message per line
+	FramingLSP    = "lsp"    // Content-Length headers before every message
+)
+
+// errMessageTooLarge is returned for stream messages above the size limit
+var errMessageTooLarge = errors.Str("message exceeds the size limit")
+
+// JSON-RPC error codes of messages that cannot be decoded
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+)
+
+// streamLine is a message read off a stream or the error ending the read
+type streamLine struct {
+	data []byte
+	err  error
+}
+
+// lineReader reads framed messages off a stream in the background.
+// Connections take messages from it without owning the stream, so one can
+// end while the stream stays open for the next.
+type lineReader struct {
+	lines chan streamLine
+	done  chan struct{}
+	once  sync.Once
+}
+
+// newLineReader starts reading messages framed as framing off r, messages
+// above maxSize bytes fail the read, 0 means unlimited
+func newLineReader(r io.Reader, framing string, maxSize int64) *lineReader {
+	lr := &lineReader{
+		lines: make(chan streamLine),
+		done:  make(chan struct{}),
+	}
+
+	read := readLine
+	if framing == FramingLSP {
+		read = readFrame
+	}
+
+	go func() {
+		br := bufio.NewReader(r)
+		for {
+			data, err := read(br, maxSize)
+			if len(bytes.TrimSpace(data)) == 0 && err == nil {
+				continue
+			}
+
+			select {
+			case lr.lines <- streamLine{data: data, err: err}:
+			case <-lr.done:
+				return
+			}
+		}
+	}()
+
+	return lr
+}
+
+// readLine reads one message, a read after io.EOF waits for more input
+func readLine(br *bufio.Reader, maxSize int64) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := br.ReadSlice('\n')
+		line = append(line, chunk...)
+		if maxSize > 0 && int64(len(line)) > maxSize {
+			// Skip the rest of the oversized message
+			for err == bufio.ErrBufferFull {
+				_, err = br.ReadSlice('\n')
+			}
+			return nil, errMessageTooLarge
+		}
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return line, err
+		}
+		return line, nil
+	}
+}
+
+// readFrame reads one message preceded by LSP style headers
+func readFrame(br *bufio.Reader, maxSize int64) ([]byte, error) {
+	length := int64(-1)
+	for {
+		line, err := readLine(br, 1024)
+		if err != nil {
+			return nil, err
+		}
+
+		header := strings.TrimSpace(string(line))
+		if header == "" {
+			if length < 0 {
+				continue
+			}
+			break
+		}
+
+		name, value, ok := strings.Cut(header, ":")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "Content-Length") {
+			continue
+		}
+		length, err = strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+		if err != nil || length < 0 {
+			return nil, errors.Errorf("invalid Content-Length %q", value)
+		}
+	}
+
+	if maxSize > 0 && length > maxSize {
+		if _, err := br.Discard(int(length)); err != nil {
+			return nil, err
+		}
+		return nil, errMessageTooLarge
+	}
+
+	data := make([]byte, length)
+	if _, err := io.ReadFull(br, data); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// next waits for the next message or read error
+func (lr *lineReader) next(ctx context.Context) (streamLine, error) {
+	select {
+	case line := <-lr.lines:
+		return line, nil
+	case <-ctx.Done():
+		return streamLine{}, ctx.Err()
+	}
+}
+
+// stop ends the background read once a pending read returns
+func (lr *lineReader) stop() {
+	lr.once.Do(func() { close(lr.done) })
+}
+
+// streamTransport connects a session to framed JSON-RPC over a
+// stream. The stream is closed with the connection only when closer is set.
+type streamTransport struct {
+	lines   *lineReader
+	framing string
+	w       io.Writer
+	closer  io.Closer
+
+	// Message already taken off the reader, read first
+	first *streamLine
+}
+
+// Connect implements mcp.Transport
+func (t *streamTransport) Connect(context.Context) (mcp.Connection, error) {
+	return &streamConn{
+		lines:   t.lines,
+		pending: t.first,
+		framing: t.framing,
+		w:       t.w,
+		closer:  t.closer,
+		closed:  make(chan struct{}),
+	}, nil
+}
+
+// streamConn is a connection over a streamTransport
+type streamConn struct {
+	lines   *lineReader
+	pending *streamLine
+	framing string
+	closer  io.Closer
+
+	wmu sync.Mutex
+	w   io.Writer
+
+	closed    chan struct{}
+	closeOnce sync.Once
+	closeErr  error
+}
+
+// Read implements mcp.Connection, Close unblocks a pending read
+func (c *streamConn) Read(ctx context.Context) (jsonrpc.Message, error) {
+	for {
+		if line := c.pending; line != nil {
+			c.pending = nil
+			if msg, err := c.decodeLine(*line); msg != nil || err != nil {
+				return msg, err
+			}
+			continue
+		}
+
+		select {
+		case line := <-c.lines.lines:
+			if msg, err := c.decodeLine(line); msg != nil || err != nil {
+				return msg, err
+			}
+		case <-c.closed:
+			return nil, io.EOF
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// decodeLine decodes a message read off the stream. A malformed message that
+// did not end the stream is answered with a parse or invalid request error
+// and returns neither message nor error
+func (c *streamConn) decodeLine(line streamLine) (jsonrpc.Message, error) {
+	data := bytes.TrimSpace(line.data)
+	if len(data) == 0 {
+		return nil, line.err
+	}
+
+	msg, err := jsonrpc.DecodeMessage(data)
+	if err == nil {
+		return msg, nil
+	}
+	if line.err != nil {
+		return nil, line.err
+	}
+
+	// The id of a message that cannot be decoded is unknown, it is answered with null
+	code, message := int64(codeInvalidRequest), "Invalid Request"
+	if !json.Valid(data) {
+		code, message = codeParseError, "Parse error"
+	}
+	reply, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      nil,
+		"error":   &ToolError{Code: code, Message: message},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, c.writeFrame(reply)
+}
+
+// Write implements mcp.Connection
+func (c *streamConn) Write(_ context.Context, msg jsonrpc.Message) error {
+	data, err := jsonrpc.EncodeMessage(msg)
+	if err != nil {
+		return err
+	}
+
+	return c.writeFrame(data)
+}
+
+// writeFrame writes an encoded message framed for the stream
+func (c *streamConn) writeFrame(data []byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
+	select {
+	case <-c.closed:
+		return io.ErrClosedPipe
+	default:
+	}
+
+	if c.framing == FramingLSP {
+		if _, err := fmt.Fprintf(c.w, "Content-Length: %d\r\n\r\n", len(data)); err != nil {
+			return err
+		}
+		_, err := c.w.Write(data)
+		return err
+	}
+
+	_, err := c.w.Write(append(data, '\n'))
+	return err
+}
+
+// Close implements mcp.Connection, the stream stays open unless the
+// connection owns it
+func (c *streamConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closed)
+		if c.closer != nil {
+			c.lines.stop()
+			c.closeErr = c.closer.Close()
+		}
+	})
+	return c.closeErr
+}
+
+// SessionID implements mcp.Connection
+func (c *streamConn) SessionID() string {
+	return ""
+}
@@ -0,0 +1,184 @@
+package mcp
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/roadrunner-server/errors"
+)
+
+// validateStrict fails on keys under the mcp section no setting decodes and
+// on settings that contradict each other, it runs before defaults are applied
+func (c *Config) validateStrict(raw map[string]interface{}) error {
+	const op = errors.Op("mcp_config_strict")
+
+	if unknown := unknownKeys(PluginName, raw, reflect.TypeOf(c).Elem()); len(unknown) > 0 {
+		return errors.E(op, errors.Errorf("unknown configuration keys: %s", strings.Join(unknown, ", ")))
+	}
+
+	if c.Transport == "stdio" && c.TLS != nil {
+		return errors.E(op, errors.Str("mcp.tls: TLS is not used by the stdio transport"))
+	}
+
+	if c.Transport == "stdio" || c.Transport == transportTCP {
+		switch {
+		case len(c.Servers) > 0:
+			return errors.E(op, errors.Str("mcp.servers: logical servers require the sse transport"))
+		case c.REST.Enabled:
+			return errors.E(op, errors.Str("mcp.rest.enabled: REST endpoints require the sse transport"))
+		case c.Webhooks.Enabled:
+			return errors.E(op, errors.Str("mcp.webhooks.enabled: webhooks require the sse transport"))
+		case c.Clients.ResumeGrace > 0:
+			return errors.E(op, errors.Str("mcp.clients.resume_grace: resuming sessions requires the sse transport"))
+		}
+	}
+
+	if c.Transport != "stdio" && c.Stdio.RestartOnDisconnect {
+		return errors.E(op, errors.Str("mcp.stdio.restart_on_disconnect: requires the stdio transport"))
+	}
+
+	if c.Transport != transportTCP && c.TCP.Framing != "" {
+		return errors.E(op, errors.Str("mcp.tcp.framing: requires the tcp transport"))
+	}
+
+	if c.Readiness.DelayListeners && c.Readiness.MinWorkers == 0 {
+		return errors.E(op, errors.Str("mcp.readiness.delay_listeners: requires readiness.min_workers"))
+	}
+
+	if conflicts := c.supervisorConflicts(); len(conflicts) > 0 {
+		return errors.E(op, errors.Str("mcp."+conflicts[0]))
+	}
+
+	return nil
+}
+
+// unknownKeys returns the paths of the keys in raw that no field of t
+// decodes, following mapstructure's case-insensitive name matching
+func unknownKeys(path string, raw interface{}, t reflect.Type) []string {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var unknown []string
+
+	switch t.Kind() {
+	case reflect.Struct:
+		values, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+
+		fields := structKeys(t)
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		slices.Sort(keys)
+
+		for _, key := range keys {
+			field, ok := fields[strings.ToLower(key)]
+			if !ok {
+				unknown = append(unknown, path+"."+key+suggestKey(key, fields))
+				continue
+			}
+			unknown = append(unknown, unknownKeys(path+"."+key, values[key], field)...)
+		}
+
+	case reflect.Map:
+		values, ok := raw.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		for key, value := range values {
+			unknown = append(unknown, unknownKeys(path+"."+key, value, t.Elem())...)
+		}
+		slices.Sort(unknown)
+
+	case reflect.Slice, reflect.Array:
+		items, ok := raw.([]interface{})
+		if !ok {
+			return nil
+		}
+		for i, item := range items {
+			unknown = append(unknown, unknownKeys(fmt.Sprintf("%s[%d]", path, i), item, t.Elem())...)
+		}
+	}
+
+	return unknown
+}
+
+// structKeys maps the lowercased keys a struct decodes to their field types,
+// squashed embedded structs contribute their own keys
+func structKeys(t reflect.Type) map[string]reflect.Type {
+	keys := make(map[string]reflect.Type, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(field.Tag.Get("mapstructure"), ",")
+		if name == "-" {
+			continue
+		}
+		if strings.Contains(opts, "squash") {
+			embedded := field.Type
+			for embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			for key, typ := range structKeys(embedded) {
+				keys[key] = typ
+			}
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		keys[strings.ToLower(name)] = field.Type
+	}
+
+	return keys
+}
+
+// suggestKey names the closest known key when the unknown one looks like a typo
+func suggestKey(key string, fields map[string]reflect.Type) string {
+	best, bestDistance := "", 3
+	for known := range fields {
+		if d := editDistance(strings.ToLower(key), known); d < bestDistance || d == bestDistance && known < best {
+			best, bestDistance = known, d
+		}
+	}
+
+	if best == "" {
+		return ""
+	}
+
+	return fmt.Sprintf(" (did you mean %q?)", best)
+}
+
+// editDistance is the Levenshtein distance of two keys
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
@@ -0,0 +1,113 @@
+package mcp
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"github.com/roadrunner-server/pool/pool"
+	"go.uber.org/zap"
+)
+
+// poolConfigs returns the configured worker pools by their config path
+func (c *Config) poolConfigs() map[string]*pool.Config {
+	pools := make(map[string]*pool.Config)
+	if c.Pool != nil {
+		pools["pool"] = c.Pool
+	}
+	for id, tenant := range c.Tenants {
+		if tenant != nil && tenant.Pool != nil {
+			pools["tenants."+id+".pool"] = tenant.Pool
+		}
+	}
+	if c.Canary.Pool != nil {
+		pools["canary.pool"] = c.Canary.Pool
+	}
+	if c.Shadow.Pool != nil {
+		pools["shadow.pool"] = c.Shadow.Pool
+	}
+	return pools
+}
+
+// validateSupervisors checks the supervisor settings of every pool
+func (c *Config) validateSupervisors() error {
+	pools := c.poolConfigs()
+	for _, path := range sortedKeys(pools) {
+		sv := pools[path].Supervisor
+		if sv == nil {
+			continue
+		}
+
+		for key, d := range map[string]time.Duration{
+			"watch_tick": sv.WatchTick,
+			"ttl":        sv.TTL,
+			"idle_ttl":   sv.IdleTTL,
+			"exec_ttl":   sv.ExecTTL,
+		} {
+			if d < 0 {
+				return errors.Errorf("%s.supervisor.%s must not be negative", path, key)
+			}
+		}
+	}
+
+	return nil
+}
+
+// supervisorConflicts lists supervisor settings that kill workers in the
+// middle of tool calls: exec_ttl kills a busy worker, unlike ttl, idle_ttl
+// and max_worker_memory which wait for the call to finish. Calls running
+// into it fail with a worker crash instead of a timeout.
+func (c *Config) supervisorConflicts() []string {
+	var conflicts []string
+
+	pools := c.poolConfigs()
+	for _, path := range sortedKeys(pools) {
+		// Debug pools run without a supervisor
+		sv := pools[path].Supervisor
+		if sv == nil || sv.ExecTTL <= 0 || pools[path].Debug {
+			continue
+		}
+
+		switch {
+		case c.Tools.CallTimeout == 0:
+			conflicts = append(conflicts, fmt.Sprintf("%s.supervisor.exec_ttl (%s) kills workers of calls without a deadline, set tools.call_timeout below it", path, sv.ExecTTL))
+		case sv.ExecTTL < c.Tools.CallTimeout:
+			conflicts = append(conflicts, fmt.Sprintf("%s.supervisor.exec_ttl (%s) is shorter than tools.call_timeout (%s), workers are killed before calls time out", path, sv.ExecTTL, c.Tools.CallTimeout))
+		}
+
+		for _, name := range sortedKeys(c.Tools.Overrides) {
+			override := c.Tools.Overrides[name]
+			if override != nil && override.Timeout > sv.ExecTTL {
+				conflicts = append(conflicts, fmt.Sprintf("%s.supervisor.exec_ttl (%s) is shorter than the timeout of tool %q (%s), workers are killed before calls time out", path, sv.ExecTTL, name, override.Timeout))
+			}
+		}
+	}
+
+	return conflicts
+}
+
+// logSupervisors logs the supervisor settings of every pool and warns about
+// settings that kill workers in the middle of tool calls
+func (p *Plugin) logSupervisors() {
+	pools := p.cfg.poolConfigs()
+	for _, path := range sortedKeys(pools) {
+		sv := pools[path].Supervisor
+		if sv == nil {
+			p.log.Debug("worker pool without supervisor", zap.String("pool", path))
+			continue
+		}
+
+		p.log.Debug("worker pool supervisor",
+			zap.String("pool", path),
+			zap.Duration("watch_tick", sv.WatchTick),
+			zap.Duration("ttl", sv.TTL),
+			zap.Duration("idle_ttl", sv.IdleTTL),
+			zap.Duration("exec_ttl", sv.ExecTTL),
+			zap.Uint64("max_worker_memory", sv.MaxWorkerMemory),
+		)
+	}
+
+	for _, conflict := range p.cfg.supervisorConflicts() {
+		p.log.Warn("pool supervisor conflicts with tool timeouts", zap.String("conflict", conflict))
+	}
+}
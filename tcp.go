@@ -0,0 +1,168 @@
+package mcp
+
+import (
+	"context"
+	"crypto/tls"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// transportTCP serves JSON-RPC over raw TCP connections
+const transportTCP = "tcp"
+
+// serveTCP accepts clients speaking framed JSON-RPC on a TCP socket, unix
+// socket or named pipe, every connection is one session. Connections beyond
+// clients.max_connections are closed right away.
+func (p *Plugin) serveTCP() error {
+	const op = errors.Op("mcp_serve_tcp")
+
+	ln, certs, err := listenTLS(p.cfg.Address, p.cfg.TLS, p.cfg.Clients.KeepAlive, nil)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// The listener may be swapped at runtime, see swapListener
+	p.mu.Lock()
+	p.tcpListener = ln
+	p.listener = &transportListener{address: p.cfg.Address, tls: p.cfg.TLS, certs: certs}
+	p.mu.Unlock()
+
+	p.log.Info("TCP transport listening",
+		zap.String("address", ln.Addr().String()),
+		zap.String("framing", p.cfg.TCP.Framing),
+	)
+
+	defer p.watchCertificates(certs)()
+
+	return p.acceptTCP(ln)
+}
+
+// acceptTCP serves the clients of a TCP transport listener until it closes,
+// clients.max_connections is shared by all listeners
+func (p *Plugin) acceptTCP(ln net.Listener) error {
+	const op = errors.Op("mcp_accept_tcp")
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if p.ctx.Err() != nil || stderrors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return errors.E(op, err)
+		}
+
+		select {
+		case p.tcpSlots <- struct{}{}:
+		default:
+			p.log.Warn("TCP connection rejected, too many connections",
+				zap.String("remote_addr", conn.RemoteAddr().String()),
+			)
+			_ = conn.Close()
+			continue
+		}
+
+		go func() {
+			defer func() { <-p.tcpSlots }()
+			p.serveTCPConn(conn)
+		}()
+	}
+}
+
+// serveTCPConn serves one TCP client until it disconnects
+func (p *Plugin) serveTCPConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	credentials := map[string]string{"ip": conn.RemoteAddr().String()}
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		ctx, cancel := context.WithTimeout(p.ctx, p.cfg.Clients.ReadTimeout)
+		err := tlsConn.HandshakeContext(ctx)
+		cancel()
+		if err != nil {
+			p.log.Debug("TCP TLS handshake failed",
+				zap.String("remote_addr", conn.RemoteAddr().String()),
+				zap.Error(err),
+			)
+			return
+		}
+		if chains := tlsConn.ConnectionState().VerifiedChains; len(chains) > 0 {
+			credentials["client_cert_subject"] = chains[0][0].Subject.String()
+		}
+	}
+
+	if p.authMode("", transportTCP) == AuthModeMTLS && credentials["client_cert_subject"] == "" {
+		p.errorCounts.add(errorClassAuth)
+		p.log.Warn("TCP connection rejected, client certificate required",
+			zap.String("remote_addr", conn.RemoteAddr().String()),
+		)
+		return
+	}
+
+	// Generate session ID
+	sessionID := uuid.New().String()
+
+	// Track session, authentication happens on initialize
+	credentialsMap := make(map[string]interface{}, len(credentials))
+	for k, v := range credentials {
+		credentialsMap[k] = v
+	}
+	p.trackSession(sessionID, transportTCP, credentials, credentialsMap)
+
+	p.log.Info("TCP client connected",
+		zap.String("session_id", sessionID),
+		zap.String("remote_addr", conn.RemoteAddr().String()),
+	)
+
+	defer func() {
+		p.removeSession(sessionID)
+		p.log.Info("TCP client disconnected", zap.String("session_id", sessionID))
+	}()
+
+	transport := &streamTransport{
+		lines:   newLineReader(conn, p.cfg.TCP.Framing, p.cfg.Limits.MaxRequestSize),
+		framing: p.cfg.TCP.Framing,
+		w:       &deadlineWriter{conn: conn, timeout: p.cfg.Clients.WriteTimeout},
+		closer:  conn,
+	}
+
+	ss, err := p.connServer(sessionID).Connect(withSessionID(p.ctx, sessionID), transport, nil)
+	if err != nil {
+		p.log.Error("failed to connect TCP transport",
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+		)
+		return
+	}
+
+	// Block until the client closes the connection or the plugin stops
+	stop := context.AfterFunc(p.ctx, func() { _ = ss.Close() })
+	defer stop()
+
+	if err := ss.Wait(); err != nil && !stderrors.Is(err, io.EOF) && !stderrors.Is(err, context.Canceled) && !stderrors.Is(err, net.ErrClosed) {
+		p.log.Debug("TCP session ended",
+			zap.String("session_id", sessionID),
+			zap.Error(err),
+		)
+	}
+}
+
+// deadlineWriter bounds every write to a connection by a timeout, a client
+// not reading its responses is disconnected
+type deadlineWriter struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (w *deadlineWriter) Write(data []byte) (int, error) {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(w.timeout)); err != nil {
+		return 0, fmt.Errorf("failed to set write deadline: %w", err)
+	}
+	return w.conn.Write(data)
+}
@@ -0,0 +1,343 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// tenantSeparator joins the registered name of a tenant's tool or prompt
+// with the tenant ID, tenant sessions see the name without the suffix
+const tenantSeparator = "@"
+
+// tenantIDPattern restricts tenant IDs to characters safe in names and labels
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// validTenantID reports whether id can be used as a tenant ID
+func validTenantID(id string) bool {
+	return tenantIDPattern.MatchString(id)
+}
+
+// tenantName returns the registered name of a tenant's tool or prompt
+func tenantName(tenant, name string) string {
+	if tenant == "" {
+		return name
+	}
+	return name + tenantSeparator + tenant
+}
+
+// tenantCtxKey carries the tenant of a called tool to the worker pool selection
+type tenantCtxKey struct{}
+
+// withTenant binds a tenant to a handler context
+func withTenant(ctx context.Context, tenant string) context.Context {
+	if tenant == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, tenantCtxKey{}, tenant)
+}
+
+// tenantFromContext returns the tenant bound to a handler context
+func tenantFromContext(ctx context.Context) string {
+	if tenant, ok := ctx.Value(tenantCtxKey{}).(string); ok {
+		return tenant
+	}
+	return ""
+}
+
+// sessionTenant returns the tenant of a session
+func (p *Plugin) sessionTenant(sessionID string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if info, ok := p.sessions.get(sessionID); ok {
+		return info.Tenant
+	}
+
+	return ""
+}
+
+// tenantMiddleware isolates sessions to their tenant's registry: tenant tools
+// and prompts are listed and called by their declared names, features of
+// other tenants are hidden, and tool calls are rate limited per tenant
+func (p *Plugin) tenantMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		switch method {
+		case "tools/list", "tools/call", "prompts/list", "prompts/get", "resources/list", "resources/read":
+		default:
+			return next(ctx, method, req)
+		}
+
+		tenant := p.sessionTenant(sessionIDFromContext(ctx))
+
+		switch params := req.GetParams().(type) {
+		case *mcp.CallToolParamsRaw:
+			if !p.rateLimiter.allow(tenant) {
+				p.callLogger(ctx).Debug("tool call rate limited",
+					zap.String("tenant", tenant),
+					zap.String("tool", params.Name),
+				)
+				return nil, jsonRPCError(p.countError(errorClassRateLimited, errors.Str("rate limit exceeded, retry later")))
+			}
+
+			name, ok := p.resolveTenantTool(tenant, params.Name)
+			if !ok {
+				return nil, newJSONRPCError(codeInvalidParams, fmt.Sprintf("unknown tool %q", params.Name), nil)
+			}
+			params.Name = name
+
+		case *mcp.GetPromptParams:
+			name, ok := p.resolveTenantPrompt(tenant, params.Name)
+			if !ok {
+				return nil, newJSONRPCError(codeInvalidParams, fmt.Sprintf("unknown prompt %q", params.Name), nil)
+			}
+			params.Name = name
+
+		case *mcp.ReadResourceParams:
+			if owner := p.resourceTenant(params.URI); owner != "" && owner != tenant {
+				return nil, mcp.ResourceNotFoundError(params.URI)
+			}
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		switch res := result.(type) {
+		case *mcp.ListToolsResult:
+			res.Tools = p.tenantTools(tenant, res.Tools)
+		case *mcp.ListPromptsResult:
+			res.Prompts = p.tenantPrompts(tenant, res.Prompts)
+		case *mcp.ListResourcesResult:
+			resources := make([]*mcp.Resource, 0, len(res.Resources))
+			for _, resource := range res.Resources {
+				if owner := p.resourceTenant(resource.URI); owner == "" || owner == tenant {
+					resources = append(resources, resource)
+				}
+			}
+			res.Resources = resources
+		}
+
+		return result, nil
+	}
+}
+
+// resolveTenantTool returns the registered name of a tool called by a
+// session, tenant tools shadow shared tools of the same name
+func (p *Plugin) resolveTenantTool(tenant, name string) (string, bool) {
+	tools := p.toolSnapshot()
+
+	if tenant != "" {
+		if _, ok := tools[tenantName(tenant, name)]; ok {
+			return tenantName(tenant, name), true
+		}
+	}
+
+	// Tools of tenants are only reachable by their declared name
+	if entry, ok := tools[name]; ok && entry.Tenant != "" {
+		return "", false
+	}
+
+	return name, true
+}
+
+// tenantTools returns the tools visible to a tenant under their declared names
+func (p *Plugin) tenantTools(tenant string, tools []*mcp.Tool) []*mcp.Tool {
+	registered := p.toolSnapshot()
+
+	visible := make([]*mcp.Tool, 0, len(tools))
+	for _, tool := range tools {
+		entry, ok := registered[tool.Name]
+		switch {
+		case !ok || entry.Tenant == "":
+			if tenant != "" {
+				if _, shadowed := registered[tenantName(tenant, tool.Name)]; shadowed {
+					continue
+				}
+			}
+			visible = append(visible, tool)
+		case entry.Tenant == tenant:
+			t := *tool
+			t.Name = strings.TrimSuffix(tool.Name, tenantSeparator+tenant)
+			visible = append(visible, &t)
+		}
+	}
+
+	return visible
+}
+
+// promptTenant returns the tenant owning a registered prompt
+func (p *Plugin) promptTenant(name string) string {
+	for _, up := range p.upstreams {
+		if _, ok := up.prompts[name]; ok {
+			return up.cfg.Tenant
+		}
+	}
+	return ""
+}
+
+// resolveTenantPrompt returns the registered name of a prompt requested by a session
+func (p *Plugin) resolveTenantPrompt(tenant, name string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if tenant != "" {
+		for _, up := range p.upstreams {
+			if _, ok := up.prompts[tenantName(tenant, name)]; ok {
+				return tenantName(tenant, name), true
+			}
+		}
+	}
+
+	if p.promptTenant(name) != "" {
+		return "", false
+	}
+
+	return name, true
+}
+
+// tenantPrompts returns the prompts visible to a tenant under their upstream names
+func (p *Plugin) tenantPrompts(tenant string, prompts []*mcp.Prompt) []*mcp.Prompt {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	visible := make([]*mcp.Prompt, 0, len(prompts))
+	for _, prompt := range prompts {
+		switch owner := p.promptTenant(prompt.Name); owner {
+		case "":
+			visible = append(visible, prompt)
+		case tenant:
+			pr := *prompt
+			pr.Name = strings.TrimSuffix(prompt.Name, tenantSeparator+tenant)
+			visible = append(visible, &pr)
+		}
+	}
+
+	return visible
+}
+
+// resourceTenant returns the tenant owning a resource
+func (p *Plugin) resourceTenant(uri string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for _, up := range p.upstreams {
+		if _, ok := up.resources[uri]; ok {
+			return up.cfg.Tenant
+		}
+	}
+
+	return ""
+}
+
+// poolFor returns the worker pool serving a tenant
+func (p *Plugin) poolFor(tenant string) Pool {
+	if pool, ok := p.tenantPools[tenant]; ok && tenant != "" {
+		return pool
+	}
+	return p.pool
+}
+
+// createTenantPools starts the dedicated pools of tenants, must be called under lock
+func (p *Plugin) createTenantPools() error {
+	for id, tenant := range p.cfg.Tenants {
+		if tenant == nil || tenant.Pool == nil {
+			continue
+		}
+
+		pool, err := p.server.NewPool(
+			p.ctx,
+			tenant.Pool,
+			p.workerEnv(map[string]string{"RR_MCP_TENANT": id}),
+			p.log.Named(id),
+		)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", id, err)
+		}
+
+		p.tenantPools[id] = pool
+	}
+
+	return nil
+}
+
+// rateLimiter keeps a token bucket per key, a tenant or a tool
+type rateLimiter struct {
+	mu      sync.Mutex
+	limit   RateLimitConfig
+	limits  map[string]*RateLimitConfig // key -> limit replacing the default
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket is the state of a single limit
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter creates a limiter for the default and per key limits
+func newRateLimiter(limit RateLimitConfig, limits map[string]*RateLimitConfig) *rateLimiter {
+	return &rateLimiter{
+		limit:   limit,
+		limits:  limits,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// tenantRateLimits returns the limits tenants replace the default with
+func tenantRateLimits(tenants map[string]*TenantConfig) map[string]*RateLimitConfig {
+	limits := make(map[string]*RateLimitConfig)
+	for id, cfg := range tenants {
+		if cfg != nil && cfg.RateLimit != nil {
+			limits[id] = cfg.RateLimit
+		}
+	}
+	return limits
+}
+
+// allow takes a token from the key's bucket
+func (l *rateLimiter) allow(key string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		limit := l.limit
+		if cfg, ok := l.limits[key]; ok {
+			limit = *cfg
+		}
+		if limit.Rate == 0 {
+			return true
+		}
+
+		burst := float64(limit.Burst)
+		if burst == 0 {
+			burst = math.Ceil(limit.Rate)
+		}
+
+		bucket = &tokenBucket{rate: limit.Rate, burst: burst, tokens: burst, last: time.Now()}
+		l.buckets[key] = bucket
+	}
+
+	now := time.Now()
+	bucket.tokens = math.Min(bucket.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*bucket.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+
+	return true
+}
@@ -0,0 +1,162 @@
+package mcp_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	mcpserver "github.com/roadrunner-plugins/mcp-server"
+	"github.com/roadrunner-plugins/mcp-server/mcptest"
+)
+
+// newTenantHarness runs the plugin with tenants a and b, sessions belong to
+// the tenant passed as their "tenant" credential
+func newTenantHarness(t *testing.T, cfg *mcpserver.Config) *mcptest.Harness {
+	t.Helper()
+
+	if cfg == nil {
+		cfg = &mcpserver.Config{}
+	}
+	cfg.Auth.Enabled = true
+	cfg.Tenants = map[string]*mcpserver.TenantConfig{"a": {}, "b": {}}
+
+	h := mcptest.New(t, cfg)
+	h.Worker.HandleAuth(func(_ context.Context, client *mcpserver.ClientConnectedPayload) (*mcpserver.ClientConnectedResponse, error) {
+		return &mcpserver.ClientConnectedResponse{Allowed: true, Tenant: client.Credentials["tenant"]}, nil
+	})
+
+	return h
+}
+
+func declareTenantTool(h *mcptest.Harness, tenant, name string) {
+	h.DeclareTools(&mcpserver.DeclareToolsRequest{
+		Tenant: tenant,
+		Tools: []mcpserver.ToolDefinition{{
+			Name:        name,
+			Description: "tool of " + tenant,
+			InputSchema: map[string]interface{}{"type": "object"},
+		}},
+	})
+}
+
+func toolNames(tools []*mcp.Tool) []string {
+	names := make([]string, 0, len(tools))
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	return names
+}
+
+func TestTenantToolsOfOtherTenants(t *testing.T) {
+	h := newTenantHarness(t, nil)
+	declareTenantTool(h, "b", "export")
+	h.Worker.HandleTool("export", func(context.Context, *mcpserver.CallToolPayload) (*mcpserver.CallToolResponse, error) {
+		return mcptest.Text("exported"), nil
+	})
+
+	a := h.Connect(map[string]string{"tenant": "a"})
+	if names := toolNames(a.ListTools()); slices.Contains(names, "export") || slices.Contains(names, "export@b") {
+		t.Errorf("tenant a lists the tool of tenant b: %v", names)
+	}
+	for _, name := range []string{"export", "export@b"} {
+		if _, err := a.CallToolErr(name, map[string]interface{}{}); err == nil || !strings.Contains(err.Error(), "unknown tool") {
+			t.Errorf("tenant a calling %q: err = %v, want unknown tool", name, err)
+		}
+	}
+
+	b := h.Connect(map[string]string{"tenant": "b"})
+	if names := toolNames(b.ListTools()); !slices.Equal(names, []string{"export"}) {
+		t.Errorf("tenant b lists %v, want [export]", names)
+	}
+	if _, err := b.CallToolErr("export@b", map[string]interface{}{}); err == nil {
+		t.Error("tenant b called its tool by the qualified name")
+	}
+	if res := b.CallTool("export", map[string]interface{}{}); res.IsError {
+		t.Errorf("tenant b calling its tool failed: %+v", res.Content)
+	}
+}
+
+func TestTenantToolShadowsSharedTool(t *testing.T) {
+	h := newTenantHarness(t, nil)
+	declareTenantTool(h, "", "lookup")
+	declareTenantTool(h, "a", "lookup")
+	h.Worker.HandleTool("lookup", func(_ context.Context, call *mcpserver.CallToolPayload) (*mcpserver.CallToolResponse, error) {
+		return mcptest.Text("tenant=" + call.Tenant), nil
+	})
+
+	tests := []struct {
+		tenant      string
+		description string
+		text        string
+	}{
+		{tenant: "a", description: "tool of a", text: "tenant=a"},
+		{tenant: "b", description: "tool of ", text: "tenant="},
+	}
+
+	for _, tt := range tests {
+		t.Run("tenant "+tt.tenant, func(t *testing.T) {
+			c := h.Connect(map[string]string{"tenant": tt.tenant})
+
+			tools := c.ListTools()
+			if len(tools) != 1 || tools[0].Name != "lookup" || tools[0].Description != tt.description {
+				t.Fatalf("tools = %v, want one lookup described %q", toolNames(tools), tt.description)
+			}
+
+			res := c.CallTool("lookup", map[string]interface{}{})
+			if text := res.Content[0].(*mcp.TextContent).Text; text != tt.text {
+				t.Errorf("call answered %q, want %q", text, tt.text)
+			}
+		})
+	}
+}
+
+func TestTenantResourcesOfOtherTenants(t *testing.T) {
+	const uri = "docs://b/handbook"
+
+	upstream := mcp.NewServer(&mcp.Implementation{Name: "docs", Version: "1.0.0"}, nil)
+	upstream.AddResource(&mcp.Resource{URI: uri, Name: "handbook"}, func(_ context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		return &mcp.ReadResourceResult{Contents: []*mcp.ResourceContents{{URI: req.Params.URI, Text: "handbook"}}}, nil
+	})
+	srv := httptest.NewServer(mcp.NewStreamableHTTPHandler(func(*http.Request) *mcp.Server { return upstream }, nil))
+	t.Cleanup(srv.Close)
+
+	cfg := &mcpserver.Config{}
+	cfg.Upstreams = map[string]*mcpserver.UpstreamConfig{"docs": {URL: srv.URL, Tenant: "b"}}
+	h := newTenantHarness(t, cfg)
+
+	b := h.Connect(map[string]string{"tenant": "b"})
+	// Upstreams are mounted in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		res, err := b.Session.ListResources(context.Background(), nil)
+		if err == nil && len(res.Resources) == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("upstream resource not mounted: %v, %v", res, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, err := b.Session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: uri}); err != nil {
+		t.Fatalf("tenant b reading its resource: %v", err)
+	}
+
+	a := h.Connect(map[string]string{"tenant": "a"})
+	res, err := a.Session.ListResources(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("list resources: %v", err)
+	}
+	if len(res.Resources) != 0 {
+		t.Errorf("tenant a lists resources of tenant b: %v", res.Resources)
+	}
+
+	_, err = a.Session.ReadResource(context.Background(), &mcp.ReadResourceParams{URI: uri})
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("tenant a reading the resource of tenant b: err = %v, want not found", err)
+	}
+}
@@ -0,0 +1,136 @@
+package mcp
+
+import (
+	"sync"
+	"time"
+
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// revokedTokens rejects revoked client and session tokens until they expire
+type revokedTokens struct {
+	mu     sync.Mutex
+	ttl    time.Duration
+	tokens map[string]time.Time // token -> revoked at
+}
+
+func newRevokedTokens(ttl time.Duration) *revokedTokens {
+	return &revokedTokens{ttl: ttl, tokens: make(map[string]time.Time)}
+}
+
+// add revokes a token, expired revocations are dropped
+func (r *revokedTokens) add(token string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for t, revokedAt := range r.tokens {
+		if now.Sub(revokedAt) > r.ttl {
+			delete(r.tokens, t)
+		}
+	}
+
+	r.tokens[token] = now
+}
+
+// has reports whether a token is revoked
+func (r *revokedTokens) has(token string) bool {
+	if token == "" {
+		return false
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	revokedAt, ok := r.tokens[token]
+	return ok && time.Since(revokedAt) <= r.ttl
+}
+
+// revokeToken revokes a client or session token and closes the sessions using it
+func (p *Plugin) revokeToken(token string) []string {
+	p.revoked.add(token)
+
+	p.mu.Lock()
+	var closed []string
+	var closers []func() error
+	for id, info := range p.sessions.snapshot() {
+		if info.Token != token && info.Credentials["token"] != token {
+			continue
+		}
+
+		closed = append(closed, id)
+		if info.Session != nil {
+			closers = append(closers, info.Session.Close)
+		} else {
+			// The handshake has not completed, nothing to close
+			p.sessions.delete(id)
+		}
+	}
+	p.mu.Unlock()
+
+	// Closing ends the transport, which removes the session
+	for _, closeSession := range closers {
+		_ = closeSession()
+	}
+
+	p.log.Info("token revoked", zap.Int("sessions", len(closed)))
+
+	p.notifyPHP(EventTokenRevoked, &TokenRevokedPayload{Token: token, Sessions: closed})
+
+	return closed
+}
+
+// rotateSessionToken replaces the token of a session, the previous one is revoked
+func (p *Plugin) rotateSessionToken(sessionID, token string) (string, error) {
+	const op = errors.Op("mcp_rotate_session_token")
+
+	if token == "" {
+		var err error
+		token, err = generateToken()
+		if err != nil {
+			return "", errors.E(op, err)
+		}
+	}
+
+	p.mu.Lock()
+	info, ok := p.sessions.get(sessionID)
+	if !ok {
+		p.mu.Unlock()
+		return "", errors.E(op, errors.Errorf("unknown session: %s", sessionID))
+	}
+	previous := info.Token
+	info.Token = token
+	p.mu.Unlock()
+
+	if previous != "" {
+		p.revoked.add(previous)
+	}
+
+	p.log.Info("session token rotated", zap.String("session_id", sessionID))
+
+	p.notifyPHP(EventSessionTokenRotated, &SessionTokenRotatedPayload{
+		SessionID:     sessionID,
+		PreviousToken: previous,
+		Token:         token,
+	})
+
+	return token, nil
+}
+
+// notifyPHP sends an informational event to PHP in the background, the
+// caller may be the only worker of the pool
+func (p *Plugin) notifyPHP(event string, payload interface{}) {
+	if p.cfg.Mode == ModeMock {
+		return
+	}
+
+	go func() {
+		if _, err := p.sendEvent(p.ctx, "", event, payload); err != nil {
+			p.log.Warn("failed to notify worker",
+				zap.String("event", event),
+				zap.Error(err),
+			)
+		}
+	}()
+}
@@ -0,0 +1,137 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"regexp"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+)
+
+// W3C trace context and baggage headers, also accepted as _meta keys
+var traceHeaders = []string{"traceparent", "tracestate", "baggage"}
+
+// validTraceparent matches a version 00 traceparent
+var validTraceparent = regexp.MustCompile(`^[0-9a-f]{2}-[0-9a-f]{32}-[0-9a-f]{16}-[0-9a-f]{2}$`)
+
+// traceCtxKey carries the trace headers of a tool call
+type traceCtxKey struct{}
+
+// traceFromContext returns the trace headers of a tool call
+func traceFromContext(ctx context.Context) map[string]string {
+	trace, _ := ctx.Value(traceCtxKey{}).(map[string]string)
+	return trace
+}
+
+// traceContext collects the trace headers from a source, a missing or
+// invalid traceparent drops the trace context but keeps baggage
+func traceContext(get func(name string) string) map[string]string {
+	trace := make(map[string]string)
+	for _, name := range traceHeaders {
+		if value := get(name); value != "" {
+			trace[name] = value
+		}
+	}
+	if !validTraceparent.MatchString(trace["traceparent"]) {
+		delete(trace, "traceparent")
+		delete(trace, "tracestate")
+	}
+	return trace
+}
+
+// injectTrace copies the trace headers of a message POST into the _meta of
+// the posted tool call, where the SDK passes them on to the method handlers
+func injectTrace(r *http.Request) {
+	trace := traceContext(r.Header.Get)
+	if len(trace) == 0 || r.Body == nil {
+		return
+	}
+
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		// The SDK reports the failed read
+		r.Body = io.NopCloser(errReader{err})
+		return
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+
+	// Only tool calls reach workers
+	var msg map[string]json.RawMessage
+	var method string
+	if json.Unmarshal(body, &msg) != nil || json.Unmarshal(msg["method"], &method) != nil || method != "tools/call" {
+		return
+	}
+
+	var params map[string]json.RawMessage
+	if raw := msg["params"]; raw != nil && json.Unmarshal(raw, &params) != nil {
+		return
+	}
+	if params == nil {
+		params = make(map[string]json.RawMessage)
+	}
+
+	var meta map[string]interface{}
+	if raw := params["_meta"]; raw != nil && json.Unmarshal(raw, &meta) != nil {
+		return
+	}
+	if meta == nil {
+		meta = make(map[string]interface{})
+	}
+
+	// Trace context set by the client in _meta wins
+	for name, value := range trace {
+		if _, ok := meta[name]; !ok {
+			meta[name] = value
+		}
+	}
+
+	var ok bool
+	if params["_meta"], ok = marshalRaw(meta); !ok {
+		return
+	}
+	if msg["params"], ok = marshalRaw(params); !ok {
+		return
+	}
+	rewritten, ok := marshalRaw(msg)
+	if !ok {
+		return
+	}
+
+	r.Body = io.NopCloser(bytes.NewReader(rewritten))
+	r.ContentLength = int64(len(rewritten))
+}
+
+// marshalRaw encodes a value, reporting whether it succeeded
+func marshalRaw(v interface{}) (json.RawMessage, bool) {
+	data, err := json.Marshal(v)
+	return data, err == nil
+}
+
+// errReader fails reads with an error
+type errReader struct{ err error }
+
+func (r errReader) Read([]byte) (int, error) { return 0, r.err }
+
+// traceMiddleware binds the trace context of a tool call from its _meta, so
+// it is forwarded to workers as headers
+func (p *Plugin) traceMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.CallToolParamsRaw)
+		if method != "tools/call" || !ok || len(params.Meta) == 0 {
+			return next(ctx, method, req)
+		}
+
+		trace := traceContext(func(name string) string {
+			value, _ := params.Meta[name].(string)
+			return value
+		})
+		if len(trace) == 0 {
+			return next(ctx, method, req)
+		}
+
+		return next(context.WithValue(ctx, traceCtxKey{}, trace), method, req)
+	}
+}
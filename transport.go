@@ -1,8 +1,14 @@
 package mcp
 
 import (
+	"bytes"
+	"context"
+	stderrors "errors"
 	"fmt"
+	"io"
 	"net/http"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -16,44 +22,90 @@ import (
 func (p *Plugin) serveSSE() error {
 	const op = errors.Op("mcp_serve_sse")
 
-	// Create SSE server using the SDK
-	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Generate session ID
-		sessionID := uuid.New().String()
+	mux := http.NewServeMux()
+	mux.Handle("/", p.sseSessionHandler(""))
 
-		// Extract credentials from Authorization header
-		credentials := make(map[string]string)
-		if authHeader := r.Header.Get("Authorization"); authHeader != "" {
-			token := strings.TrimPrefix(authHeader, "Bearer ")
-			credentials["token"] = token
+	// Logical servers sharing the main listener
+	p.mountServers(mux)
+
+	// Notifications pushed by external systems
+	if p.cfg.Webhooks.Enabled {
+		mux.Handle(http.MethodPost+" "+p.cfg.Webhooks.Path, http.HandlerFunc(p.serveWebhook))
+	}
+
+	// Tools callable over plain HTTP
+	if p.cfg.REST.Enabled {
+		mux.Handle(http.MethodPost+" "+p.cfg.REST.Path+"/{name}", http.HandlerFunc(p.serveREST))
+		mux.Handle(http.MethodGet+" "+p.cfg.REST.OpenAPIPath, http.HandlerFunc(p.serveOpenAPI))
+	}
+
+	// Create HTTP server
+	srv := p.newClientServer(p.cfg.Address, p.logAccess(p.limitRequests(p.compressResponses(mux))))
+
+	ln, certs, err := listenTLS(p.cfg.Address, p.cfg.TLS, p.cfg.Clients.KeepAlive, httpProtocols)
+	if err != nil {
+		return errors.E(op, err)
+	}
+
+	// The listener may be swapped at runtime, see swapListener
+	p.mu.Lock()
+	p.httpServer = srv
+	p.listener = &transportListener{address: p.cfg.Address, tls: p.cfg.TLS, certs: certs}
+	p.mu.Unlock()
+
+	// Start server
+	p.log.Info("SSE transport listening", zap.String("address", p.cfg.Address))
+
+	defer p.watchCertificates(certs)()
+
+	if err := srv.Serve(ln); err != nil && err != http.ErrServerClosed {
+		return errors.E(op, err)
+	}
+
+	return nil
+}
+
+// sseSessionHandler serves SSE sessions of a logical server, the main
+// endpoint has an empty server name
+func (p *Plugin) sseSessionHandler(server string) http.Handler {
+	// SDK handler owns the SSE streams and routes message POSTs to them
+	sseHandler := mcp.NewSSEHandler(func(r *http.Request) *mcp.Server {
+		return p.connServer(sessionIDFromContext(r.Context()))
+	}, nil)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !p.authorizeRequest(w, r, server, "sse") {
+			return
 		}
-		credentials["ip"] = r.RemoteAddr
-		credentials["user_agent"] = r.UserAgent()
 
-		// Authenticate session if auth is enabled
-		var sessionToken string
-		var err error
-		if p.cfg.Auth.Enabled {
-			sessionToken, err = p.authenticateSession(r.Context(), sessionID, credentials)
-			if err != nil {
-				p.log.Warn("authentication failed",
-					zap.String("session_id", sessionID),
-					zap.Error(err),
-				)
-				http.Error(w, "Authentication failed", http.StatusUnauthorized)
-				return
-			}
+		// Only the hanging GET opens a new session
+		if r.Method != http.MethodGet {
+			injectTrace(r)
+			sseHandler.ServeHTTP(w, r)
+			return
 		}
 
-		// Track session
+		// Generate session ID
+		sessionID := uuid.New().String()
+
+		credentials := p.requestCredentials(r)
+
+		// Track session, authentication happens on initialize
 		credentialsMap := make(map[string]interface{})
 		for k, v := range credentials {
 			credentialsMap[k] = v
 		}
-		p.trackSession(sessionID, sessionToken, "sse", credentialsMap)
+		p.trackSession(sessionID, "sse", credentials, credentialsMap)
+		p.mu.Lock()
+		if info, ok := p.sessions.get(sessionID); ok {
+			info.Server = server
+			info.ResumeKey = r.Header.Get(headerResumeKey)
+		}
+		p.mu.Unlock()
 
 		p.log.Info("SSE client connected",
 			zap.String("session_id", sessionID),
+			zap.String("server", server),
 			zap.String("remote_addr", r.RemoteAddr),
 		)
 
@@ -64,61 +116,128 @@ func (p *Plugin) serveSSE() error {
 			)
 		}()
 
-		// Create SSE transport for this connection
-		transport := mcp.NewSSETransport("/sse", w, r)
+		// A stream blocked beyond the write timeout ends the session
+		ctx, cancel := context.WithCancel(withSessionID(r.Context(), sessionID))
+		defer cancel()
 
-		// Connect server to transport with proper context
-		_, err = p.mcpServer.Connect(r.Context(), transport, nil)
-		if err != nil {
-			p.log.Error("failed to connect SSE transport",
-				zap.String("session_id", sessionID),
-				zap.Error(err),
-			)
-			http.Error(w, "Failed to establish SSE connection", http.StatusInternalServerError)
-			return
+		sw := newSSEWriter(w, p.cfg.Clients.WriteTimeout, func() {
+			p.evictSlowConsumer(sessionID, server)
+			cancel()
+		})
+
+		// Message POSTs name the SDK session, the access log maps it back
+		if p.accessLog != nil {
+			setAccessSession(r.Context(), sessionID)
+
+			var endpoint string
+			sw.endpoint = func(id string) {
+				endpoint = id
+				p.accessLog.endpoints.Store(id, sessionID)
+			}
+			defer func() { p.accessLog.endpoints.Delete(endpoint) }()
 		}
+
+		// Serve the stream until the client goes away
+		sseHandler.ServeHTTP(sw, r.WithContext(ctx))
 	})
+}
 
-	// Create HTTP server
-	p.httpServer = &http.Server{
-		Addr:         p.cfg.Address,
-		Handler:      handler,
-		ReadTimeout:  p.cfg.Clients.ReadTimeout,
-		WriteTimeout: p.cfg.Clients.WriteTimeout,
-	}
+// isLocalTransport reports whether sessions of a transport are opened by the
+// plugin itself rather than by a connected client
+func isLocalTransport(transport string) bool {
+	return transport == transportREST || isTrustedTransport(transport)
+}
 
-	// Start server
-	p.log.Info("SSE transport listening", zap.String("address", p.cfg.Address))
+// isTrustedTransport reports whether sessions of a transport are started by
+// the plugin on its own behalf and skip authentication
+func isTrustedTransport(transport string) bool {
+	return transport == transportScheduler || transport == transportReplay || transport == transportLoadTest
+}
 
-	if err := p.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		return errors.E(op, err)
+// requestCredentials extracts the credentials sent to PHP for authentication
+func (p *Plugin) requestCredentials(r *http.Request) map[string]string {
+	credentials := make(map[string]string)
+	if authHeader := r.Header.Get("Authorization"); authHeader != "" {
+		if username, password, ok := r.BasicAuth(); ok && p.cfg.Auth.Credentials.Basic {
+			credentials["username"] = username
+			credentials["password"] = password
+		} else {
+			token := strings.TrimPrefix(authHeader, "Bearer ")
+			credentials["token"] = token
+		}
+	}
+	for _, source := range p.cfg.Auth.Credentials.Sources {
+		if value := source.extract(r); value != "" {
+			credentials[source.Key] = value
+		}
+	}
+	credentials["ip"] = r.RemoteAddr
+	credentials["user_agent"] = r.UserAgent()
+	if hasVerifiedClientCert(r) {
+		credentials["client_cert_subject"] = r.TLS.VerifiedChains[0][0].Subject.String()
 	}
 
-	return nil
+	return credentials
 }
 
-// serveStdio starts the stdio transport
+// serveStdio serves a client on stdin and stdout. A session starts with the
+// first message, with stdio.restart_on_disconnect the next client is awaited
+// once it disconnected, otherwise the transport ends. It also ends once
+// stdin is closed.
 func (p *Plugin) serveStdio() error {
-	const op = errors.Op("mcp_serve_stdio")
+	// The reader outlives sessions, stdin and stdout stay open between them
+	lines := newLineReader(os.Stdin, FramingNDJSON, p.cfg.Limits.MaxRequestSize)
+	defer lines.stop()
+
+	for {
+		first, err := lines.next(p.ctx)
+		if err != nil {
+			return nil
+		}
 
-	// Create stdio transport
-	transport := mcp.NewStdioTransport()
+		if len(bytes.TrimSpace(first.data)) == 0 {
+			// Oversized messages are skipped while awaiting a client
+			if stderrors.Is(first.err, errMessageTooLarge) {
+				p.log.Warn("stdio message dropped", zap.Error(first.err))
+				continue
+			}
 
-	// Generate session ID
-	sessionID := uuid.New().String()
+			// stdin is closed, no further client can connect
+			if first.err != nil && !stderrors.Is(first.err, io.EOF) {
+				p.log.Warn("stdin read failed", zap.Error(first.err))
+			}
+			p.log.Info("stdin closed, stdio transport stopped")
+			return nil
+		}
 
-	// Skip authentication for stdio if configured
-	var sessionToken string
-	var err error
-	if p.cfg.Auth.Enabled && !p.cfg.Auth.SkipForStdio {
-		sessionToken, err = p.authenticateSession(p.ctx, sessionID, map[string]string{})
+		err = p.serveStdioSession(&streamTransport{lines: lines, w: os.Stdout, first: &first})
+		if p.ctx.Err() != nil {
+			return nil
+		}
+		if !p.cfg.Stdio.RestartOnDisconnect {
+			return err
+		}
 		if err != nil {
-			return errors.E(op, fmt.Errorf("authentication failed: %w", err))
+			p.log.Warn("stdio session failed", zap.Error(err))
+		}
+
+		select {
+		case <-p.ctx.Done():
+			return nil
+		case <-time.After(p.cfg.Stdio.RestartDelay):
 		}
 	}
+}
+
+// serveStdioSession serves one stdio client until it disconnects
+func (p *Plugin) serveStdioSession(transport mcp.Transport) error {
+	const op = errors.Op("mcp_serve_stdio")
 
-	// Track session
-	p.trackSession(sessionID, sessionToken, "stdio", nil)
+	// Generate session ID
+	sessionID := uuid.New().String()
+
+	// Track session, authentication happens on initialize
+	p.trackSession(sessionID, "stdio", map[string]string{}, nil)
 
 	p.log.Info("stdio transport connected", zap.String("session_id", sessionID))
 
@@ -127,30 +246,193 @@ func (p *Plugin) serveStdio() error {
 		p.log.Info("stdio transport disconnected", zap.String("session_id", sessionID))
 	}()
 
-	// Connect server to transport - this blocks until connection ends
-	_, err = p.mcpServer.Connect(p.ctx, transport, nil)
+	// Connect server to transport
+	ss, err := p.connServer(sessionID).Connect(withSessionID(p.ctx, sessionID), transport, nil)
 	if err != nil {
 		return errors.E(op, fmt.Errorf("failed to connect stdio transport: %w", err))
 	}
 
+	// Block until the client closes the connection or the plugin stops
+	stop := context.AfterFunc(p.ctx, func() { _ = ss.Close() })
+	defer stop()
+
+	if err := ss.Wait(); err != nil && !stderrors.Is(err, io.EOF) && !stderrors.Is(err, context.Canceled) {
+		return errors.E(op, err)
+	}
+
 	return nil
 }
 
+// Authentication failures returned to clients
+var (
+	errAuthFailed     = withCode(ErrAuth, errors.Str("authentication failed"))
+	errSessionExpired = withCode(ErrAuth, errors.Str("session expired"))
+)
+
+// sessionMiddleware records client info from the initialize request and
+// authenticates the session before the handshake completes
+func (p *Plugin) sessionMiddleware(next mcp.MethodHandler) mcp.MethodHandler {
+	return func(ctx context.Context, method string, req mcp.Request) (mcp.Result, error) {
+		params, ok := req.GetParams().(*mcp.InitializeParams)
+		if method != "initialize" || !ok {
+			// Expired credentials are renewed or the session is closed
+			if sessionID := sessionIDFromContext(ctx); p.sessionExpired(sessionID) && !p.renewSession(ctx, sessionID) {
+				go p.closeExpiredSession(sessionID)
+				return nil, jsonRPCError(errSessionExpired)
+			}
+			return next(ctx, method, req)
+		}
+
+		sessionID := sessionIDFromContext(ctx)
+
+		p.mu.Lock()
+		info, _ := p.sessions.get(sessionID)
+		var credentials map[string]string
+		if info != nil {
+			info.ClientInfo = params.ClientInfo
+			info.Capabilities = params.Capabilities
+			credentials = info.Credentials
+		}
+		p.mu.Unlock()
+
+		if info == nil {
+			return nil, fmt.Errorf("unknown session: %s", sessionID)
+		}
+
+		if p.revoked.has(credentials["token"]) {
+			p.errorCounts.add(errorClassAuth)
+			p.log.Warn("revoked token rejected", zap.String("session_id", sessionID))
+			return nil, jsonRPCError(errAuthFailed)
+		}
+
+		if params.ClientInfo != nil {
+			p.log.Debug("client initialized",
+				zap.String("session_id", sessionID),
+				zap.String("client_name", params.ClientInfo.Name),
+				zap.String("client_version", params.ClientInfo.Version),
+			)
+		}
+
+		// Skip authentication for stdio if configured and for sessions the plugin runs itself
+		if p.sessionRequiresAuth(info) {
+			authResp, err := p.authenticateSession(ctx, sessionID, credentials, params)
+			if err != nil {
+				p.errorCounts.add(errorClassAuth)
+				p.log.Warn("authentication failed",
+					zap.String("session_id", sessionID),
+					zap.Error(err),
+				)
+				return nil, jsonRPCError(errAuthFailed)
+			}
+
+			if !authResp.ExpiresAt.IsZero() && !authResp.ExpiresAt.After(time.Now()) {
+				p.errorCounts.add(errorClassAuth)
+				p.log.Warn("authentication failed",
+					zap.String("session_id", sessionID),
+					zap.String("reason", "credentials already expired"),
+				)
+				return nil, jsonRPCError(errAuthFailed)
+			}
+
+			p.mu.Lock()
+			info.Token = authResp.Token
+			info.Authenticated = true
+			info.Scopes = authResp.Scopes
+			info.Tenant = authResp.Tenant
+			p.scheduleExpiry(info, authResp.ExpiresAt)
+			p.mu.Unlock()
+		}
+
+		result, err := next(ctx, method, req)
+		if err != nil {
+			return nil, err
+		}
+
+		// Bind the SDK session so the plugin can address it directly
+		if ss, ok := req.GetSession().(*mcp.ServerSession); ok {
+			p.mu.Lock()
+			info.Session = ss
+			p.mu.Unlock()
+		}
+
+		// Deliver what was missed while the client was reconnecting
+		p.resumeSession(info)
+
+		p.mu.RLock()
+		p.publish(&BusEvent{
+			Event:     BusSessionConnected,
+			SessionID: sessionID,
+			Transport: info.Transport,
+			Server:    info.Server,
+			Tenant:    info.Tenant,
+		})
+		p.mu.RUnlock()
+
+		// Instructions may have been replaced by PHP after the server was created
+		if res, ok := result.(*mcp.InitializeResult); ok {
+			p.mu.RLock()
+			res.Instructions = p.instructions
+			p.mu.RUnlock()
+		}
+
+		return result, nil
+	}
+}
+
+// sessionCtxKey carries the plugin session ID through SDK handler contexts
+type sessionCtxKey struct{}
+
+// withSessionID binds a session ID to the connection context
+func withSessionID(ctx context.Context, sessionID string) context.Context {
+	return context.WithValue(ctx, sessionCtxKey{}, sessionID)
+}
+
+// sessionIDFromContext returns the session ID bound to the connection context
+func sessionIDFromContext(ctx context.Context) string {
+	if sessionID, ok := ctx.Value(sessionCtxKey{}).(string); ok {
+		return sessionID
+	}
+	return ""
+}
+
+// callSessionID returns the plugin session a request arrived on. The ID is
+// bound to the connection context on Connect, requests whose context lost it
+// are matched by their SDK session.
+func (p *Plugin) callSessionID(ctx context.Context, ss *mcp.ServerSession) string {
+	if sessionID := sessionIDFromContext(ctx); sessionID != "" {
+		return sessionID
+	}
+	if ss == nil {
+		return ""
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	for sessionID, info := range p.sessions.snapshot() {
+		if info.Session == ss {
+			return sessionID
+		}
+	}
+
+	return ""
+}
+
 // trackSession adds a new session to the registry
-func (p *Plugin) trackSession(sessionID, token, transport string, metadata map[string]interface{}) {
+func (p *Plugin) trackSession(sessionID, transport string, credentials map[string]string, metadata map[string]interface{}) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
 	info := &SessionInfo{
-		ID:           sessionID,
-		Token:        token,
-		ConnectedAt:  time.Now(),
-		LastActivity: time.Now(),
-		Transport:    transport,
-		Metadata:     metadata,
+		ID:          sessionID,
+		ConnectedAt: time.Now(),
+		Transport:   transport,
+		Metadata:    metadata,
+		Credentials: credentials,
 	}
+	info.touch()
 
-	p.sessions[sessionID] = info
+	p.sessions.set(sessionID, info)
 
 	p.log.Debug("session tracked",
 		zap.String("session_id", sessionID),
@@ -163,7 +445,64 @@ func (p *Plugin) removeSession(sessionID string) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
 
-	delete(p.sessions, sessionID)
+	info, ok := p.sessions.get(sessionID)
+	if !ok {
+		return
+	}
+
+	if info.expiryTimer != nil {
+		info.expiryTimer.Stop()
+	}
+	p.sessions.delete(sessionID)
+	p.storage.drop(sessionID)
+	p.dropConnServer(sessionID)
+
+	// Notifications are buffered until the client resumes the session
+	p.parkSession(info)
+
+	// Only sessions that completed the handshake were announced
+	if info.Session != nil {
+		p.publish(&BusEvent{
+			Event:     BusSessionDisconnected,
+			SessionID: sessionID,
+			Transport: info.Transport,
+			Server:    info.Server,
+			Tenant:    info.Tenant,
+		})
+	}
 
 	p.log.Debug("session removed", zap.String("session_id", sessionID))
 }
+
+// sessionTransport returns the transport of a session
+func (p *Plugin) sessionTransport(sessionID string) string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if info, ok := p.sessions.get(sessionID); ok {
+		return info.Transport
+	}
+
+	return ""
+}
+
+// sessionHasScope reports whether a session may use a scoped tool. Sessions
+// not authenticated by PHP (auth disabled or skipped for stdio) are trusted.
+func (p *Plugin) sessionHasScope(sessionID, scope string) bool {
+	if scope == "" {
+		return true
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	info, ok := p.sessions.get(sessionID)
+	if !ok {
+		return false
+	}
+	if !info.Authenticated {
+		return true
+	}
+
+	return slices.Contains(info.Scopes, scope)
+}
@@ -2,74 +2,646 @@ package mcp
 
 import (
 	"encoding/json"
+	"sync"
+	"sync/atomic"
 	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
 // DeclareToolsRequest is sent from PHP to register tools
 type DeclareToolsRequest struct {
-	Tools []ToolDefinition `json:"tools"`
+	Namespace string           `json:"namespace,omitempty"` // Prepended to tool names
+	Tenant    string           `json:"tenant,omitempty"`    // Tools visible only to sessions of this tenant
+	Tools     []ToolDefinition `json:"tools"`
+	Force     bool             `json:"force,omitempty"` // Accept backward-incompatible schema changes
+	Batch     string           `json:"batch,omitempty"` // Chunks sharing a batch ID are applied together
+	Final     bool             `json:"final,omitempty"` // Last chunk of the batch
+}
+
+// declarationBatch holds DeclareTools chunks staged until the final chunk
+type declarationBatch struct {
+	Requests  []*DeclareToolsRequest
+	StartedAt time.Time
 }
 
 // ToolDefinition represents a tool definition from PHP
 type ToolDefinition struct {
 	Name        string                 `json:"name"`
+	Title       string                 `json:"title,omitempty"`
 	Description string                 `json:"description"`
 	InputSchema map[string]interface{} `json:"inputSchema"`
+	// OutputSchema describes structuredContent returned by the tool
+	OutputSchema map[string]interface{} `json:"outputSchema,omitempty"`
+	Icons        []ToolIcon             `json:"icons,omitempty"`
+	Annotations  *mcp.ToolAnnotations   `json:"annotations,omitempty"`
+	Version      string                 `json:"version,omitempty"` // Revision reported back in conflicts
+	// Deprecated marks the tool deprecated, tools.overrides take precedence
+	Deprecated *ToolDeprecation `json:"deprecated,omitempty"`
+	// Former names whose calls are routed to the tool, e.g. after a rename
+	Aliases []string `json:"aliases,omitempty"`
+}
+
+// ToolDeprecation marks a tool deprecated: it stays callable, its results
+// carry a notice, until disable_after passed
+type ToolDeprecation struct {
+	ReplacedBy   string `json:"replacedBy,omitempty" mapstructure:"replaced_by"` // Tool to use instead
+	Message      string `json:"message,omitempty" mapstructure:"message"`
+	DisableAfter string `json:"disableAfter,omitempty" mapstructure:"disable_after"` // Date (2006-01-02) or RFC 3339 time
 }
 
-// DeclareToolsResponse is returned to PHP after tool registration
+// ToolIcon describes an icon clients may render for a tool
+type ToolIcon struct {
+	Src      string   `json:"src" mapstructure:"src"`
+	MimeType string   `json:"mimeType,omitempty" mapstructure:"mime_type"`
+	Sizes    []string `json:"sizes,omitempty" mapstructure:"sizes"`
+	Theme    string   `json:"theme,omitempty" mapstructure:"theme"` // "light" or "dark"
+}
+
+// DeclareToolsResponse is returned to PHP after tool registration (qualified names)
 type DeclareToolsResponse struct {
+	Registered []string       `json:"registered"`
+	Updated    []string       `json:"updated"`
+	Conflicts  []ToolConflict `json:"conflicts,omitempty"`
+	Staged     []string       `json:"staged,omitempty"`    // Names staged by a non-final batch chunk
+	Unchanged  []string       `json:"unchanged,omitempty"` // Declared again with identical definitions
+
+	// Schema problems, tools with errors are not registered
+	Diagnostics []ToolDiagnostic `json:"diagnostics,omitempty"`
+}
+
+// ListToolsResponse answers EventListTools with the declarations a worker
+// would send over RPC
+type ListToolsResponse struct {
+	Declarations []*DeclareToolsRequest `json:"declarations"`
+}
+
+// ToolConflict describes a declaration rejected for breaking the registered schema
+type ToolConflict struct {
+	Name            string   `json:"name"`
+	CurrentVersion  string   `json:"currentVersion,omitempty"`
+	DeclaredVersion string   `json:"declaredVersion,omitempty"`
+	Changes         []string `json:"changes"`
+}
+
+// ValidateToolsResponse reports what DeclareTools would do with a declaration
+type ValidateToolsResponse struct {
+	Valid       bool             `json:"valid"` // No diagnostic with severity error
+	Registered  []string         `json:"registered"`
+	Updated     []string         `json:"updated"`
+	Unchanged   []string         `json:"unchanged,omitempty"`
+	Conflicts   []ToolConflict   `json:"conflicts,omitempty"`
+	Diagnostics []ToolDiagnostic `json:"diagnostics"`
+}
+
+// Severities of tool diagnostics
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// ToolDiagnostic is a problem found in a tool declaration
+type ToolDiagnostic struct {
+	Tool     string `json:"tool"`
+	Severity string `json:"severity"`
+	Code     string `json:"code"`
+	Field    string `json:"field,omitempty"` // Path of the offending field, e.g. inputSchema
+	Message  string `json:"message"`
+}
+
+// SetInstructionsRequest is sent from PHP to replace the server instructions
+type SetInstructionsRequest struct {
+	Instructions string `json:"instructions"`
+}
+
+// GetToolsRequest is sent from PHP to inspect the tool registry
+type GetToolsRequest struct {
+	Namespace string `json:"namespace,omitempty"` // Only tools of this namespace
+	Tenant    string `json:"tenant,omitempty"`    // Only tools of this tenant
+}
+
+// GetToolsResponse is returned to PHP with the registered tools, sorted by name
+type GetToolsResponse struct {
+	Tools []ToolInfo `json:"tools"`
+}
+
+// ToolInfo describes a registered tool as seen by the Go side
+type ToolInfo struct {
+	Name         string                 `json:"name"`
+	Title        string                 `json:"title,omitempty"`
+	Description  string                 `json:"description"`
+	InputSchema  map[string]interface{} `json:"inputSchema"`
+	Annotations  *mcp.ToolAnnotations   `json:"annotations,omitempty"`
+	Namespace    string                 `json:"namespace,omitempty"`
+	Tenant       string                 `json:"tenant,omitempty"`
+	Version      string                 `json:"version,omitempty"`
+	Source       string                 `json:"source"`
+	Hash         string                 `json:"hash,omitempty"` // Content hash of the PHP declaration
+	RegisteredAt time.Time              `json:"registeredAt"`
+	UpdatedAt    time.Time              `json:"updatedAt"`
+}
+
+// ClientConnectRequest is sent from PHP to open a connection to an MCP server
+type ClientConnectRequest struct {
+	Name      string            `json:"name"`
+	Command   []string          `json:"command,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	URL       string            `json:"url,omitempty"`
+	Transport string            `json:"transport,omitempty"` // "streamable" or "sse"
+	Headers   map[string]string `json:"headers,omitempty"`
+	Timeout   string            `json:"timeout,omitempty"` // Duration, e.g. "30s"
+}
+
+// ClientConnectResponse is returned to PHP with the connected server identity
+type ClientConnectResponse struct {
+	ServerInfo *mcp.Implementation `json:"serverInfo,omitempty"`
+	Reused     bool                `json:"reused"`
+}
+
+// ClientRequest identifies a client connection
+type ClientRequest struct {
+	Name string `json:"name"`
+}
+
+// ClientListToolsResponse is returned to PHP with the server's tools
+type ClientListToolsResponse struct {
+	Tools []*mcp.Tool `json:"tools"`
+}
+
+// ClientCallToolRequest is sent from PHP to call a tool on a connected server
+type ClientCallToolRequest struct {
+	Name      string                 `json:"name"`
+	Tool      string                 `json:"tool"`
+	Arguments json.RawMessage        `json:"arguments,omitempty"`
+	Meta      map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// ClientCallToolResponse is returned to PHP with the tool result
+type ClientCallToolResponse struct {
+	Result *mcp.CallToolResult `json:"result"`
+}
+
+// ClientReadResourceRequest is sent from PHP to read a resource from a connected server
+type ClientReadResourceRequest struct {
+	Name string `json:"name"`
+	URI  string `json:"uri"`
+}
+
+// ClientReadResourceResponse is returned to PHP with the resource contents
+type ClientReadResourceResponse struct {
+	Contents []*mcp.ResourceContents `json:"contents"`
+}
+
+// WebhookNotification is posted by external systems to notify sessions
+type WebhookNotification struct {
+	Type     string          `json:"type"`               // "message" or "resource_updated"
+	Sessions []string        `json:"sessions,omitempty"` // Target sessions, all when empty
+	Level    string          `json:"level,omitempty"`    // Logging level of messages
+	Logger   string          `json:"logger,omitempty"`
+	Data     json.RawMessage `json:"data,omitempty"`
+	URI      string          `json:"uri,omitempty"` // Updated resource
+}
+
+// GetRecentCallsRequest is sent from PHP to inspect the call history
+type GetRecentCallsRequest struct {
+	Tool  string `json:"tool,omitempty"`  // Only calls of this tool
+	Limit int    `json:"limit,omitempty"` // All recorded calls when zero
+}
+
+// GetRecentCallsResponse is returned to PHP with recorded calls, newest first
+type GetRecentCallsResponse struct {
+	Calls []*CallRecord `json:"calls"`
+}
+
+// ReplayCallRequest is sent from PHP to re-execute a recorded call
+type ReplayCallRequest struct {
+	ID uint64 `json:"id"`
+}
+
+// ReplayCallResponse is returned to PHP with the result of the replayed call
+type ReplayCallResponse struct {
+	Result *mcp.CallToolResult `json:"result"`
+	Error  *ErrorInfo          `json:"error,omitempty"` // Set when the replayed call failed
+}
+
+// ResetToolFilterRequest is sent from PHP when tool visibility changed
+type ResetToolFilterRequest struct {
+	SessionID string `json:"sessionId,omitempty"` // All sessions when empty
+}
+
+// ResetToolFilterResponse is returned to PHP with the number of notified sessions
+type ResetToolFilterResponse struct {
+	Sessions int `json:"sessions"`
+}
+
+// RevokeTokenRequest is sent from PHP to invalidate a client or session token
+type RevokeTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// RevokeTokenResponse is returned to PHP with the closed sessions
+type RevokeTokenResponse struct {
+	Sessions []string `json:"sessions"`
+}
+
+// RotateSessionTokenRequest is sent from PHP to replace a session token
+type RotateSessionTokenRequest struct {
+	SessionID string `json:"sessionId"`
+	Token     string `json:"token,omitempty"` // Generated when empty
+}
+
+// RotateSessionTokenResponse is returned to PHP with the new token
+type RotateSessionTokenResponse struct {
+	Token string `json:"token"`
+}
+
+// SetSessionAttributesRequest is sent from PHP to attach application
+// metadata to a session
+type SetSessionAttributesRequest struct {
+	SessionID  string            `json:"sessionId"`
+	Attributes map[string]string `json:"attributes"` // Empty values remove attributes
+	Replace    bool              `json:"replace,omitempty"`
+}
+
+// SetSessionAttributesResponse is returned to PHP with the session's attributes
+type SetSessionAttributesResponse struct {
+	Attributes map[string]string `json:"attributes"`
+}
+
+// SessionStorageRequest addresses a value stored for a session
+type SessionStorageRequest struct {
+	SessionID string `json:"sessionId"`
+	Key       string `json:"key"`
+}
+
+// SessionStorageGetResponse is returned to PHP with the stored value
+type SessionStorageGetResponse struct {
+	Value string `json:"value"`
+	Found bool   `json:"found"`
+}
+
+// SessionStorageSetRequest is sent from PHP to store a value for a session
+type SessionStorageSetRequest struct {
+	SessionID string `json:"sessionId"`
+	Key       string `json:"key"`
+	Value     string `json:"value"`
+}
+
+// SessionStorageSetResponse is returned to PHP with the session's usage
+type SessionStorageSetResponse struct {
+	Keys  int `json:"keys"`
+	Bytes int `json:"bytes"`
+}
+
+// SessionStorageDeleteResponse is returned to PHP after a value was removed
+type SessionStorageDeleteResponse struct {
+	Deleted bool `json:"deleted"`
+}
+
+// SwapListenerRequest moves the transport listener, an empty address keeps
+// the current one and replaces its certificates
+type SwapListenerRequest struct {
+	Address string        `json:"address,omitempty"`
+	TLS     *TLSConfig    `json:"tls,omitempty"`   // Plain connections when nil
+	Drain   time.Duration `json:"drain,omitempty"` // Default 30s, at most 10m
+}
+
+// SwapListenerResponse reports the listener after a swap
+type SwapListenerResponse struct {
+	Address  string `json:"address"`
+	Notified int    `json:"notified"` // SSE sessions told to reconnect
+}
+
+// DeclareResourcesRequest is sent from PHP to register resources read
+// through the ReadResource event
+type DeclareResourcesRequest struct {
+	Resources []ResourceDefinition `json:"resources"`
+}
+
+// ResourceDefinition represents a resource served by PHP
+type ResourceDefinition struct {
+	URI         string `json:"uri"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	MimeType    string `json:"mimeType,omitempty"`
+	Size        int64  `json:"size,omitempty"` // In bytes, when known
+}
+
+// DeclareResourcesResponse is returned to PHP after resource registration
+type DeclareResourcesResponse struct {
 	Registered []string `json:"registered"`
-	Updated    []string `json:"updated"`
+}
+
+// NotifyResourceUpdatedRequest is sent from PHP after a resource changed
+type NotifyResourceUpdatedRequest struct {
+	URI      string   `json:"uri"`
+	Sessions []string `json:"sessions,omitempty"` // Notified sessions, all when empty
+}
+
+// NotifyResourceUpdatedResponse reports the sessions told about the change
+type NotifyResourceUpdatedResponse struct {
+	Delivered int `json:"delivered"`
+}
+
+// WorkerPingRequest is sent from PHP to check the path to the workers
+type WorkerPingRequest struct {
+	Timeout time.Duration `json:"timeout,omitempty"` // Default 5s
+}
+
+// WorkerPingResponse reports the worker that answered the ping
+type WorkerPingResponse struct {
+	RoundTrip       time.Duration `json:"roundTrip"`
+	ProtocolVersion int           `json:"protocolVersion"`
+}
+
+// LoadTestRequest describes a synthetic load test against a tool
+type LoadTestRequest struct {
+	Tool        string          `json:"tool"`
+	Arguments   json.RawMessage `json:"arguments,omitempty"`
+	Concurrency int             `json:"concurrency,omitempty"` // Parallel callers, default 10
+	Duration    time.Duration   `json:"duration,omitempty"`    // Default 10s, at most 5m
+}
+
+// LoadTestResponse reports the outcome of a load test
+type LoadTestResponse struct {
+	Tool        string        `json:"tool"`
+	Concurrency int           `json:"concurrency"`
+	Duration    time.Duration `json:"duration"`
+	Calls       int           `json:"calls"`
+	Errors      int           `json:"errors"`     // Protocol errors
+	ToolErrors  int           `json:"toolErrors"` // Results with isError set
+	Throughput  float64       `json:"throughput"` // Calls per second
+	Latency     LoadTestStats `json:"latency"`
+	FirstError  string        `json:"firstError,omitempty"`
+}
+
+// LoadTestStats are the latency statistics of a load test
+type LoadTestStats struct {
+	Min  time.Duration `json:"min"`
+	Mean time.Duration `json:"mean"`
+	P50  time.Duration `json:"p50"`
+	P90  time.Duration `json:"p90"`
+	P95  time.Duration `json:"p95"`
+	P99  time.Duration `json:"p99"`
+	Max  time.Duration `json:"max"`
 }
 
 // ClientConnectedPayload is sent to PHP for authentication
 type ClientConnectedPayload struct {
-	SessionID   string            `json:"sessionId"`
-	Credentials map[string]string `json:"credentials"`
+	SessionID    string                  `json:"sessionId"`
+	Credentials  map[string]string       `json:"credentials"`
+	ClientInfo   *mcp.Implementation     `json:"clientInfo,omitempty"`
+	Capabilities *mcp.ClientCapabilities `json:"capabilities,omitempty"`
+	Server       string                  `json:"server,omitempty"` // Logical server the client connected to
 }
 
 // ClientConnectedResponse is expected from PHP after authentication
 type ClientConnectedResponse struct {
+	Allowed bool     `json:"allowed"`
+	Token   string   `json:"token,omitempty"`
+	Message string   `json:"message,omitempty"`
+	Scopes  []string `json:"scopes,omitempty"` // Grants access to scoped built-in tools
+	Tenant  string   `json:"tenant,omitempty"` // Isolates the session to the tenant's registry
+
+	// End of the grant, auth.on_expiry decides what happens then
+	ExpiresAt time.Time `json:"expiresAt,omitzero"`
+}
+
+// TokenRevokedPayload is sent to PHP after a token was revoked
+type TokenRevokedPayload struct {
+	Token    string   `json:"token"`
+	Sessions []string `json:"sessions"` // Closed sessions
+}
+
+// SessionTokenRotatedPayload is sent to PHP after a session token was replaced
+type SessionTokenRotatedPayload struct {
+	SessionID     string `json:"sessionId"`
+	PreviousToken string `json:"previousToken,omitempty"`
+	Token         string `json:"token"`
+}
+
+// RootsChangedPayload is sent to PHP with the roots of a client that
+// reported a change
+type RootsChangedPayload struct {
+	SessionID string      `json:"sessionId"`
+	Roots     []*mcp.Root `json:"roots"`
+}
+
+// FilterToolsPayload is sent to PHP to decide which tools a session may see
+type FilterToolsPayload struct {
+	SessionID    string                  `json:"sessionId"`
+	Tools        []string                `json:"tools"` // Registered tool names
+	ClientInfo   *mcp.Implementation     `json:"clientInfo,omitempty"`
+	Capabilities *mcp.ClientCapabilities `json:"capabilities,omitempty"`
+	Scopes       []string                `json:"scopes,omitempty"`
+}
+
+// FilterToolsResponse is expected from PHP with the tools visible to the session
+type FilterToolsResponse struct {
+	Tools []string `json:"tools"`
+}
+
+// ReadResourcePayload is sent to PHP to read a declared resource
+type ReadResourcePayload struct {
+	SessionID string `json:"sessionId"`
+	URI       string `json:"uri"`
+	ETag      string `json:"etag,omitempty"` // Version of the cached contents, like If-None-Match
+
+	// Bytes the client asked for with _meta.range, the whole resource when nil
+	Range *ResourceRange `json:"range,omitempty"`
+}
+
+// ResourceRange selects bytes of every content of a resource, blobs decoded
+type ResourceRange struct {
+	Offset int64 `json:"offset"`
+	Length int64 `json:"length,omitempty"` // To the end when zero
+}
+
+// ReadResourceResponse is expected from PHP with the resource contents
+type ReadResourceResponse struct {
+	Contents    []ResourceContent `json:"contents"`
+	ETag        string            `json:"etag,omitempty"`        // Caches the contents until the resource is updated
+	NotModified bool              `json:"notModified,omitempty"` // The contents of the sent etag are current
+
+	// Size of the whole resource when the contents are the requested range,
+	// otherwise the plugin cuts the range from the contents
+	TotalSize int64 `json:"totalSize,omitempty"`
+}
+
+// BeforeToolCallPayload describes a tool call about to be executed, it is
+// sent to PHP and passed to Go policies
+type BeforeToolCallPayload struct {
+	SessionID  string                 `json:"sessionId"`
+	Tool       string                 `json:"tool"` // Registered tool name
+	Arguments  json.RawMessage        `json:"arguments"`
+	Transport  string                 `json:"transport"`
+	ClientInfo *mcp.Implementation    `json:"clientInfo,omitempty"`
+	Scopes     []string               `json:"scopes,omitempty"`
+	Meta       map[string]interface{} `json:"_meta,omitempty"`
+}
+
+// BeforeToolCallResponse is the policy decision on a tool call
+type BeforeToolCallResponse struct {
 	Allowed bool   `json:"allowed"`
-	Token   string `json:"token,omitempty"`
-	Message string `json:"message,omitempty"`
+	Message string `json:"message,omitempty"` // Reason returned to the client on deny
+
+	// Replaces the call arguments when set
+	Arguments json.RawMessage `json:"arguments,omitempty"`
+
+	// Merged into the call's _meta, PHP handlers receive it in CallTool
+	Meta map[string]interface{} `json:"_meta,omitempty"`
 }
 
 // CallToolPayload is sent to PHP for tool execution
 type CallToolPayload struct {
-	SessionID string          `json:"sessionId"`
-	ToolName  string          `json:"toolName"`
-	Arguments json.RawMessage `json:"arguments"`
+	SessionID    string                  `json:"sessionId"`
+	ToolName     string                  `json:"toolName"` // Name as declared, without prefix or namespace
+	Namespace    string                  `json:"namespace,omitempty"`
+	Tenant       string                  `json:"tenant,omitempty"`
+	Arguments    json.RawMessage         `json:"arguments"`
+	ClientInfo   *mcp.Implementation     `json:"clientInfo,omitempty"`
+	Capabilities *mcp.ClientCapabilities `json:"capabilities,omitempty"`
+	Meta         map[string]interface{}  `json:"_meta,omitempty"`      // progressToken and custom keys
+	Attributes   map[string]string       `json:"attributes,omitempty"` // Set on the session via SetSessionAttributes
 }
 
 // CallToolResponse is expected from PHP after tool execution
 type CallToolResponse struct {
-	Content []MCPContent `json:"content"`
-	IsError bool         `json:"isError"`
+	Content []MCPContent           `json:"content"`
+	IsError bool                   `json:"isError"`
+	Meta    map[string]interface{} `json:"_meta,omitempty"` // attached to the CallToolResult
+	Error   *ToolError             `json:"error,omitempty"` // returned as a JSON-RPC error
+
+	// Validated against the tool's outputSchema
+	StructuredContent json.RawMessage `json:"structuredContent,omitempty"`
+}
+
+// ToolError represents a protocol-level error reported by PHP
+type ToolError struct {
+	Code    int64           `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
 }
 
 // MCPContent represents MCP response content
 type MCPContent struct {
-	Type     string `json:"type"`               // "text", "image", "resource"
-	Text     string `json:"text,omitempty"`     // For type="text"
-	Data     string `json:"data,omitempty"`     // Base64 for type="image"
-	URI      string `json:"uri,omitempty"`      // For type="resource"
-	MimeType string `json:"mimeType,omitempty"` // MIME type
+	Type        string           `json:"type"`                  // "text", "image", "audio", "resource", "resource_link"
+	Text        string           `json:"text,omitempty"`        // For type="text"
+	Data        string           `json:"data,omitempty"`        // Base64 for type="image" and "audio"
+	URI         string           `json:"uri,omitempty"`         // For type="resource" and "resource_link"
+	MimeType    string           `json:"mimeType,omitempty"`    // MIME type
+	Blob        string           `json:"blob,omitempty"`        // Base64 for type="resource"
+	Resource    *ResourceContent `json:"resource,omitempty"`    // Nested form for type="resource"
+	Name        string           `json:"name,omitempty"`        // For type="resource_link"
+	Title       string           `json:"title,omitempty"`       // For type="resource_link"
+	Description string           `json:"description,omitempty"` // For type="resource_link"
+	Size        *int64           `json:"size,omitempty"`        // For type="resource_link"
+}
+
+// ResourceContent represents the contents of an embedded resource
+type ResourceContent struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"` // Base64 encoded
+	File     string `json:"file,omitempty"` // Read as the blob from resources.file_root, resource reads only
+}
+
+// toolEntry is a registered tool together with its origin
+type toolEntry struct {
+	Tool         *mcp.Tool
+	Namespace    string
+	Tenant       string
+	Version      string
+	Schema       map[string]interface{}
+	Source       string
+	Deprecation  *ToolDeprecation // Declared by PHP
+	Aliases      []string         // Keys of the tool's aliases
+	Definition   *ToolDefinition  // As declared by PHP, exported with the server definition
+	Hash         string           // Of the declaration PHP sent, see declarationHash
+	RegisteredAt time.Time
+	UpdatedAt    time.Time
 }
 
+// Tool sources
+const (
+	ToolSourcePHP      = "php"
+	ToolSourceUpstream = "upstream"
+	ToolSourceProvider = "plugin"
+	ToolSourceMock     = "mock"
+)
+
 // SessionInfo represents an active MCP client session
 type SessionInfo struct {
 	ID           string
 	Token        string
 	ConnectedAt  time.Time
-	LastActivity time.Time
+	LastActivity atomic.Int64 // Unix nanoseconds, updated without the plugin lock
 	Transport    string
 	Metadata     map[string]interface{}
+
+	// Credentials captured by the transport, sent to PHP on initialize
+	Credentials map[string]string
+
+	// Client implementation and capabilities reported in initialize
+	ClientInfo   *mcp.Implementation
+	Capabilities *mcp.ClientCapabilities
+
+	// SDK session, bound once the handshake completed
+	Session *mcp.ServerSession
+
+	// Set when PHP authenticated the session, with the scopes it granted
+	Authenticated bool
+	Scopes        []string
+
+	// Tenant resolved by PHP, empty for sessions using the shared registry
+	Tenant string
+
+	// Logical server the session connected to, empty for the main endpoint
+	Server string
+
+	// Key the client resumes the session with after a reconnect
+	ResumeKey string
+
+	// Application metadata set by PHP, replaced as a whole on every change
+	Attributes map[string]string
+
+	// Expiry of the credentials granted on connect, zero when they do not expire
+	ExpiresAt   time.Time
+	expiryTimer *time.Timer
+	expired     bool
+
+	// Cached FilterTools decisions (tool name -> visible)
+	VisibleTools   map[string]bool
+	VisibleToolsAt time.Time
+
+	// Serializes roots refreshes, so the list PHP receives last is current
+	rootsMu sync.Mutex
+}
+
+// touch records activity on the session
+func (s *SessionInfo) touch() {
+	s.LastActivity.Store(time.Now().UnixNano())
+}
+
+// lastActivity returns the time of the last activity on the session
+func (s *SessionInfo) lastActivity() time.Time {
+	return time.Unix(0, s.LastActivity.Load())
 }
 
 // Event names for PHP worker communication
 const (
 	EventClientConnected = "ClientConnected"
 	EventCallTool        = "CallTool"
+	EventFilterTools     = "FilterTools"
+	EventBeforeToolCall  = "BeforeToolCall"
+	EventListTools       = "ListTools"
+	EventReadResource    = "ReadResource"
+
+	// Informational, the response body is ignored
+	EventTokenRevoked        = "TokenRevoked"
+	EventSessionTokenRotated = "SessionTokenRotated"
+	EventRootsChanged        = "RootsChanged"
+	EventPing                = "Ping" // Readiness check at startup
 )
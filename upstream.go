@@ -0,0 +1,414 @@
+package mcp
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"github.com/roadrunner-server/errors"
+	"go.uber.org/zap"
+)
+
+// upstream is a mounted upstream MCP server
+type upstream struct {
+	name    string
+	cfg     *UpstreamConfig
+	session *mcp.ClientSession
+
+	// Re-exposed features (exposed name -> upstream name)
+	tools     map[string]string
+	prompts   map[string]string
+	resources map[string]struct{}
+}
+
+// connectUpstreams connects to all configured upstream servers
+func (p *Plugin) connectUpstreams() {
+	for name, cfg := range p.cfg.Upstreams {
+		if err := p.connectUpstream(name, cfg); err != nil {
+			p.log.Error("failed to mount upstream MCP server",
+				zap.String("upstream", name),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// connectUpstream connects to an upstream server and re-exposes its features
+func (p *Plugin) connectUpstream(name string, cfg *UpstreamConfig) error {
+	const op = errors.Op("mcp_connect_upstream")
+
+	up := &upstream{
+		name:      name,
+		cfg:       cfg,
+		tools:     make(map[string]string),
+		prompts:   make(map[string]string),
+		resources: make(map[string]struct{}),
+	}
+
+	resync := func(ctx context.Context) {
+		if err := p.syncUpstream(ctx, up); err != nil {
+			p.log.Error("failed to sync upstream MCP server",
+				zap.String("upstream", name),
+				zap.Error(err),
+			)
+		}
+	}
+
+	client := mcp.NewClient(&mcp.Implementation{
+		Name:    p.cfg.Server.Name,
+		Version: p.cfg.Server.Version,
+	}, &mcp.ClientOptions{
+		ToolListChangedHandler:     func(ctx context.Context, _ *mcp.ToolListChangedRequest) { resync(ctx) },
+		PromptListChangedHandler:   func(ctx context.Context, _ *mcp.PromptListChangedRequest) { resync(ctx) },
+		ResourceListChangedHandler: func(ctx context.Context, _ *mcp.ResourceListChangedRequest) { resync(ctx) },
+	})
+
+	session, err := client.Connect(p.ctx, p.upstreamTransport(cfg), nil)
+	if err != nil {
+		return errors.E(op, fmt.Errorf("failed to connect: %w", err))
+	}
+	up.session = session
+
+	p.mu.Lock()
+	p.upstreams[name] = up
+	p.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(p.ctx, cfg.Timeout)
+	defer cancel()
+
+	if err := p.syncUpstream(ctx, up); err != nil {
+		return errors.E(op, err)
+	}
+
+	p.log.Info("upstream MCP server mounted",
+		zap.String("upstream", name),
+		zap.String("prefix", cfg.Prefix),
+	)
+
+	return nil
+}
+
+// connectClient opens a client connection on behalf of PHP workers
+func (p *Plugin) connectClient(name string, cfg *UpstreamConfig) (*upstream, error) {
+	const op = errors.Op("mcp_connect_client")
+
+	client := mcp.NewClient(&mcp.Implementation{
+		Name:    p.cfg.Server.Name,
+		Version: p.cfg.Server.Version,
+	}, nil)
+
+	ctx, cancel := context.WithTimeout(p.ctx, cfg.Timeout)
+	defer cancel()
+
+	session, err := client.Connect(ctx, p.upstreamTransport(cfg), nil)
+	if err != nil {
+		return nil, errors.E(op, fmt.Errorf("failed to connect: %w", err))
+	}
+
+	p.log.Info("client connection opened",
+		zap.String("name", name),
+	)
+
+	return &upstream{name: name, cfg: cfg, session: session}, nil
+}
+
+// lookupClient returns a client connection or a mounted upstream by name
+func (p *Plugin) lookupClient(name string) (*upstream, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if up, ok := p.clients[name]; ok {
+		return up, nil
+	}
+	if up, ok := p.upstreams[name]; ok {
+		return up, nil
+	}
+
+	return nil, errors.Errorf("no client connection named %q", name)
+}
+
+// upstreamTransport creates the client transport for an upstream
+func (p *Plugin) upstreamTransport(cfg *UpstreamConfig) mcp.Transport {
+	if len(cfg.Command) > 0 {
+		cmd := exec.CommandContext(p.ctx, cfg.Command[0], cfg.Command[1:]...)
+		cmd.Env = os.Environ()
+		for k, v := range cfg.Env {
+			cmd.Env = append(cmd.Env, k+"="+v)
+		}
+
+		return &mcp.CommandTransport{Command: cmd}
+	}
+
+	httpClient := &http.Client{
+		Transport: &headerTransport{headers: cfg.Headers, base: http.DefaultTransport},
+	}
+
+	if cfg.Transport == "sse" {
+		return &mcp.SSEClientTransport{Endpoint: cfg.URL, HTTPClient: httpClient}
+	}
+
+	return &mcp.StreamableClientTransport{Endpoint: cfg.URL, HTTPClient: httpClient}
+}
+
+// syncUpstream re-lists upstream features and updates the local registry
+func (p *Plugin) syncUpstream(ctx context.Context, up *upstream) error {
+	var tools []*mcp.Tool
+	for tool, err := range up.session.Tools(ctx, nil) {
+		if err != nil {
+			return fmt.Errorf("failed to list tools: %w", err)
+		}
+		tools = append(tools, tool)
+	}
+
+	// Features are optional, servers without them reject the list calls
+	var prompts []*mcp.Prompt
+	if caps := up.session.InitializeResult().Capabilities; caps != nil && caps.Prompts != nil {
+		for prompt, err := range up.session.Prompts(ctx, nil) {
+			if err != nil {
+				return fmt.Errorf("failed to list prompts: %w", err)
+			}
+			prompts = append(prompts, prompt)
+		}
+	}
+
+	var resources []*mcp.Resource
+	if caps := up.session.InitializeResult().Capabilities; caps != nil && caps.Resources != nil {
+		for resource, err := range up.session.Resources(ctx, nil) {
+			if err != nil {
+				return fmt.Errorf("failed to list resources: %w", err)
+			}
+			resources = append(resources, resource)
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	toolsChanged := p.syncUpstreamTools(up, tools)
+	p.syncUpstreamPrompts(up, prompts)
+	p.syncUpstreamResources(up, resources)
+
+	if toolsChanged && p.cfg.Tools.NotifyClientsOnChange {
+		p.notifyToolsChanged()
+	}
+
+	return nil
+}
+
+// syncUpstreamTools replaces the upstream's tools in the registry, must be called under lock
+func (p *Plugin) syncUpstreamTools(up *upstream, tools []*mcp.Tool) bool {
+	changed := false
+	seen := make(map[string]struct{}, len(tools))
+
+	for _, t := range tools {
+		name := tenantName(up.cfg.Tenant, p.qualifiedToolName(up.cfg.Prefix, t.Name))
+
+		if entry, exists := p.tools[name]; exists && entry.Source != ToolSourceUpstream {
+			p.log.Warn("upstream tool collides with registered tool",
+				zap.String("upstream", up.name),
+				zap.String("tool", name),
+			)
+			continue
+		}
+
+		schema, _ := t.InputSchema.(map[string]interface{})
+		if schema["type"] != "object" {
+			p.log.Warn("upstream tool has no object input schema",
+				zap.String("upstream", up.name),
+				zap.String("tool", t.Name),
+			)
+			continue
+		}
+
+		tool := *t
+		tool.Name = name
+		p.addTool(&tool, p.upstreamToolHandler(up, t.Name))
+
+		now := time.Now()
+		registeredAt := now
+		if entry, exists := p.tools[name]; exists {
+			registeredAt = entry.RegisteredAt
+		} else {
+			p.publish(&BusEvent{
+				Event:     BusToolRegistered,
+				Tool:      name,
+				Source:    ToolSourceUpstream,
+				Namespace: up.cfg.Prefix,
+				Tenant:    up.cfg.Tenant,
+			})
+		}
+
+		p.setTool(name, &toolEntry{
+			Tool:         &tool,
+			Namespace:    up.cfg.Prefix,
+			Tenant:       up.cfg.Tenant,
+			Schema:       schema,
+			Source:       ToolSourceUpstream,
+			RegisteredAt: registeredAt,
+			UpdatedAt:    now,
+		})
+
+		up.tools[name] = t.Name
+		seen[name] = struct{}{}
+		changed = true
+	}
+
+	// Drop tools the upstream no longer offers
+	for name := range up.tools {
+		if _, ok := seen[name]; ok {
+			continue
+		}
+		p.deleteTool(name)
+		delete(up.tools, name)
+		changed = true
+	}
+
+	return changed
+}
+
+// syncUpstreamPrompts replaces the upstream's prompts, must be called under lock
+func (p *Plugin) syncUpstreamPrompts(up *upstream, prompts []*mcp.Prompt) {
+	seen := make(map[string]struct{}, len(prompts))
+
+	for _, pr := range prompts {
+		name := tenantName(up.cfg.Tenant, p.qualifiedToolName(up.cfg.Prefix, pr.Name))
+
+		prompt := *pr
+		prompt.Name = name
+		p.addPrompt(&prompt, p.upstreamPromptHandler(up, pr.Name))
+
+		up.prompts[name] = pr.Name
+		seen[name] = struct{}{}
+	}
+
+	for name := range up.prompts {
+		if _, ok := seen[name]; !ok {
+			p.removeFeature(featurePrompt + name)
+			delete(up.prompts, name)
+		}
+	}
+}
+
+// syncUpstreamResources replaces the upstream's resources, must be called under lock
+func (p *Plugin) syncUpstreamResources(up *upstream, resources []*mcp.Resource) {
+	seen := make(map[string]struct{}, len(resources))
+
+	for _, res := range resources {
+		if _, ok := p.resources[res.URI]; ok {
+			p.log.Warn("upstream resource collides with declared resource",
+				zap.String("upstream", up.name),
+				zap.String("uri", res.URI),
+			)
+			continue
+		}
+
+		resource := *res
+		resource.Name = p.qualifiedToolName(up.cfg.Prefix, res.Name)
+		p.addResource(&resource, p.upstreamResourceHandler(up))
+
+		up.resources[res.URI] = struct{}{}
+		seen[res.URI] = struct{}{}
+	}
+
+	for uri := range up.resources {
+		if _, ok := seen[uri]; !ok {
+			p.removeFeature(featureResource + uri)
+			delete(up.resources, uri)
+		}
+	}
+}
+
+// upstreamToolHandler proxies a tool call to the upstream server
+func (p *Plugin) upstreamToolHandler(up *upstream, name string) mcp.ToolHandler {
+	return func(ctx context.Context, req *mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		params := &mcp.CallToolParams{
+			Meta: req.Params.Meta,
+			Name: name,
+		}
+		if len(req.Params.Arguments) > 0 {
+			params.Arguments = req.Params.Arguments
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, up.cfg.Timeout)
+		defer cancel()
+
+		p.log.Debug("proxying tool call to upstream",
+			zap.String("upstream", up.name),
+			zap.String("tool", name),
+		)
+
+		return up.session.CallTool(ctx, params)
+	}
+}
+
+// upstreamPromptHandler proxies a prompt request to the upstream server
+func (p *Plugin) upstreamPromptHandler(up *upstream, name string) mcp.PromptHandler {
+	return func(ctx context.Context, req *mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, up.cfg.Timeout)
+		defer cancel()
+
+		return up.session.GetPrompt(ctx, &mcp.GetPromptParams{
+			Meta:      req.Params.Meta,
+			Name:      name,
+			Arguments: req.Params.Arguments,
+		})
+	}
+}
+
+// upstreamResourceHandler proxies a resource read to the upstream server
+func (p *Plugin) upstreamResourceHandler(up *upstream) mcp.ResourceHandler {
+	return func(ctx context.Context, req *mcp.ReadResourceRequest) (*mcp.ReadResourceResult, error) {
+		ctx, cancel := context.WithTimeout(ctx, up.cfg.Timeout)
+		defer cancel()
+
+		return up.session.ReadResource(ctx, &mcp.ReadResourceParams{
+			Meta: req.Params.Meta,
+			URI:  req.Params.URI,
+		})
+	}
+}
+
+// closeUpstreams disconnects from all upstream servers and client connections
+func (p *Plugin) closeUpstreams() {
+	p.mu.RLock()
+	upstreams := make([]*upstream, 0, len(p.upstreams)+len(p.clients))
+	for _, up := range p.upstreams {
+		upstreams = append(upstreams, up)
+	}
+	for _, up := range p.clients {
+		upstreams = append(upstreams, up)
+	}
+	p.mu.RUnlock()
+
+	for _, up := range upstreams {
+		if err := up.session.Close(); err != nil {
+			p.log.Debug("failed to close upstream session",
+				zap.String("upstream", up.name),
+				zap.Error(err),
+			)
+		}
+	}
+}
+
+// headerTransport adds configured headers to upstream HTTP requests
+type headerTransport struct {
+	headers map[string]string
+	base    http.RoundTripper
+}
+
+func (t *headerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	if len(t.headers) == 0 {
+		return t.base.RoundTrip(r)
+	}
+
+	r = r.Clone(r.Context())
+	for k, v := range t.headers {
+		r.Header.Set(k, v)
+	}
+
+	return t.base.RoundTrip(r)
+}
@@ -3,10 +3,23 @@ package mcp
 import (
 	"crypto/rand"
 	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/modelcontextprotocol/go-sdk/jsonrpc"
+	"github.com/modelcontextprotocol/go-sdk/mcp"
 )
 
+// generateToken generates a random session token
+func generateToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
 // generateSessionID generates a unique session ID
 func generateSessionID() string {
 	b := make([]byte, 16)
@@ -17,6 +30,51 @@ func generateSessionID() string {
 	return hex.EncodeToString(b)
 }
 
+// newJSONRPCError builds an error the SDK sends as a JSON-RPC error response.
+// The SDK keeps its wire error type internal, so it is decoded from a response.
+func newJSONRPCError(code int64, message string, data json.RawMessage) error {
+	raw, err := json.Marshal(map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      0,
+		"error":   &ToolError{Code: code, Message: message, Data: data},
+	})
+	if err != nil {
+		return fmt.Errorf("%s", message)
+	}
+
+	msg, err := jsonrpc.DecodeMessage(raw)
+	if err != nil {
+		return fmt.Errorf("%s", message)
+	}
+
+	resp, ok := msg.(*jsonrpc.Response)
+	if !ok || resp.Error == nil {
+		return fmt.Errorf("%s", message)
+	}
+
+	return resp.Error
+}
+
+// toolErrorResult builds a tool result reporting an error to the model
+func toolErrorResult(err error) *mcp.CallToolResult {
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: err.Error()}},
+		IsError: true,
+	}
+}
+
+// jsonResult builds a tool result with a JSON encoded value as text
+func jsonResult(v interface{}) (*mcp.CallToolResult, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return &mcp.CallToolResult{
+		Content: []mcp.Content{&mcp.TextContent{Text: string(data)}},
+	}, nil
+}
+
 // boolPtr returns a pointer to a boolean value
 func boolPtr(b bool) *bool {
 	return &b
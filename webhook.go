@@ -0,0 +1,154 @@
+package mcp
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/modelcontextprotocol/go-sdk/mcp"
+	"go.uber.org/zap"
+)
+
+// Webhook notification types
+const (
+	webhookMessage         = "message"
+	webhookResourceUpdated = "resource_updated"
+)
+
+// notificationResourceUpdated is the method of resource update notifications
+const notificationResourceUpdated = "notifications/resources/updated"
+
+// notificationMessage is the method of log message notifications
+const notificationMessage = "notifications/message"
+
+// loggingLevels are the MCP logging levels accepted for messages
+var loggingLevels = []string{"debug", "info", "notice", "warning", "error", "critical", "alert", "emergency"}
+
+// maxWebhookBodySize limits the size of posted events
+const maxWebhookBodySize = 1 << 20
+
+// serveWebhook turns a posted event into notifications to the selected sessions
+func (p *Plugin) serveWebhook(w http.ResponseWriter, r *http.Request) {
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(p.cfg.Webhooks.Token)) != 1 {
+		p.errorCounts.add(errorClassAuth)
+		writeJSONError(w, http.StatusUnauthorized, "invalid token")
+		return
+	}
+
+	var event WebhookNotification
+	if err := json.NewDecoder(io.LimitReader(r.Body, maxWebhookBodySize)).Decode(&event); err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid JSON body")
+		return
+	}
+
+	switch event.Type {
+	case webhookMessage:
+		if event.Level == "" {
+			event.Level = "info"
+		}
+		if !slices.Contains(loggingLevels, event.Level) {
+			writeJSONError(w, http.StatusBadRequest, "invalid level")
+			return
+		}
+	case webhookResourceUpdated:
+		if event.URI == "" {
+			writeJSONError(w, http.StatusBadRequest, "uri is required")
+			return
+		}
+	default:
+		writeJSONError(w, http.StatusBadRequest, "type must be 'message' or 'resource_updated'")
+		return
+	}
+
+	delivered := p.broadcastNotification(&event)
+
+	p.log.Debug("webhook notification queued",
+		zap.String("type", event.Type),
+		zap.Int("sessions", delivered),
+	)
+
+	writeJSON(w, http.StatusOK, map[string]int{"delivered": delivered})
+}
+
+// broadcastNotification queues an event for the selected sessions, buffers
+// it for those in their resume grace period and returns the number of
+// sessions it was queued or buffered for
+func (p *Plugin) broadcastNotification(event *WebhookNotification) int {
+	method := notificationResourceUpdated
+	if event.Type == webhookMessage {
+		method = notificationMessage
+	} else {
+		p.resourceCache.invalidate(event.URI)
+	}
+
+	deliver := func(ctx context.Context, ss *mcp.ServerSession) error {
+		return p.deliverWebhook(ctx, ss, event)
+	}
+
+	targets := p.webhookTargets(event.Sessions)
+	for _, ss := range targets {
+		p.enqueueNotification(ss, method, func(ctx context.Context) error {
+			return deliver(ctx, ss)
+		})
+	}
+
+	return len(targets) + p.bufferNotification(event.Sessions, method, deliver)
+}
+
+// webhookTargets returns the initialized sessions matching the selection
+func (p *Plugin) webhookTargets(sessionIDs []string) map[string]*mcp.ServerSession {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	targets := make(map[string]*mcp.ServerSession)
+
+	if len(sessionIDs) == 0 {
+		for id, info := range p.sessions.snapshot() {
+			if info.Session != nil && !isLocalTransport(info.Transport) {
+				targets[id] = info.Session
+			}
+		}
+		return targets
+	}
+
+	for _, id := range sessionIDs {
+		if info, ok := p.sessions.get(id); ok && info.Session != nil {
+			targets[id] = info.Session
+		}
+	}
+
+	return targets
+}
+
+// deliverWebhook sends the event to a single session
+func (p *Plugin) deliverWebhook(ctx context.Context, ss *mcp.ServerSession, event *WebhookNotification) (err error) {
+	defer p.recoverNotification(ss, &err)
+
+	switch event.Type {
+	case webhookMessage:
+		var data any = event.Data
+		if len(event.Data) == 0 {
+			data = nil
+		}
+		// Honors the level the client set with logging/setLevel
+		return ss.Log(ctx, &mcp.LoggingMessageParams{
+			Level:  mcp.LoggingLevel(event.Level),
+			Logger: event.Logger,
+			Data:   data,
+		})
+	default:
+		if p.sendNotification == nil {
+			return nil
+		}
+		_, err := p.sendNotification(ctx, notificationResourceUpdated, &mcp.ServerRequest[*mcp.ResourceUpdatedNotificationParams]{
+			Session: ss,
+			Params:  &mcp.ResourceUpdatedNotificationParams{URI: event.URI},
+		})
+		return err
+	}
+}